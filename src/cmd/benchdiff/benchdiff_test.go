@@ -0,0 +1,131 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMannWhitneyU(t *testing.T) {
+	tests := []struct {
+		x, y []float64
+		p    float64
+	}{
+		// Exact distribution.
+		{[]float64{1, 2, 3, 4, 5}, []float64{6, 7, 8, 9, 10}, 2.0 / 252},
+		{[]float64{6, 7, 8, 9, 10}, []float64{1, 2, 3, 4, 5}, 2.0 / 252},
+		{[]float64{1, 3, 5}, []float64{2, 4, 6}, 0.7},
+		{[]float64{1}, []float64{2}, 1},
+
+		// Normal approximation with correction for ties.
+		{[]float64{1, 1, 2, 2, 3}, []float64{2, 3, 3, 4, 4}, 0.05241162867102868},
+		{[]float64{5, 5, 5}, []float64{5, 5, 5}, 1},
+	}
+	for _, tt := range tests {
+		if p := mannWhitneyU(tt.x, tt.y); math.Abs(p-tt.p) > 1e-9 {
+			t.Errorf("mannWhitneyU(%v, %v) = %v, want %v", tt.x, tt.y, p, tt.p)
+		}
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	s := summarize([]float64{10, 2, 9, 3, 8, 4, 7, 5, 6, 1}, 0.05)
+	if want := (summary{n: 10, median: 5.5, lo: 2, hi: 9}); s != want {
+		t.Errorf("summarize = %+v, want %+v", s, want)
+	}
+	s = summarize([]float64{3, 1, 2, 5, 4}, 0.05)
+	if s.median != 3 || !math.IsInf(s.lo, -1) || !math.IsInf(s.hi, 1) {
+		t.Errorf("summarize of 5 values = %+v, want median 3 and infinite interval", s)
+	}
+	if n := minSamples(0.05); n != 6 {
+		t.Errorf("minSamples(0.05) = %d, want 6", n)
+	}
+}
+
+const textInput = `goos: linux
+goarch: amd64
+pkg: example.com/p
+BenchmarkFoo-8   	 1000000	      1047 ns/op	      64 B/op
+BenchmarkFoo-8   	 1000000	      1012 ns/op	      64 B/op
+--- BENCH: BenchmarkFoo-8
+    x_test.go:8: BenchmarkFoo-8   	 1	      1 ns/op
+BenchmarkBar-8   	     100	  10000000 ns/op	        42.5 widgets/op
+PASS
+ok  	example.com/p	3.012s
+`
+
+// jsonInput is the JSON form of textInput, as printed by
+// go test -json -benchmetrics.
+const jsonInput = `{"Action":"output","Package":"example.com/p","Output":"goos: linux\n"}
+{"Action":"output","Package":"example.com/p","Output":"BenchmarkFoo-8   \t 1000000\t      1047 ns/op\t      64 B/op\n"}
+{"Action":"metrics","Package":"example.com/p","Test":"BenchmarkFoo-8","Iterations":1000000,"Metrics":{"B/op":64,"ns/op":1047}}
+{"Action":"output","Package":"example.com/p","Output":"BenchmarkFoo-8   \t 1000000\t      1012 ns/op\t      64 B/op\n"}
+{"Action":"metrics","Package":"example.com/p","Test":"BenchmarkFoo-8","Iterations":1000000,"Metrics":{"B/op":64,"ns/op":1012}}
+{"Action":"output","Package":"example.com/p","Output":"BenchmarkBar-8   \t     100\t  10000000 ns/op\t        42.5 widgets/op\n"}
+{"Action":"metrics","Package":"example.com/p","Test":"BenchmarkBar-8","Iterations":100,"Metrics":{"ns/op":10000000,"widgets/op":42.5}}
+{"Action":"pass","Package":"example.com/p"}
+`
+
+func TestParse(t *testing.T) {
+	want := &resultSet{
+		keys:  []key{{"example.com/p", "BenchmarkFoo-8"}, {"example.com/p", "BenchmarkBar-8"}},
+		units: []string{"ns/op", "B/op", "widgets/op"},
+		values: map[key]map[string][]float64{
+			{"example.com/p", "BenchmarkFoo-8"}: {"ns/op": {1047, 1012}, "B/op": {64, 64}},
+			{"example.com/p", "BenchmarkBar-8"}: {"ns/op": {10000000}, "widgets/op": {42.5}},
+		},
+	}
+
+	// Without metrics events, the results come from the output.
+	var noMetrics []string
+	for _, line := range strings.SplitAfter(jsonInput, "\n") {
+		if !strings.Contains(line, `"metrics"`) {
+			noMetrics = append(noMetrics, line)
+		}
+	}
+
+	for name, in := range map[string]string{
+		"text":     textInput,
+		"json":     jsonInput,
+		"jsonText": strings.Join(noMetrics, ""),
+	} {
+		rs, err := parse([]byte(in))
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		if !reflect.DeepEqual(rs, want) {
+			t.Errorf("%s: got %+v, want %+v", name, rs, want)
+		}
+	}
+}
+
+func TestCompare(t *testing.T) {
+	old, new := newResultSet(), newResultSet()
+	for i := 0; i < 6; i++ {
+		old.add(key{"", "BenchmarkFast"}, "ns/op", 1000+float64(i))
+		new.add(key{"", "BenchmarkFast"}, "ns/op", 800+float64(i))
+		old.add(key{"", "BenchmarkSame"}, "ns/op", 50+float64(i))
+		new.add(key{"", "BenchmarkSame"}, "ns/op", 50+float64(5-i))
+	}
+	new.add(key{"", "BenchmarkNew"}, "ns/op", 1.5)
+
+	var buf bytes.Buffer
+	compare(&buf, old, new, 0.05)
+	want := `name  old ns/op   new ns/op   delta
+Fast  1.00k ± 0%  0.80k ± 0%  -19.95%  (p=0.002 n=6+6)
+Same  52.5 ± 5%   52.5 ± 5%   ~        (p=1.000 n=6+6)
+New               1.50 ±∞
+
+±∞: need >= 6 samples for confidence interval at level 0.95
+`
+	if got := buf.String(); got != want {
+		t.Errorf("compare output:\n%s\nwant:\n%s", got, want)
+	}
+}
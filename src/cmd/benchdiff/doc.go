@@ -0,0 +1,49 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+/*
+Benchdiff compares two sets of benchmark results and reports, for each
+benchmark and metric, whether the results changed in a statistically
+significant way.
+
+Usage:
+
+	go tool benchdiff [-alpha α] old.txt new.txt
+
+Each file holds the results of one or more runs of the benchmarks,
+usually obtained by running the same benchmarks several times:
+
+	go test -run=NONE -bench=. -count=10 > old.txt
+	(make changes)
+	go test -run=NONE -bench=. -count=10 > new.txt
+
+The files may contain the text printed by 'go test -bench' or the JSON
+stream printed by 'go test -json'. When the JSON stream was produced
+with 'go test -json -benchmetrics', benchdiff reads the results from
+the "metrics" events; otherwise it reads the benchmark result lines
+from the test output.
+
+For each metric reported by the benchmarks, such as ns/op, B/op or a
+custom metric added with b.ReportMetric, benchdiff prints a table
+like:
+
+	name      old ns/op     new ns/op     delta
+	Encode-8  1.25k ± 2%    1.08k ± 1%    -13.60%  (p=0.000 n=10+10)
+	Decode-8  3.40k ± 1%    3.41k ± 2%    ~        (p=0.684 n=10+10)
+
+Each value is the median of the runs, followed by the 95% confidence
+interval of the median, expressed as the largest distance from the
+median to either end of the interval. The interval is computed from
+the order statistics of the runs and so makes no assumption about
+their distribution. At the 95% level it needs at least six runs;
+with fewer, benchdiff reports it as ±∞.
+
+The delta column gives the change of the median. To decide whether
+the change is significant, benchdiff applies the Mann-Whitney U test to
+the two sets of runs. If the resulting p-value is at least the
+significance level set by the -alpha flag (0.05 by default), the
+change is reported as ~, meaning that the results do not show a
+difference. The level of the confidence intervals is 1-α.
+*/
+package main
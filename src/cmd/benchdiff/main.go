@@ -0,0 +1,205 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"cmd/internal/objabi"
+)
+
+var flagAlpha = flag.Float64("alpha", 0.05, "consider changes significant if p < `α`")
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: go tool benchdiff [-alpha α] old.txt new.txt\n")
+	flag.PrintDefaults()
+	os.Exit(2)
+}
+
+func main() {
+	objabi.AddVersionFlag()
+	flag.Usage = usage
+	flag.Parse()
+	if flag.NArg() != 2 {
+		usage()
+	}
+	if !(0 < *flagAlpha && *flagAlpha < 1) {
+		fatalf("-alpha must be between 0 and 1")
+	}
+
+	old := readFile(flag.Arg(0))
+	new := readFile(flag.Arg(1))
+	var buf bytes.Buffer
+	compare(&buf, old, new, *flagAlpha)
+	os.Stdout.Write(buf.Bytes())
+}
+
+func fatalf(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, "benchdiff: "+format+"\n", args...)
+	os.Exit(1)
+}
+
+func readFile(file string) *resultSet {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		fatalf("%v", err)
+	}
+	rs, err := parse(data)
+	if err != nil {
+		fatalf("%s: %v", file, err)
+	}
+	if len(rs.keys) == 0 {
+		fatalf("%s: no benchmark results found", file)
+	}
+	return rs
+}
+
+// compare writes to w one table for each metric and package,
+// comparing the results in old with those in new.
+func compare(w io.Writer, old, new *resultSet, alpha float64) {
+	units := append([]string(nil), old.units...)
+	for _, u := range new.units {
+		if !contains(units, u) {
+			units = append(units, u)
+		}
+	}
+	keys := append([]key(nil), old.keys...)
+	for _, k := range new.keys {
+		if old.values[k] == nil {
+			keys = append(keys, k)
+		}
+	}
+	var pkgs []string
+	for _, k := range keys {
+		if !contains(pkgs, k.pkg) {
+			pkgs = append(pkgs, k.pkg)
+		}
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	needMore := false
+	first := true
+	for _, unit := range units {
+		for _, pkg := range pkgs {
+			var rows []key
+			for _, k := range keys {
+				if k.pkg == pkg && (old.values[k][unit] != nil || new.values[k][unit] != nil) {
+					rows = append(rows, k)
+				}
+			}
+			if len(rows) == 0 {
+				continue
+			}
+			if !first {
+				fmt.Fprintln(tw)
+			}
+			first = false
+			if pkg != "" {
+				fmt.Fprintf(tw, "pkg: %s\n", pkg)
+			}
+			fmt.Fprintf(tw, "name\told %s\tnew %s\tdelta\n", unit, unit)
+			for _, k := range rows {
+				row, inf := compareRow(old.values[k][unit], new.values[k][unit], alpha)
+				needMore = needMore || inf
+				line := displayName(k.name) + "\t" + strings.Join(row, "\t")
+				fmt.Fprintf(tw, "%s\n", strings.TrimRight(line, "\t"))
+			}
+		}
+	}
+	tw.Flush()
+	if needMore {
+		fmt.Fprintf(w, "\n±∞: need >= %d samples for confidence interval at level %v\n", minSamples(alpha), 1-alpha)
+	}
+}
+
+// compareRow returns the old, new and delta cells of one row of
+// a table. It reports whether either confidence interval is unknown.
+func compareRow(old, new []float64, alpha float64) (row []string, inf bool) {
+	var so, sn summary
+	ref := 0.0
+	if new != nil {
+		sn = summarize(new, alpha)
+		ref = sn.median
+	}
+	if old != nil {
+		so = summarize(old, alpha)
+		ref = so.median
+	}
+	format := newScaler(ref)
+
+	cell := func(s summary) string {
+		if s.n == 0 {
+			return ""
+		}
+		if math.IsInf(s.lo, 0) {
+			inf = true
+			return format(s.median) + " ±∞"
+		}
+		if s.median == 0 {
+			return format(s.median)
+		}
+		d := math.Max(s.median-s.lo, s.hi-s.median)
+		return fmt.Sprintf("%s ± %.0f%%", format(s.median), 100*d/math.Abs(s.median))
+	}
+	row = []string{cell(so), cell(sn), ""}
+	if so.n == 0 || sn.n == 0 {
+		return row, inf
+	}
+
+	p := mannWhitneyU(old, new)
+	delta := "~"
+	if p < alpha {
+		if so.median == 0 {
+			delta = "?"
+		} else {
+			delta = fmt.Sprintf("%+.2f%%", 100*(sn.median-so.median)/math.Abs(so.median))
+		}
+	}
+	row[2] = fmt.Sprintf("%s\t(p=%.3f n=%d+%d)", delta, p, so.n, sn.n)
+	return row, inf
+}
+
+// displayName returns the name of a benchmark as shown in the tables,
+// without the Benchmark prefix.
+func displayName(name string) string {
+	if s := strings.TrimPrefix(name, "Benchmark"); s != "" {
+		return s
+	}
+	return name
+}
+
+// newScaler returns a function that formats values of the same
+// magnitude as v with at least three significant digits, using
+// the SI prefix k, M, G or T for large values.
+func newScaler(v float64) func(float64) string {
+	scale, prefix := 1.0, ""
+	for _, p := range []struct {
+		scale  float64
+		prefix string
+	}{{1e12, "T"}, {1e9, "G"}, {1e6, "M"}, {1e3, "k"}} {
+		if math.Abs(v) >= p.scale {
+			scale, prefix = p.scale, p.prefix
+			break
+		}
+	}
+	prec := 0
+	if x := math.Abs(v) / scale; x > 0 {
+		prec = 2 - int(math.Floor(math.Log10(x)))
+		if prec < 0 {
+			prec = 0
+		}
+	}
+	return func(x float64) string {
+		return fmt.Sprintf("%.*f%s", prec, x/scale, prefix)
+	}
+}
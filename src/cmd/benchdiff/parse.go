@@ -0,0 +1,224 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// A key identifies a benchmark.
+type key struct {
+	pkg  string // import path of the package, if known
+	name string // name as printed, including any -GOMAXPROCS suffix
+}
+
+// A resultSet holds the benchmark results read from one file.
+type resultSet struct {
+	keys   []key // in the order first seen
+	units  []string
+	values map[key]map[string][]float64 // values[key][unit] lists the values of all runs
+}
+
+func newResultSet() *resultSet {
+	return &resultSet{values: make(map[key]map[string][]float64)}
+}
+
+// add records the value v of the metric unit for one run of benchmark k.
+func (rs *resultSet) add(k key, unit string, v float64) {
+	m := rs.values[k]
+	if m == nil {
+		m = make(map[string][]float64)
+		rs.values[k] = m
+		rs.keys = append(rs.keys, k)
+	}
+	if !contains(rs.units, unit) {
+		rs.units = append(rs.units, unit)
+	}
+	m[unit] = append(m[unit], v)
+}
+
+func contains(list []string, s string) bool {
+	for _, x := range list {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
+
+// parse reads benchmark results from data, which holds either the
+// output of 'go test -bench' or the JSON stream of 'go test -json'.
+func parse(data []byte) (*resultSet, error) {
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		return parseJSON(data)
+	}
+	rs := newResultSet()
+	parseText(rs, "", data)
+	return rs, nil
+}
+
+// parseText adds to rs the results in the benchmark output text.
+// Lines of the form "pkg: path" set the package of the results that
+// follow; pkg is the package to assume before any such line.
+func parseText(rs *resultSet, pkg string, text []byte) {
+	s := bufio.NewScanner(bytes.NewReader(text))
+	s.Buffer(nil, 1<<20)
+	for s.Scan() {
+		line := s.Text()
+		if strings.HasPrefix(line, "pkg: ") {
+			pkg = strings.TrimSpace(line[len("pkg: "):])
+			continue
+		}
+		name, metrics, ok := parseBenchLine(line)
+		if !ok {
+			continue
+		}
+		for _, m := range metrics {
+			rs.add(key{pkg, name}, m.unit, m.value)
+		}
+	}
+}
+
+type metric struct {
+	unit  string
+	value float64
+}
+
+// parseBenchLine parses a benchmark result line like
+//
+//	BenchmarkFoo-8   	 1000000	      1234 ns/op	      64 B/op
+//
+// and returns the benchmark name and the value-unit pairs.
+func parseBenchLine(line string) (name string, metrics []metric, ok bool) {
+	f := strings.Fields(line)
+	if len(f) < 4 || len(f)%2 != 0 || !isBenchmarkName(f[0]) {
+		return "", nil, false
+	}
+	if _, err := strconv.ParseInt(f[1], 10, 64); err != nil {
+		return "", nil, false
+	}
+	for i := 2; i < len(f); i += 2 {
+		v, err := strconv.ParseFloat(f[i], 64)
+		if err != nil {
+			return "", nil, false
+		}
+		metrics = append(metrics, metric{f[i+1], v})
+	}
+	return f[0], metrics, true
+}
+
+// isBenchmarkName reports whether s is the name of a benchmark:
+// "Benchmark" followed by nothing or by a character other than
+// a lower-case letter.
+func isBenchmarkName(s string) bool {
+	if !strings.HasPrefix(s, "Benchmark") {
+		return false
+	}
+	rest := s[len("Benchmark"):]
+	return rest == "" || !('a' <= rest[0] && rest[0] <= 'z')
+}
+
+// event is the subset of the test2json events used by benchdiff.
+type event struct {
+	Action     string
+	Package    string
+	Test       string
+	Output     string
+	Iterations int64
+	Metrics    map[string]float64
+}
+
+// parseJSON reads results from a 'go test -json' stream. It uses the
+// "metrics" events if there are any, and the output of each package
+// otherwise.
+func parseJSON(data []byte) (*resultSet, error) {
+	var events []event
+	dec := json.NewDecoder(bytes.NewReader(data))
+	for {
+		var e event
+		if err := dec.Decode(&e); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("reading JSON: %v", err)
+		}
+		events = append(events, e)
+	}
+
+	rs := newResultSet()
+	useMetrics := false
+	for _, e := range events {
+		if e.Action == "metrics" {
+			useMetrics = true
+			break
+		}
+	}
+	if useMetrics {
+		for _, e := range events {
+			if e.Action != "metrics" {
+				continue
+			}
+			k := key{e.Package, e.Test}
+			for _, unit := range sortedUnits(e.Metrics) {
+				rs.add(k, unit, e.Metrics[unit])
+			}
+		}
+		return rs, nil
+	}
+
+	// Output events of different packages may be interleaved,
+	// so reassemble the output of each package first.
+	var pkgs []string
+	output := make(map[string]*bytes.Buffer)
+	for _, e := range events {
+		if e.Action != "output" {
+			continue
+		}
+		b := output[e.Package]
+		if b == nil {
+			b = new(bytes.Buffer)
+			output[e.Package] = b
+			pkgs = append(pkgs, e.Package)
+		}
+		b.WriteString(e.Output)
+	}
+	for _, pkg := range pkgs {
+		parseText(rs, pkg, output[pkg].Bytes())
+	}
+	return rs, nil
+}
+
+// sortedUnits returns the units of m in the order in which
+// the testing package prints them: ns/op and MB/s, the custom
+// metrics sorted by unit, and then B/op and allocs/op.
+func sortedUnits(m map[string]float64) []string {
+	var units, custom []string
+	for u := range m {
+		switch u {
+		case "ns/op", "MB/s", "B/op", "allocs/op":
+		default:
+			custom = append(custom, u)
+		}
+	}
+	sort.Strings(custom)
+	for _, u := range []string{"ns/op", "MB/s"} {
+		if _, ok := m[u]; ok {
+			units = append(units, u)
+		}
+	}
+	units = append(units, custom...)
+	for _, u := range []string{"B/op", "allocs/op"} {
+		if _, ok := m[u]; ok {
+			units = append(units, u)
+		}
+	}
+	return units
+}
@@ -0,0 +1,169 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"math"
+	"sort"
+)
+
+// A summary describes one sample: the values of one metric of one
+// benchmark across all the runs in a result file.
+type summary struct {
+	n      int
+	median float64
+	lo, hi float64 // confidence interval of the median; ±Inf if unknown
+}
+
+// summarize returns the summary of the values in x at confidence
+// level 1-alpha. It sorts x.
+func summarize(x []float64, alpha float64) summary {
+	sort.Float64s(x)
+	n := len(x)
+	s := summary{n: n, lo: math.Inf(-1), hi: math.Inf(1)}
+	if n%2 == 1 {
+		s.median = x[n/2]
+	} else {
+		s.median = (x[n/2-1] + x[n/2]) / 2
+	}
+
+	// The interval between the k'th smallest and the k'th largest
+	// value contains the median unless at least n-k+1 values fall on
+	// the same side of it, which happens with probability
+	// 2*P(B ≤ k-1) for B ~ Binomial(n, 1/2). Use the largest k
+	// that keeps this probability within alpha.
+	k := 0
+	for k+1 <= (n+1)/2 && 2*binomCDF(k, n) <= alpha {
+		k++
+	}
+	if k > 0 {
+		s.lo, s.hi = x[k-1], x[n-k]
+	}
+	return s
+}
+
+// binomCDF returns P(B ≤ k) for B ~ Binomial(n, 1/2).
+func binomCDF(k, n int) float64 {
+	p, c := 0.0, 1.0 // c is n choose i
+	for i := 0; i <= k; i++ {
+		p += c
+		c = c * float64(n-i) / float64(i+1)
+	}
+	return p / math.Pow(2, float64(n))
+}
+
+// minSamples returns the smallest number of values for which
+// summarize can compute a confidence interval at level 1-alpha.
+func minSamples(alpha float64) int {
+	n := 1
+	for 2*math.Pow(0.5, float64(n)) > alpha {
+		n++
+	}
+	return n
+}
+
+// mannWhitneyU returns the two-sided p-value of the Mann-Whitney U test
+// of the hypothesis that the values in x and y come from the same
+// distribution. The test uses the exact distribution of U for small
+// samples without ties and a normal approximation otherwise.
+func mannWhitneyU(x, y []float64) float64 {
+	n1, n2 := len(x), len(y)
+	if n1 == 0 || n2 == 0 {
+		return 1
+	}
+
+	// Rank the combined values, giving tied values their average rank.
+	type value struct {
+		v     float64
+		fromX bool
+	}
+	all := make([]value, 0, n1+n2)
+	for _, v := range x {
+		all = append(all, value{v, true})
+	}
+	for _, v := range y {
+		all = append(all, value{v, false})
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].v < all[j].v })
+	var r1, tieCorr float64
+	ties := false
+	for i := 0; i < len(all); {
+		j := i + 1
+		for j < len(all) && all[j].v == all[i].v {
+			j++
+		}
+		rank := float64(i+j+1) / 2 // average of the ranks i+1 to j
+		for _, v := range all[i:j] {
+			if v.fromX {
+				r1 += rank
+			}
+		}
+		if t := float64(j - i); t > 1 {
+			ties = true
+			tieCorr += t*t*t - t
+		}
+		i = j
+	}
+	u := r1 - float64(n1*(n1+1))/2
+
+	if !ties && n1+n2 <= 100 {
+		// P(U ≤ u) and P(U ≥ u) from the exact distribution.
+		dist := uDist(n1, n2)
+		var total, le, ge float64
+		for i, c := range dist {
+			total += c
+			if float64(i) <= u {
+				le += c
+			}
+			if float64(i) >= u {
+				ge += c
+			}
+		}
+		return math.Min(1, 2*math.Min(le, ge)/total)
+	}
+
+	n := float64(n1 + n2)
+	mu := float64(n1*n2) / 2
+	sigma := math.Sqrt(float64(n1*n2) / 12 * ((n + 1) - tieCorr/(n*(n-1))))
+	if sigma == 0 {
+		return 1
+	}
+	z := (math.Abs(u-mu) - 0.5) / sigma // with continuity correction
+	if z < 0 {
+		return 1
+	}
+	return math.Min(1, math.Erfc(z/math.Sqrt2))
+}
+
+// uDist returns the distribution of the Mann-Whitney U statistic for
+// samples of sizes n1 and n2 without ties: dist[u] is the number of
+// orderings of the combined samples for which U equals u.
+func uDist(n1, n2 int) []float64 {
+	// f[j][u] is the number of orderings of i values of x and j
+	// values of y with U = u, built up one value of x at a time:
+	// an ordering ends either with a value of x, which is larger
+	// than all j values of y, or with a value of y.
+	f := make([][]float64, n2+1)
+	for j := range f {
+		f[j] = make([]float64, n1*n2+1)
+		f[j][0] = 1 // i == 0
+	}
+	for i := 1; i <= n1; i++ {
+		prev := f
+		f = make([][]float64, n2+1)
+		for j := range f {
+			f[j] = make([]float64, n1*n2+1)
+			for u := range f[j] {
+				if u >= j {
+					f[j][u] = prev[j][u-j]
+				}
+				if j > 0 {
+					f[j][u] += f[j-1][u]
+				}
+			}
+		}
+	}
+	return f[n2]
+}
@@ -56,6 +56,11 @@ Flags:
 		Write a CPU profile for the compilation to file.
 	-dynlink
 		Allow references to Go symbols in shared libraries (experimental).
+	-embedcfg file
+		Read go:embed configuration from file.
+		This is required if any //go:embed directives are used.
+		The file is a JSON file mapping patterns to lists of filenames
+		and filenames to full path names.
 	-e
 		Remove the limit on the number of errors reported (default limit is 10).
 	-goversion string
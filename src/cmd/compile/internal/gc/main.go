@@ -43,22 +43,24 @@ var (
 )
 
 var (
-	Debug_append       int
-	Debug_checkptr     int
-	Debug_closure      int
-	Debug_compilelater int
-	debug_dclstack     int
-	Debug_libfuzzer    int
-	Debug_panic        int
-	Debug_slice        int
-	Debug_vlog         bool
-	Debug_wb           int
-	Debug_pctab        string
-	Debug_locationlist int
-	Debug_typecheckinl int
-	Debug_gendwarfinl  int
-	Debug_softfloat    int
-	Debug_defer        int
+	Debug_append          int
+	Debug_checkptr        int
+	Debug_closure         int
+	Debug_compilelater    int
+	debug_dclstack        int
+	Debug_libfuzzer       int
+	Debug_panic           int
+	Debug_slice           int
+	Debug_vlog            bool
+	Debug_wb              int
+	Debug_pctab           string
+	Debug_locationlist    int
+	Debug_typecheckinl    int
+	Debug_gendwarfinl     int
+	Debug_softfloat       int
+	Debug_defer           int
+	Debug_pgoinline       int
+	Debug_pgodevirtualize int
 )
 
 // Debug arguments.
@@ -91,6 +93,8 @@ var debugtab = []struct {
 	{"dwarfinl", "print information about DWARF inlined function creation", &Debug_gendwarfinl},
 	{"softfloat", "force compiler to emit soft-float code", &Debug_softfloat},
 	{"defer", "print information about defer compilation", &Debug_defer},
+	{"pgoinline", "enable profile-guided inlining", &Debug_pgoinline},
+	{"pgodevirtualize", "enable profile-guided devirtualization", &Debug_pgodevirtualize},
 }
 
 const debugHelpHeader = `usage: -d arg[,arg]* and arg is <key>[=<value>]
@@ -231,6 +235,7 @@ func Main(archInit func(*Arch)) {
 	flag.BoolVar(&flagDWARF, "dwarf", !Wasm, "generate DWARF symbols")
 	flag.BoolVar(&Ctxt.Flag_locationlists, "dwarflocationlists", true, "add location lists to DWARF in optimized mode")
 	flag.IntVar(&genDwarfInline, "gendwarfinl", 2, "generate DWARF inline info records")
+	objabi.Flagfn1("embedcfg", "read go:embed configuration from `file`", readEmbedCfg)
 	objabi.Flagcount("e", "no limit on number of errors reported", &Debug['e'])
 	objabi.Flagcount("h", "halt on error", &Debug['h'])
 	objabi.Flagfn1("importmap", "add `definition` of the form source=actual to import map", addImportMap)
@@ -249,6 +254,7 @@ func Main(archInit func(*Arch)) {
 	flag.StringVar(&outfile, "o", "", "write output to `file`")
 	flag.StringVar(&myimportpath, "p", "", "set expected package import `path`")
 	flag.BoolVar(&writearchive, "pack", false, "write to file.a instead of file.o")
+	objabi.Flagfn1("pgoprofile", "read profile from `file` for profile-guided optimization", readPGOProfile)
 	objabi.Flagcount("r", "debug generated wrappers", &Debug['r'])
 	if sys.RaceDetectorSupported(objabi.GOOS, objabi.GOARCH) {
 		flag.BoolVar(&flag_race, "race", false, "enable race detector")
@@ -411,6 +417,11 @@ func Main(archInit func(*Arch)) {
 		log.Fatalf("location lists requested but register mapping not available on %v", Ctxt.Arch.Name)
 	}
 
+	// Profile-guided optimizations are enabled by default
+	// when there is a profile.
+	Debug_pgoinline = 1
+	Debug_pgodevirtualize = 1
+
 	// parse -d argument
 	if debugstr != "" {
 	Split:
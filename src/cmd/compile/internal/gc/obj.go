@@ -115,6 +115,7 @@ func dumpdata() {
 	externs := len(externdcl)
 
 	dumpglobls()
+	dumpembeds()
 	addptabs()
 	addsignats(externdcl)
 	dumpsignats()
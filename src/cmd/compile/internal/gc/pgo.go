@@ -0,0 +1,405 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Profile-guided optimization.
+//
+// The -pgoprofile flag names a CPU profile in pprof format, normally
+// collected from a production build of the same program. The profile
+// is reduced to a set of weighted call edges: a caller, the line of
+// the call in the caller, and the function called. Functions inlined
+// into others in the profiled binary appear as callers and callees of
+// their own, just as they do in tracebacks. The heaviest edges that
+// together account for pgoHotCDF percent of the total weight are hot.
+//
+// Hot edges are used in two ways:
+//
+//	- A function called from a hot call site is inlined there if its
+//	  cost is at most inlineHotMaxBudget, instead of inlineMaxBudget.
+//	  Functions that are inlinable only thanks to the larger budget are
+//	  not inlined anywhere else.
+//	- An interface method call at a hot call site whose hot callee is
+//	  a concrete method is rewritten to test for that concrete type and
+//	  call the method directly, which can then be inlined (see
+//	  pgoDevirtualize).
+//
+// Call sites are identified by line number, so edits to a file make
+// the profile less effective for the functions in it, but never
+// incorrect.
+
+package gc
+
+import (
+	"cmd/compile/internal/types"
+	"cmd/internal/objabi"
+	"cmd/internal/src"
+	"fmt"
+	"internal/profile"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// pgoHotCDF is the percentage of the total call edge weight
+	// covered by the hot edges.
+	pgoHotCDF = 99
+
+	// inlineHotMaxBudget is the inlining budget of functions called
+	// from hot call sites.
+	inlineHotMaxBudget = 2000
+)
+
+// pgo holds the profile given with -pgoprofile, or nil.
+var pgo *pgoProfile
+
+// A pgoCallSite identifies a call site in a profile: the function
+// containing the call and the line of the call.
+type pgoCallSite struct {
+	caller string
+	line   int64
+}
+
+// A pgoProfile is the part of a profile used for optimization.
+type pgoProfile struct {
+	// hot maps each call site with hot edges to the callees of
+	// those edges, hottest first.
+	hot map[pgoCallSite][]string
+
+	// hotCallees is the set of functions called from a hot call site.
+	hotCallees map[string]bool
+}
+
+// A pgoEdge is a weighted call edge.
+type pgoEdge struct {
+	site   pgoCallSite
+	callee string
+	weight int64
+}
+
+// readPGOProfile reads the CPU profile in file and sets pgo.
+func readPGOProfile(file string) {
+	f, err := os.Open(file)
+	if err != nil {
+		log.Fatalf("-pgoprofile: %v", err)
+	}
+	defer f.Close()
+	p, err := profile.Parse(f)
+	if err != nil {
+		log.Fatalf("%s: %v", file, err)
+	}
+
+	index := -1
+	for i, st := range p.SampleType {
+		if st.Type == "cpu" && st.Unit == "nanoseconds" {
+			index = i
+		}
+	}
+	if index < 0 {
+		log.Fatalf("%s: not a CPU profile", file)
+	}
+
+	// Collect the call edges of the samples. Each sample's stack
+	// lists the leaf first; each location lists its innermost
+	// inlined function first.
+	weights := make(map[pgoCallSite]map[string]int64)
+	var total int64
+	for _, s := range p.Sample {
+		w := s.Value[index]
+		if w <= 0 {
+			continue
+		}
+		callee := ""
+		for _, loc := range s.Location {
+			if len(loc.Line) == 0 {
+				// Not symbolized; the stack is broken here.
+				callee = ""
+				continue
+			}
+			for _, line := range loc.Line {
+				if line.Function == nil {
+					callee = ""
+					continue
+				}
+				if callee != "" {
+					site := pgoCallSite{line.Function.Name, line.Line}
+					if weights[site] == nil {
+						weights[site] = make(map[string]int64)
+					}
+					weights[site][callee] += w
+					total += w
+				}
+				callee = line.Function.Name
+			}
+		}
+	}
+
+	var edges []pgoEdge
+	for site, callees := range weights {
+		for callee, w := range callees {
+			edges = append(edges, pgoEdge{site, callee, w})
+		}
+	}
+	sort.Slice(edges, func(i, j int) bool {
+		a, b := &edges[i], &edges[j]
+		if a.weight != b.weight {
+			return a.weight > b.weight
+		}
+		if a.site.caller != b.site.caller {
+			return a.site.caller < b.site.caller
+		}
+		if a.site.line != b.site.line {
+			return a.site.line < b.site.line
+		}
+		return a.callee < b.callee
+	})
+
+	pgo = &pgoProfile{
+		hot:        make(map[pgoCallSite][]string),
+		hotCallees: make(map[string]bool),
+	}
+	var cum int64
+	for _, e := range edges {
+		if cum*100 >= total*pgoHotCDF {
+			break
+		}
+		cum += e.weight
+		pgo.hot[e.site] = append(pgo.hot[e.site], e.callee)
+		pgo.hotCallees[e.callee] = true
+	}
+}
+
+// pgoLinkName returns the linker symbol name as it appears in
+// profiles, where the local package is named by its path.
+func pgoLinkName(name string) string {
+	if strings.HasPrefix(name, `"".`) {
+		name = objabi.PathToPrefix(myimportpath) + name[len(`""`):]
+	}
+	return name
+}
+
+// pgoCallSiteAt returns the call site of a call at pos in Curfn.
+// Calls in the bodies of functions inlined into Curfn belong to
+// the inlined function.
+func pgoCallSiteAt(pos src.XPos) pgoCallSite {
+	p := Ctxt.PosTable.Pos(pos)
+	var caller string
+	if ix := p.Base().InliningIndex(); ix >= 0 {
+		caller = pgoLinkName(Ctxt.InlTree.InlinedFunction(ix).Name)
+	} else {
+		caller = pgoLinkName(Curfn.Func.Nname.Sym.LinksymName())
+	}
+	return pgoCallSite{caller, int64(p.RelLine())}
+}
+
+// pgoHotCallee reports whether fn, an ODCLFUNC, is called from a hot
+// call site.
+func pgoHotCallee(fn *Node) bool {
+	if pgo == nil || Debug_pgoinline == 0 {
+		return false
+	}
+	return pgo.hotCallees[pgoLinkName(fn.Func.Nname.Sym.LinksymName())]
+}
+
+// pgoHotCall reports whether the call n to fn is on a hot edge.
+func pgoHotCall(n, fn *Node) bool {
+	if pgo == nil || Debug_pgoinline == 0 {
+		return false
+	}
+	callee := pgoLinkName(fn.Sym.LinksymName())
+	for _, c := range pgo.hot[pgoCallSiteAt(n.Pos)] {
+		if c == callee {
+			return true
+		}
+	}
+	return false
+}
+
+// pgoDevirtualize rewrites the interface method call n, if its call
+// site is hot and a hot callee is a method of a concrete type T that
+// implements the interface, into the equivalent of
+//
+//	if t, ok := recv.(T); ok {
+//		results = t.M(args)
+//	} else {
+//		results = recv.M(args)
+//	}
+//
+// and inlines the direct call. It returns the rewritten call as an
+// OINLCALL, or n if it does not apply.
+func pgoDevirtualize(n *Node, maxCost int32, inlMap map[*Node]bool) *Node {
+	if pgo == nil || Debug_pgodevirtualize == 0 || n.NoInline() {
+		return n
+	}
+	sel := n.Left
+	iface := sel.Left.Type
+
+	var typ *types.Type
+	for _, callee := range pgo.hot[pgoCallSiteAt(n.Pos)] {
+		t, method := pgoMethodType(callee)
+		if t == nil || method != sel.Sym.Name {
+			continue
+		}
+		var missing, have *types.Field
+		var ptr int
+		if implements(t, iface, &missing, &have, &ptr) {
+			typ = t
+			break
+		}
+	}
+	if typ == nil {
+		return n
+	}
+
+	if Debug['m'] != 0 {
+		fmt.Printf("%v: PGO devirtualizing %v to %v\n", n.Line(), sel, typ)
+	}
+
+	// The fallback call is n itself; it must not be rewritten again.
+	n.SetNoInline(true)
+
+	// Evaluate the receiver and arguments once, in order.
+	init := n.Ninit.Slice()
+	n.Ninit.Set(nil)
+	recv := temp(iface)
+	init = append(init, nod(ODCL, recv, nil), typecheck(nod(OAS, recv, sel.Left), ctxStmt))
+	sel.Left = recv
+	args := n.List.Slice()
+	for i, arg := range args {
+		a := temp(arg.Type)
+		init = append(init, nod(ODCL, a, nil), typecheck(nod(OAS, a, arg), ctxStmt))
+		args[i] = a
+	}
+
+	t := temp(typ)
+	ok := temp(types.Types[TBOOL])
+	dot := nodl(n.Pos, ODOTTYPE, recv, nil)
+	dot.Type = typ
+	as := nodl(n.Pos, OAS2, nil, nil)
+	as.List.Set2(t, ok)
+	as.Rlist.Set1(dot)
+	init = append(init, nod(ODCL, t, nil), nod(ODCL, ok, nil), typecheck(as, ctxStmt))
+
+	var retvars []*Node
+	for _, f := range sel.Type.Results().FieldSlice() {
+		r := temp(f.Type)
+		init = append(init, nod(ODCL, r, nil))
+		retvars = append(retvars, r)
+	}
+
+	call := nodl(n.Pos, OCALL, nodSym(OXDOT, t, sel.Sym), nil)
+	call.List.Set(args)
+	call.SetIsDDD(n.IsDDD())
+
+	nif := nodl(n.Pos, OIF, ok, nil)
+	nif.Nbody.Set1(pgoAssignResults(retvars, call))
+	nif.Rlist.Set1(pgoAssignResults(retvars, n))
+	nif = typecheck(nif, ctxStmt)
+
+	inlnodelist(nif.Nbody, maxCost, inlMap)
+	for _, n1 := range nif.Nbody.Slice() {
+		if n1.Op == OINLCALL {
+			inlconv2stmt(n1)
+		}
+	}
+
+	res := nodl(n.Pos, OINLCALL, nil, nil)
+	res.Ninit.Set(init)
+	res.Nbody.Set1(nif)
+	res.Rlist.Set(retvars)
+	res.Type = n.Type
+	res.SetTypecheck(1)
+	return res
+}
+
+// pgoAssignResults returns a statement assigning the results of call
+// to retvars.
+func pgoAssignResults(retvars []*Node, call *Node) *Node {
+	switch len(retvars) {
+	case 0:
+		return call
+	case 1:
+		return nod(OAS, retvars[0], call)
+	}
+	as := nod(OAS2, nil, nil)
+	as.List.Append(retvars...) // copy; inlconv2list modifies the OINLCALL's Rlist
+	as.Rlist.Set1(call)
+	return as
+}
+
+// pgoMethodType returns the type T or *T and the method name M named
+// by a profile function name of the form "pkg.T.M" or "pkg.(*T).M".
+// It returns nil if the name is not a method or if T is not a
+// package-level type known to this compilation.
+func pgoMethodType(name string) (*types.Type, string) {
+	slash := strings.LastIndex(name, "/")
+	dot := strings.Index(name[slash+1:], ".")
+	if dot < 0 {
+		return nil, ""
+	}
+	dot += slash + 1
+	prefix, name := name[:dot], name[dot+1:]
+
+	ptr := false
+	var typName string
+	if strings.HasPrefix(name, "(*") {
+		i := strings.Index(name, ").")
+		if i < 0 {
+			return nil, ""
+		}
+		ptr = true
+		typName, name = name[len("(*"):i], name[i+len(")."):]
+	} else {
+		i := strings.Index(name, ".")
+		if i < 0 {
+			return nil, ""
+		}
+		typName, name = name[:i], name[i+1:]
+	}
+	if typName == "" || name == "" || strings.Contains(name, ".") {
+		// Not a method; for example, a closure in a method.
+		return nil, ""
+	}
+
+	var pkg *types.Pkg
+	if prefix == objabi.PathToPrefix(myimportpath) {
+		pkg = localpkg
+	} else if pkg = types.LookupPkg(pgoPkgPath(prefix)); pkg == nil {
+		return nil, ""
+	}
+	s := pkg.Syms[typName]
+	if s == nil || s.Def == nil {
+		return nil, ""
+	}
+	n := resolve(asNode(s.Def))
+	if n.Op != OTYPE || n.Type == nil || n.Type.Sym != s || n.Type.IsInterface() {
+		return nil, ""
+	}
+	t := n.Type
+	if ptr {
+		t = types.NewPtr(t)
+	}
+	return t, name
+}
+
+// pgoPkgPath returns the package path for the symbol prefix s,
+// undoing the escaping done by objabi.PathToPrefix.
+func pgoPkgPath(s string) string {
+	if !strings.Contains(s, "%") {
+		return s
+	}
+	b := make([]byte, 0, len(s))
+	for i := 0; i < len(s); i++ {
+		if s[i] == '%' && i+3 <= len(s) {
+			if c, err := strconv.ParseUint(s[i+1:i+3], 16, 8); err == nil {
+				b = append(b, byte(c))
+				i += 2
+				continue
+			}
+		}
+		b = append(b, s[i])
+	}
+	return string(b)
+}
@@ -0,0 +1,202 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gc
+
+import (
+	"fmt"
+	"internal/profile"
+	"internal/testenv"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const pgoSrc = `package p
+
+type Adder interface {
+	Add(x, y int) int
+}
+
+type Add struct{ n int }
+
+func (a *Add) Add(x, y int) int {
+	s := x + y
+	s += a.n * 1
+	s ^= s >> 3
+	s += a.n * 2
+	s ^= s << 5
+	s += a.n * 3
+	s ^= s >> 3
+	s += a.n * 4
+	s ^= s << 5
+	s += a.n * 5
+	s ^= s >> 3
+	s += a.n * 6
+	s ^= s << 5
+	if s > 1000000 {
+		s -= 1000000
+	}
+	return s
+}
+
+type Sub struct{}
+
+func (Sub) Add(x, y int) int { return x - y }
+
+func big(x int) int {
+	x = x*31 + 1
+	x ^= x >> 3
+	x += x * 7
+	x ^= x << 5
+	x = x*31 + 2
+	x ^= x >> 3
+	x += x * 7
+	x ^= x << 5
+	x = x*31 + 3
+	x ^= x >> 3
+	x += x * 7
+	x ^= x << 5
+	x = x*31 + 4
+	x ^= x >> 3
+	x += x * 7
+	x ^= x << 5
+	return x
+}
+
+func run(a Adder, n int) int {
+	s := 0
+	for i := 0; i < n; i++ {
+		s = a.Add(s, i) // hot, devirtualized
+		s += big(i)     // hot, inlined
+	}
+	return s + big(n) // cold, not inlined
+}
+`
+
+// pgoLine returns the line number of the first line of pgoSrc
+// containing s.
+func pgoLine(t *testing.T, s string) int64 {
+	for i, line := range strings.Split(pgoSrc, "\n") {
+		if strings.Contains(line, s) {
+			return int64(i + 1)
+		}
+	}
+	t.Fatalf("%q not found in source", s)
+	return 0
+}
+
+// writePGOProfile writes a CPU profile with the given call edges
+// from p.run to the file name.
+func writePGOProfile(t *testing.T, name string, edges map[string]int64, lines map[string]int64) {
+	p := &profile.Profile{
+		SampleType: []*profile.ValueType{
+			{Type: "samples", Unit: "count"},
+			{Type: "cpu", Unit: "nanoseconds"},
+		},
+		PeriodType: &profile.ValueType{Type: "cpu", Unit: "nanoseconds"},
+		Period:     1,
+	}
+	funcs := make(map[string]*profile.Function)
+	location := func(fn string, line int64) *profile.Location {
+		f := funcs[fn]
+		if f == nil {
+			f = &profile.Function{ID: uint64(len(p.Function) + 1), Name: fn, SystemName: fn}
+			p.Function = append(p.Function, f)
+			funcs[fn] = f
+		}
+		l := &profile.Location{
+			ID:   uint64(len(p.Location) + 1),
+			Line: []profile.Line{{Function: f, Line: line}},
+		}
+		p.Location = append(p.Location, l)
+		return l
+	}
+	for callee, w := range edges {
+		p.Sample = append(p.Sample, &profile.Sample{
+			Location: []*profile.Location{location(callee, 1), location("p.run", lines[callee])},
+			Value:    []int64{w, w * 1e6},
+		})
+	}
+
+	f, err := os.Create(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Write(f); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPGO(t *testing.T) {
+	testenv.MustHaveGoBuild(t)
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "TestPGO")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	src := filepath.Join(dir, "p.go")
+	if err := ioutil.WriteFile(src, []byte(pgoSrc), 0644); err != nil {
+		t.Fatal(err)
+	}
+	prof := filepath.Join(dir, "cpu.pprof")
+	addLine := pgoLine(t, "a.Add(s, i)")
+	bigLine := pgoLine(t, "s += big(i)")
+	writePGOProfile(t, prof,
+		map[string]int64{"p.(*Add).Add": 1000, "p.big": 1000, "p.Sub.Add": 1},
+		map[string]int64{"p.(*Add).Add": addLine, "p.big": bigLine, "p.Sub.Add": addLine})
+
+	compile := func(args ...string) string {
+		args = append([]string{"tool", "compile", "-p", "p", "-m", "-o", filepath.Join(dir, "p.o")}, args...)
+		out, err := exec.Command(testenv.GoToolPath(t), append(args, src)...).CombinedOutput()
+		if err != nil {
+			t.Fatalf("go %s: %v\n%s", strings.Join(args, " "), err, out)
+		}
+		return string(out)
+	}
+
+	want := []string{
+		fmt.Sprintf("p.go:%d:6: can inline (*Add).Add", pgoLine(t, "func (a *Add) Add")),
+		fmt.Sprintf("p.go:%d:6: can inline big", pgoLine(t, "func big")),
+		fmt.Sprintf("p.go:%d:12: PGO devirtualizing a.Add to *Add", addLine),
+		fmt.Sprintf("p.go:%d:12: inlining call to (*Add).Add", addLine),
+		fmt.Sprintf("p.go:%d:11: inlining call to big", bigLine),
+	}
+	out := compile("-pgoprofile", prof)
+	for _, w := range want {
+		if !strings.Contains(out, w) {
+			t.Errorf("output with profile does not contain %q:\n%s", w, out)
+		}
+	}
+	if cold := fmt.Sprintf("p.go:%d:14: inlining call to big", pgoLine(t, "big(n)")); strings.Contains(out, cold) {
+		t.Errorf("cold call to big inlined:\n%s", out)
+	}
+
+	// Without the profile, the functions are over budget.
+	out = compile()
+	for _, w := range want {
+		if strings.Contains(out, w) {
+			t.Errorf("output without profile contains %q:\n%s", w, out)
+		}
+	}
+
+	// The optimizations can be turned off individually.
+	out = compile("-pgoprofile", prof, "-d", "pgodevirtualize=0")
+	if strings.Contains(out, "PGO devirtualizing") {
+		t.Errorf("output with -d pgodevirtualize=0 devirtualizes:\n%s", out)
+	}
+	out = compile("-pgoprofile", prof, "-d", "pgoinline=0")
+	if strings.Contains(out, "inlining call to big") {
+		t.Errorf("output with -d pgoinline=0 inlines big:\n%s", out)
+	}
+}
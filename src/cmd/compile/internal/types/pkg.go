@@ -69,6 +69,12 @@ func ImportedPkgList() []*Pkg {
 	return list
 }
 
+// LookupPkg returns the package with the given path,
+// or nil if there is no such package.
+func LookupPkg(path string) *Pkg {
+	return pkgMap[path]
+}
+
 type byPath []*Pkg
 
 func (a byPath) Len() int           { return len(a) }
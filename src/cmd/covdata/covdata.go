@@ -0,0 +1,229 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"internal/coverage"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"cmd/internal/objabi"
+)
+
+const usageMessage = "" +
+	`Usage: go tool covdata <mode> -i=<directories> [flags]
+
+The modes are:
+	percent   report coverage percentage of statements for each package
+	textfmt   convert coverage data to the legacy text profile format
+	merge     merge data files together into a new directory
+	subtract  subtract the coverage of one set of data files from another
+
+Examples:
+
+	go tool covdata percent -i=somedata1,somedata2
+	go tool covdata textfmt -i=somedata -o=profile.txt
+	go tool covdata merge -i=indir1,indir2 -o=outdir
+	go tool covdata subtract -i=indir1,indir2 -o=outdir
+
+Run "go tool covdata <mode> -help" for the flags of each mode.
+`
+
+func usage() {
+	fmt.Fprint(os.Stderr, usageMessage)
+	os.Exit(2)
+}
+
+// A mode is one of the covdata subcommands.
+type mode struct {
+	name    string
+	short   string // one-line description, for the -help output
+	needOut bool   // whether the mode requires the -o flag
+	run     func(inputs [][]*pod, out string) error
+}
+
+var modes = []*mode{
+	{name: "percent", short: "report coverage percentage of statements for each package", run: runPercent},
+	{name: "textfmt", short: "convert coverage data to the legacy text profile format", needOut: true, run: runTextfmt},
+	{name: "merge", short: "merge data files together into a new directory", needOut: true, run: runMerge},
+	{name: "subtract", short: "subtract the coverage of one set of data files from another", needOut: true, run: runSubtract},
+}
+
+func main() {
+	objabi.AddVersionFlag()
+	flag.Usage = usage
+	flag.Parse()
+	if flag.NArg() == 0 {
+		usage()
+	}
+
+	var m *mode
+	for _, mm := range modes {
+		if mm.name == flag.Arg(0) {
+			m = mm
+		}
+	}
+	if m == nil {
+		fmt.Fprintf(os.Stderr, "covdata: unknown mode %q\n", flag.Arg(0))
+		fmt.Fprintln(os.Stderr, `For usage information, run "go tool covdata -help"`)
+		os.Exit(2)
+	}
+
+	fs := flag.NewFlagSet(m.name, flag.ExitOnError)
+	in := fs.String("i", "", "comma-separated list of input directories")
+	var out *string
+	if m.needOut {
+		out = fs.String("o", "", "output file or directory")
+	}
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: go tool covdata %s -i=<directories> [flags]\n\n%s.\n\n", m.name, m.short)
+		fs.PrintDefaults()
+		os.Exit(2)
+	}
+	fs.Parse(flag.Args()[1:])
+	if *in == "" || fs.NArg() != 0 || (out != nil && *out == "") {
+		fs.Usage()
+	}
+
+	var inputs [][]*pod
+	for _, dir := range strings.Split(*in, ",") {
+		pods, err := readDir(dir)
+		if err != nil {
+			fatalf("%v", err)
+		}
+		inputs = append(inputs, pods)
+	}
+	var outName string
+	if out != nil {
+		outName = *out
+	}
+	if err := m.run(inputs, outName); err != nil {
+		fatalf("%v", err)
+	}
+}
+
+func fatalf(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, "covdata: "+format+"\n", args...)
+	os.Exit(1)
+}
+
+// A pod is the meta-data of one instrumented program together with
+// the counters of all the runs of that program found in one input
+// directory, merged into a single set.
+type pod struct {
+	dir    string // input directory
+	hash   string // hash of the meta-data
+	meta   *coverage.Meta
+	counts [][]uint32
+}
+
+// readDir reads the coverage data files in dir and returns one pod for
+// each meta-data file, sorted by hash.
+func readDir(dir string) ([]*pod, error) {
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	byHash := make(map[string]*pod)
+	var pods []*pod
+	for _, info := range infos {
+		name := info.Name()
+		if !strings.HasPrefix(name, coverage.MetaFilePref+".") {
+			continue
+		}
+		data, err := ioutil.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+		m, err := coverage.ParseMeta(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", filepath.Join(dir, name), err)
+		}
+		h := m.Hash()
+		if name != coverage.MetaFilePref+"."+h {
+			return nil, fmt.Errorf("%s: content does not match file name", filepath.Join(dir, name))
+		}
+		p := &pod{dir: dir, hash: h, meta: m, counts: make([][]uint32, len(m.Files))}
+		for i, f := range m.Files {
+			p.counts[i] = make([]uint32, len(f.Blocks))
+		}
+		byHash[h] = p
+		pods = append(pods, p)
+	}
+	for _, info := range infos {
+		name := info.Name()
+		if !strings.HasPrefix(name, coverage.CounterFilePref+".") {
+			continue
+		}
+		data, err := ioutil.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+		c, err := coverage.ParseCounters(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", filepath.Join(dir, name), err)
+		}
+		p := byHash[c.MetaHash]
+		if p == nil {
+			return nil, fmt.Errorf("%s: no meta-data file for hash %s", filepath.Join(dir, name), c.MetaHash)
+		}
+		if err := c.Check(p.meta); err != nil {
+			return nil, fmt.Errorf("%s: %v", filepath.Join(dir, name), err)
+		}
+		for i := range c.Counts {
+			mergeCounts(p.meta.Mode, p.counts[i], c.Counts[i])
+		}
+	}
+	if len(pods) == 0 {
+		return nil, fmt.Errorf("no coverage data files found in %s", dir)
+	}
+	sort.Slice(pods, func(i, j int) bool { return pods[i].hash < pods[j].hash })
+	return pods, nil
+}
+
+// mergeCounts merges the counters src into dst. In set mode a counter
+// only records whether its block ran, so the counters are ORed;
+// otherwise they are added, saturating at the largest counter value.
+func mergeCounts(mode string, dst, src []uint32) {
+	for i, n := range src {
+		switch {
+		case mode == "set":
+			if n != 0 {
+				dst[i] = 1
+			}
+		case uint64(dst[i])+uint64(n) > math.MaxUint32:
+			dst[i] = math.MaxUint32
+		default:
+			dst[i] += n
+		}
+	}
+}
+
+// flatten returns the pods of all the inputs.
+func flatten(inputs [][]*pod) []*pod {
+	var pods []*pod
+	for _, in := range inputs {
+		pods = append(pods, in...)
+	}
+	return pods
+}
+
+// checkMode returns the coverage mode shared by pods, or an error if
+// the pods were produced by programs built in different modes.
+func checkMode(pods []*pod) (string, error) {
+	mode := pods[0].meta.Mode
+	for _, p := range pods[1:] {
+		if p.meta.Mode != mode {
+			return "", fmt.Errorf("cannot combine coverage data with modes %q (%s) and %q (%s)", mode, pods[0].dir, p.meta.Mode, p.dir)
+		}
+	}
+	return mode, nil
+}
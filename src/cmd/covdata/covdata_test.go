@@ -0,0 +1,134 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"internal/coverage"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+var testMeta = &coverage.Meta{
+	Mode: "count",
+	Files: []coverage.FileMeta{
+		{Name: "example.com/p/a.go", Blocks: []coverage.Block{
+			{StartLine: 3, StartCol: 14, EndLine: 5, EndCol: 2, NumStmt: 1},
+			{StartLine: 7, StartCol: 14, EndLine: 9, EndCol: 2, NumStmt: 3},
+		}},
+		{Name: "example.com/q/b.go", Blocks: []coverage.Block{
+			{StartLine: 4, StartCol: 1, EndLine: 4, EndCol: 20, NumStmt: 2},
+		}},
+	},
+}
+
+// writeRun writes the meta-data and the counters of one run of the
+// program described by m into dir.
+func writeRun(t *testing.T, dir, suffix string, m *coverage.Meta, counts ...[]uint32) {
+	t.Helper()
+	h := m.Hash()
+	if err := ioutil.WriteFile(filepath.Join(dir, coverage.MetaFilePref+"."+h), m.Encode(), 0666); err != nil {
+		t.Fatal(err)
+	}
+	c := &coverage.Counters{MetaHash: h, Counts: counts}
+	if err := ioutil.WriteFile(filepath.Join(dir, coverage.CounterFilePref+"."+h+"."+suffix), c.Encode(), 0666); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMergeCounts(t *testing.T) {
+	dst := []uint32{0, 1, 2, math.MaxUint32 - 1}
+	mergeCounts("count", dst, []uint32{0, 2, 0, 5})
+	if want := []uint32{0, 3, 2, math.MaxUint32}; !reflect.DeepEqual(dst, want) {
+		t.Errorf("count mode: got %v, want %v", dst, want)
+	}
+	dst = []uint32{0, 1, 0}
+	mergeCounts("set", dst, []uint32{0, 1, 1})
+	if want := []uint32{0, 1, 1}; !reflect.DeepEqual(dst, want) {
+		t.Errorf("set mode: got %v, want %v", dst, want)
+	}
+}
+
+func TestReadDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "covdata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	writeRun(t, dir, "1.1", testMeta, []uint32{1, 0}, []uint32{0})
+	writeRun(t, dir, "2.2", testMeta, []uint32{2, 0}, []uint32{4})
+	pods, err := readDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pods) != 1 {
+		t.Fatalf("got %d pods, want 1", len(pods))
+	}
+	if want := [][]uint32{{3, 0}, {4}}; !reflect.DeepEqual(pods[0].counts, want) {
+		t.Errorf("got counts %v, want %v", pods[0].counts, want)
+	}
+
+	// Counters without their meta-data are an error.
+	other := &coverage.Meta{Mode: "count", Files: testMeta.Files[1:]}
+	c := &coverage.Counters{MetaHash: other.Hash(), Counts: [][]uint32{{1}}}
+	if err := ioutil.WriteFile(filepath.Join(dir, coverage.CounterFilePref+"."+other.Hash()+".3.3"), c.Encode(), 0666); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readDir(dir); err == nil {
+		t.Errorf("readDir succeeded with missing meta-data file")
+	}
+}
+
+func TestMakeProfile(t *testing.T) {
+	// Two programs that share the file b.go.
+	other := &coverage.Meta{Mode: "count", Files: testMeta.Files[1:]}
+	pods := []*pod{
+		{hash: testMeta.Hash(), meta: testMeta, counts: [][]uint32{{1, 0}, {2}}},
+		{hash: other.Hash(), meta: other, counts: [][]uint32{{3}}},
+	}
+	files, mode, err := makeProfile(pods)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mode != "count" {
+		t.Errorf("got mode %q, want count", mode)
+	}
+	if len(files) != 2 || files[0].name != "example.com/p/a.go" || files[1].name != "example.com/q/b.go" {
+		t.Fatalf("got files %v, want a.go and b.go", files)
+	}
+	if want := []uint32{5}; !reflect.DeepEqual(files[1].counts, want) {
+		t.Errorf("got b.go counts %v, want %v", files[1].counts, want)
+	}
+
+	pods[1].meta = &coverage.Meta{Mode: "set", Files: other.Files}
+	if _, _, err := makeProfile(pods); err == nil {
+		t.Errorf("makeProfile succeeded with mixed modes")
+	}
+}
+
+func TestSubtract(t *testing.T) {
+	dir, err := ioutil.TempDir("", "covdata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	in1 := []*pod{{hash: testMeta.Hash(), meta: testMeta, counts: [][]uint32{{1, 2}, {3}}}}
+	in2 := []*pod{{hash: testMeta.Hash(), meta: testMeta, counts: [][]uint32{{0, 1}, {0}}}}
+	if err := runSubtract([][]*pod{in1, in2}, dir); err != nil {
+		t.Fatal(err)
+	}
+	pods, err := readDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := [][]uint32{{1, 0}, {3}}; len(pods) != 1 || !reflect.DeepEqual(pods[0].counts, want) {
+		t.Errorf("got counts %v, want %v", pods[0].counts, want)
+	}
+}
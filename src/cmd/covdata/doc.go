@@ -0,0 +1,48 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+/*
+Covdata is a program for manipulating and generating reports from the
+coverage data files written by programs built with 'go build -cover'.
+
+A covered program writes its coverage data into the directory named by
+the GOCOVERDIR environment variable when it exits. Covdata reads one or
+more such directories, each given as a comma-separated list to the -i
+flag, and runs in one of the following modes:
+
+	percent   report coverage percentage of statements for each package
+	textfmt   convert coverage data to the legacy text profile format
+	merge     merge data files together into a new directory
+	subtract  subtract the coverage of one set of data files from another
+
+Report the coverage percentage for each package covered by the data in
+the directories somedata1 and somedata2:
+
+	go tool covdata percent -i=somedata1,somedata2
+
+Convert the data to the text profile format written by
+'go test -coverprofile', for use with 'go tool cover -func' or
+'go tool cover -html':
+
+	go tool covdata textfmt -i=somedata -o=profile.txt
+
+Merge the data from several runs, or from several programs, into a
+single new directory:
+
+	go tool covdata merge -i=indir1,indir2 -o=outdir
+
+Write into outdir the coverage of the statements that were executed in
+the runs recorded in indir1 but not in those recorded in indir2:
+
+	go tool covdata subtract -i=indir1,indir2 -o=outdir
+
+When merging counters, covdata ORs them together for programs built
+with -covermode=set and adds them up for the count and atomic modes.
+Data from programs built in different modes cannot be combined.
+
+For usage information, please see:
+	go help build
+	go tool covdata -help
+*/
+package main
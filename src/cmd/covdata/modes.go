@@ -0,0 +1,227 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"internal/coverage"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// runPercent implements 'go tool covdata percent'.
+func runPercent(inputs [][]*pod, _ string) error {
+	files, _, err := makeProfile(flatten(inputs))
+	if err != nil {
+		return err
+	}
+	type stmts struct{ covered, total int }
+	byPkg := make(map[string]*stmts)
+	var pkgs []string
+	for _, f := range files {
+		pkg := f.name
+		if i := strings.LastIndex(pkg, "/"); i >= 0 {
+			pkg = pkg[:i]
+		}
+		s := byPkg[pkg]
+		if s == nil {
+			s = new(stmts)
+			byPkg[pkg] = s
+			pkgs = append(pkgs, pkg)
+		}
+		for i, b := range f.blocks {
+			s.total += int(b.NumStmt)
+			if f.counts[i] != 0 {
+				s.covered += int(b.NumStmt)
+			}
+		}
+	}
+	sort.Strings(pkgs)
+	for _, pkg := range pkgs {
+		s := byPkg[pkg]
+		if s.total == 0 {
+			fmt.Printf("\t%s\t\tcoverage: [no statements]\n", pkg)
+			continue
+		}
+		fmt.Printf("\t%s\t\tcoverage: %.1f%% of statements\n", pkg, 100*float64(s.covered)/float64(s.total))
+	}
+	return nil
+}
+
+// runTextfmt implements 'go tool covdata textfmt', which writes the
+// profile format read by 'go tool cover'.
+func runTextfmt(inputs [][]*pod, out string) error {
+	files, mode, err := makeProfile(flatten(inputs))
+	if err != nil {
+		return err
+	}
+	f, err := os.Create(out)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	fmt.Fprintf(w, "mode: %s\n", mode)
+	for _, fp := range files {
+		for i, b := range fp.blocks {
+			fmt.Fprintf(w, "%s:%d.%d,%d.%d %d %d\n", fp.name, b.StartLine, b.StartCol, b.EndLine, b.EndCol, b.NumStmt, fp.counts[i])
+		}
+	}
+	err = w.Flush()
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// runMerge implements 'go tool covdata merge'.
+func runMerge(inputs [][]*pod, out string) error {
+	pods := flatten(inputs)
+	if _, err := checkMode(pods); err != nil {
+		return err
+	}
+	return writePods(out, mergePods(pods))
+}
+
+// runSubtract implements 'go tool covdata subtract'. The result keeps
+// the counters of the first input for the blocks that were not
+// executed in any of the other inputs.
+func runSubtract(inputs [][]*pod, out string) error {
+	if len(inputs) < 2 {
+		return fmt.Errorf("subtract requires at least two input directories")
+	}
+	covered := make(map[string]map[coverage.Block]bool)
+	for _, p := range flatten(inputs[1:]) {
+		for i, f := range p.meta.Files {
+			for j, b := range f.Blocks {
+				if p.counts[i][j] == 0 {
+					continue
+				}
+				if covered[f.Name] == nil {
+					covered[f.Name] = make(map[coverage.Block]bool)
+				}
+				covered[f.Name][b] = true
+			}
+		}
+	}
+	pods := mergePods(inputs[0])
+	for _, p := range pods {
+		for i, f := range p.meta.Files {
+			for j, b := range f.Blocks {
+				if covered[f.Name][b] {
+					p.counts[i][j] = 0
+				}
+			}
+		}
+	}
+	return writePods(out, pods)
+}
+
+// mergePods merges the pods that share a meta-data hash, which hold
+// the data of the same program read from different directories.
+func mergePods(pods []*pod) []*pod {
+	byHash := make(map[string]*pod)
+	var merged []*pod
+	for _, p := range pods {
+		m := byHash[p.hash]
+		if m == nil {
+			m = &pod{dir: p.dir, hash: p.hash, meta: p.meta, counts: make([][]uint32, len(p.counts))}
+			for i := range p.counts {
+				m.counts[i] = make([]uint32, len(p.counts[i]))
+			}
+			byHash[p.hash] = m
+			merged = append(merged, m)
+		}
+		for i := range p.counts {
+			mergeCounts(p.meta.Mode, m.counts[i], p.counts[i])
+		}
+	}
+	return merged
+}
+
+// writePods writes a meta-data file and a counter data file for each
+// pod into the directory dir, creating it if necessary.
+func writePods(dir string, pods []*pod) error {
+	if err := os.MkdirAll(dir, 0777); err != nil {
+		return err
+	}
+	for _, p := range pods {
+		name := filepath.Join(dir, coverage.MetaFilePref+"."+p.hash)
+		if err := ioutil.WriteFile(name, p.meta.Encode(), 0666); err != nil {
+			return err
+		}
+		c := &coverage.Counters{MetaHash: p.hash, Counts: p.counts}
+		name = filepath.Join(dir, fmt.Sprintf("%s.%s.%d.%d", coverage.CounterFilePref, p.hash, os.Getpid(), time.Now().UnixNano()))
+		if err := ioutil.WriteFile(name, c.Encode(), 0666); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// A fileProfile holds the counters of the blocks of one source file,
+// combined across all the programs that include the file.
+type fileProfile struct {
+	name   string
+	blocks []coverage.Block
+	counts []uint32
+}
+
+// makeProfile combines the counters in pods by source file and block,
+// and returns the result sorted by file name and block position,
+// along with the coverage mode.
+func makeProfile(pods []*pod) ([]*fileProfile, string, error) {
+	mode, err := checkMode(pods)
+	if err != nil {
+		return nil, "", err
+	}
+	byName := make(map[string]map[coverage.Block]uint32)
+	for _, p := range pods {
+		for i, f := range p.meta.Files {
+			blocks := byName[f.Name]
+			if blocks == nil {
+				blocks = make(map[coverage.Block]uint32)
+				byName[f.Name] = blocks
+			}
+			for j, b := range f.Blocks {
+				n := []uint32{blocks[b]}
+				mergeCounts(mode, n, p.counts[i][j:j+1])
+				blocks[b] = n[0]
+			}
+		}
+	}
+
+	var files []*fileProfile
+	for name, blocks := range byName {
+		fp := &fileProfile{name: name}
+		for b := range blocks {
+			fp.blocks = append(fp.blocks, b)
+		}
+		sort.Slice(fp.blocks, func(i, j int) bool {
+			bi, bj := fp.blocks[i], fp.blocks[j]
+			if bi.StartLine != bj.StartLine {
+				return bi.StartLine < bj.StartLine
+			}
+			if bi.StartCol != bj.StartCol {
+				return bi.StartCol < bj.StartCol
+			}
+			if bi.EndLine != bj.EndLine {
+				return bi.EndLine < bj.EndLine
+			}
+			return bi.EndCol < bj.EndCol
+		})
+		fp.counts = make([]uint32, len(fp.blocks))
+		for i, b := range fp.blocks {
+			fp.counts[i] = blocks[b]
+		}
+		files = append(files, fp)
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].name < files[j].name })
+	return files, mode, nil
+}
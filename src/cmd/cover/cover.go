@@ -38,6 +38,10 @@ Display coverage percentages to stdout for each function:
 Finally, to generate modified source code with coverage annotations
 (what go test -cover does):
 	go tool cover -mode=set -var=CoverageVariableName program.go
+
+To also register the counters with the runtime, so that a program
+built with 'go build -cover' writes them out when it exits:
+	go tool cover -mode=set -var=CoverageVariableName -register=example.com/pkg/program.go program.go
 `
 
 func usage() {
@@ -54,6 +58,7 @@ var (
 	output  = flag.String("o", "", "file for output; default: stdout")
 	htmlOut = flag.String("html", "", "generate HTML representation of coverage profile")
 	funcOut = flag.String("func", "", "output coverage profile information for each function")
+	regName = flag.String("register", "", "register the counters with the runtime under `name`, for go build -cover")
 )
 
 var profile string // The profile to read; the value of -html or -func
@@ -63,6 +68,11 @@ var counterStmt func(*File, string) string
 const (
 	atomicPackagePath = "sync/atomic"
 	atomicPackageName = "_cover_atomic_"
+
+	// coveragePackagePath is the package that writes out the counters
+	// registered with -register when the program exits. It is
+	// imported by the main package.
+	coveragePackagePath = "runtime/coverage"
 )
 
 func main() {
@@ -120,6 +130,10 @@ func parseFlags() error {
 		return fmt.Errorf("-var: %q is not a valid identifier", *varVar)
 	}
 
+	if *regName != "" && *mode == "" {
+		return fmt.Errorf("-register requires -mode")
+	}
+
 	if *mode != "" {
 		switch *mode {
 		case "set":
@@ -329,6 +343,16 @@ func annotate(name string) {
 		file.edit.Insert(file.offset(file.astFile.Name.End()),
 			fmt.Sprintf("; import %s %q", atomicPackageName, atomicPackagePath))
 	}
+	if *regName != "" {
+		// The registration function is declared with a go:linkname
+		// directive, which requires importing unsafe. A main package
+		// also imports the package that writes the counters on exit.
+		imports := `; import _ "unsafe"`
+		if file.astFile.Name.Name == "main" {
+			imports += fmt.Sprintf("; import _ %q", coveragePackagePath)
+		}
+		file.edit.Insert(file.offset(file.astFile.Name.End()), imports)
+	}
 
 	ast.Walk(file, file.astFile)
 	newContent := file.edit.Bytes()
@@ -690,6 +714,17 @@ func (f *File) addVariables(w io.Writer) {
 	if *mode == "atomic" {
 		fmt.Fprintf(w, "var _ = %s.LoadUint32\n", atomicPackageName)
 	}
+
+	// Register the counters with the runtime, which keeps track of
+	// them for package runtime/coverage.
+	if *regName != "" {
+		regFunc := *varVar + "_register"
+		fmt.Fprintf(w, "\nfunc init() {\n")
+		fmt.Fprintf(w, "\t%s(%q, %q, %s.Count[:], %s.Pos[:], %s.NumStmt[:])\n", regFunc, *mode, *regName, *varVar, *varVar, *varVar)
+		fmt.Fprintf(w, "}\n")
+		fmt.Fprintf(w, "\n//go:linkname %s runtime.addCoverFile\n", regFunc)
+		fmt.Fprintf(w, "func %s(mode, name string, counter, pos []uint32, numStmt []uint16)\n", regFunc)
+	}
 }
 
 // It is possible for positions to repeat when there is a line
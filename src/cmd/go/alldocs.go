@@ -30,6 +30,7 @@
 // 	tool        run specified go tool
 // 	version     print Go version
 // 	vet         report likely mistakes in packages
+// 	work        workspace maintenance
 //
 // Use "go help <command>" for more information about a command.
 //
@@ -98,6 +99,34 @@
 //
 // The -i flag installs the packages that are dependencies of the target.
 //
+// The coverage flags build executables instrumented for coverage analysis.
+// They are accepted by the build, install and run commands; 'go test' has
+// coverage flags of its own (see 'go help testflag').
+//
+// 	-cover
+// 		enable code coverage instrumentation. When an instrumented
+// 		program exits, it writes coverage data files into the directory
+// 		named by the GOCOVERDIR environment variable. Use
+// 		'go tool covdata' to merge those files and to convert them into
+// 		coverage profiles or per-package percentages.
+// 	-covermode set,count,atomic
+// 		set the mode for coverage analysis.
+// 		The default is "set" unless -race is enabled,
+// 		in which case it is "atomic".
+// 		The values:
+// 		set: bool: does this statement run?
+// 		count: int: how many times does this statement run?
+// 		atomic: int: count, but correct in multithreaded programs;
+// 			significantly more expensive.
+// 		Sets -cover.
+// 	-coverpkg pattern1,pattern2,pattern3
+// 		apply coverage analysis to each package matching the patterns.
+// 		The default is to apply coverage analysis to the packages in
+// 		the main module, or, in GOPATH mode, to the packages named on
+// 		the command line. The main package being built is always
+// 		instrumented. See 'go help packages' for a description of
+// 		package patterns. Sets -cover.
+//
 // The build flags are shared by the build, clean, get, install, list, run,
 // and test commands:
 //
@@ -161,6 +190,19 @@
 // 		directory, but it is not accessed. When -modfile is specified, an
 // 		alternate go.sum file is also used: its path is derived from the
 // 		-modfile flag by trimming the ".mod" extension and appending ".sum".
+// 	-pgo file
+// 		specify the file path of a profile for profile-guided optimization
+// 		(PGO), such as a CPU profile collected with net/http/pprof.
+// 		The compiler uses the profile to inline hot calls and to
+// 		devirtualize hot interface method calls.
+// 		The special name "auto" lets the go command select a file named
+// 		"default.pgo" in the directory of each main package being built,
+// 		if one exists; the profile applies to the main package and its
+// 		dependencies, except for dependencies shared by main packages
+// 		with different profiles. The special name "off" turns off PGO.
+// 		A file given explicitly applies to all packages in the build.
+// 		The default is auto. The profile's contents are part of the
+// 		build cache key.
 // 	-pkgdir dir
 // 		install and load all packages from dir instead of the usual locations.
 // 		For example, when building with a non-standard configuration,
@@ -265,6 +307,13 @@
 // download cache, including unpacked source code of versioned
 // dependencies.
 //
+// The -fuzzcache flag causes clean to remove files stored in the Go build
+// cache for fuzz testing. The fuzzing engine caches files that expand
+// code coverage, so removing them may make fuzzing less effective until
+// new inputs are found that provide the same coverage. These files are
+// distinct from those stored in testdata directory; clean does not remove
+// those files.
+//
 // For more about build flags, see 'go help build'.
 //
 // For more about specifying packages, see 'go help packages'.
@@ -787,6 +836,14 @@
 //         TestGoFiles     []string // _test.go files in package
 //         XTestGoFiles    []string // _test.go files outside package
 //
+//         // Embedded files
+//         EmbedPatterns      []string // //go:embed patterns
+//         EmbedFiles         []string // files matched by EmbedPatterns
+//         TestEmbedPatterns  []string // //go:embed patterns in TestGoFiles
+//         TestEmbedFiles     []string // files matched by TestEmbedPatterns
+//         XTestEmbedPatterns []string // //go:embed patterns in XTestGoFiles
+//         XTestEmbedFiles    []string // files matched by XTestEmbedPatterns
+//
 //         // Cgo directives
 //         CgoCFLAGS    []string // cgo: flags for C compiler
 //         CgoCPPFLAGS  []string // cgo: flags for C preprocessor
@@ -911,6 +968,7 @@
 //         Replace   *Module      // replaced by this module
 //         Time      *time.Time   // time version was created
 //         Update    *Module      // available update, if any (with -u)
+//         Retracted []string     // retraction information, if any (with -u)
 //         Main      bool         // is this the main module?
 //         Indirect  bool         // is this module only an indirect dependency of main module?
 //         Dir       string       // directory holding files for this module, if any
@@ -956,6 +1014,15 @@
 //     golang.org/x/text v0.3.0 [v0.4.0] => /tmp/text
 //     rsc.io/pdf v0.1.1 [v0.1.2]
 //
+// The -u flag also reports versions that have been retracted by the
+// module's author. When the current version of a module has been retracted,
+// list -u sets the Module's Retracted field to the rationale given by the
+// author, and the String method marks the version with "(retracted)".
+// Retracted versions are never reported as available upgrades.
+// For example:
+//
+//     example.com/m v1.0.1 (retracted) [v1.0.2]
+//
 // (For tools, 'go list -m -u -json all' may be more convenient to parse.)
 //
 // The -versions flag causes list to set the Module's Versions field
@@ -1100,9 +1167,14 @@
 // module path and version pair. If the @v is omitted, a replacement without
 // a version on the left side is dropped.
 //
+// The -retract=version and -dropretract=version flags add and drop a
+// retraction on the given version. The version may be a single version
+// like "v1.2.3" or a closed interval like "[v1.1.0,v1.1.9]". Note that
+// -retract=version is a no-op if that retraction already exists.
+//
 // The -require, -droprequire, -exclude, -dropexclude, -replace,
-// and -dropreplace editing flags may be repeated, and the changes
-// are applied in the order given.
+// -dropreplace, -retract, and -dropretract editing flags may be repeated,
+// and the changes are applied in the order given.
 //
 // The -go=version flag sets the expected Go language version.
 //
@@ -1123,6 +1195,7 @@
 // 		Require []Require
 // 		Exclude []Module
 // 		Replace []Replace
+// 		Retract []Retract
 // 	}
 //
 // 	type Require struct {
@@ -1136,6 +1209,12 @@
 // 		New Module
 // 	}
 //
+// 	type Retract struct {
+// 		Low       string
+// 		High      string
+// 		Rationale string
+// 	}
+//
 // Note that this only describes the go.mod file itself, not other modules
 // referred to indirectly. For the full set of modules available to a build,
 // use 'go list -m -json all'.
@@ -1366,7 +1445,7 @@
 // match future runs in which the files and environment variables are unchanged.
 // A cached test result is treated as executing in no time at all,
 // so a successful package test result will be cached and reused
-// regardless of -timeout setting.
+// regardless of -timeout and -testtimeout settings.
 //
 // In addition to the build flags, the flags handled by 'go test' itself are:
 //
@@ -1393,6 +1472,13 @@
 // 	    Convert test output to JSON suitable for automated processing.
 // 	    See 'go doc test2json' for the encoding details.
 //
+// 	-benchmetrics
+// 	    With -json, also report the results of each benchmark run
+// 	    as a "metrics" event holding the number of iterations and the
+// 	    value of each metric, such as ns/op, B/op, or a custom metric
+// 	    added with b.ReportMetric. The 'go tool benchdiff' command
+// 	    compares two files of such results.
+//
 // 	-o file
 // 	    Compile the test binary to the named file.
 // 	    The test still runs (unless -c or -i is specified).
@@ -1471,6 +1557,29 @@
 //   go install golang.org/x/tools/go/analysis/passes/shadow/cmd/shadow
 //   go vet -vettool=$(which shadow)
 //
+// If the root directory of a package's module contains a file named go.vet,
+// vet reads it to decide which checkers to run on the package and how to
+// configure them. Each line of the file holds one directive:
+//
+// 	enable name...      run the named checkers, including optional ones
+// 	disable name...     do not run the named checkers
+// 	flag name=value     set a checker flag, such as printf.funcs
+//
+// The enable and disable directives are ignored when checkers are selected
+// on the command line, and the command line also overrides flag directives.
+// A comment of the form
+//
+// 	//vet:ignore name...
+//
+// placed before the package clause of a Go source file suppresses the
+// diagnostics of the named checkers in that file. Placed after the package
+// clause, it suppresses them on its own line if it follows code there, and
+// otherwise on the next line.
+//
+// The -fix flag makes vet apply the fixes that checkers suggest for some of
+// their diagnostics, rewriting the source files in place. Diagnostics that
+// were fixed are not reported.
+//
 // The build flags supported by go vet are those that control package resolution
 // and execution, such as -n, -x, -v, -tags, and -toolexec.
 // For more about these flags, see 'go help build'.
@@ -1478,6 +1587,140 @@
 // See also: go fmt, go fix.
 //
 //
+// Workspace maintenance
+//
+// Go work provides access to operations on workspaces.
+//
+// A workspace is a set of modules on the local file system that are
+// developed together. It is described by a go.work file, which lists the
+// root directories of the modules with use directives:
+//
+// 	go 1.15
+//
+// 	use (
+// 		./app
+// 		./lib
+// 	)
+//
+// Directories are relative to the directory containing the go.work file.
+//
+// The go command runs in workspace mode whenever it finds a go.work file in
+// the current directory or one of its parents. The GOWORK environment
+// variable may instead name the go.work file to use, as an absolute path,
+// or be set to "off" to disable workspace mode.
+//
+// In workspace mode, all the listed modules are main modules: a package in
+// any of them may be named on the command line, by import path or by
+// directory, and imports of packages in another workspace module resolve to
+// that module's directory, whatever version of it the importing module
+// requires. The 'all' pattern matches the packages in all the workspace
+// modules and their dependencies. The main module reported by 'go env GOMOD'
+// is the workspace module containing the current directory, or the first
+// one listed if there is none; 'go list -m' lists all the workspace modules.
+//
+// The replace and exclude directives of all the workspace modules apply.
+//
+// The go command never updates the go.mod or go.sum files of the workspace
+// modules in workspace mode, as if invoked with -mod=readonly: a
+// requirement that one module's code needs must be added to that module's
+// go.mod file. The 'go get' and 'go mod' commands edit the go.mod file of a
+// single module, and so ignore the go.work file.
+//
+// 'go env GOWORK' prints the path of the go.work file in use, if any.
+//
+// Usage:
+//
+// 	go work <command> [arguments]
+//
+// The commands are:
+//
+// 	edit        edit go.work from tools or scripts
+// 	init        initialize workspace file
+// 	use         add modules to workspace file
+//
+// Use "go help work <command>" for more information about a command.
+//
+// Edit go.work from tools or scripts
+//
+// Usage:
+//
+// 	go work edit [editing flags] [go.work]
+//
+// Edit provides a command-line interface for editing go.work,
+// for use primarily by tools or scripts. It only reads go.work;
+// it does not look up information about the modules involved.
+// If no file is specified, Edit looks for a go.work file in the current
+// directory and its parent directories, as the go command does when
+// selecting the workspace.
+//
+// The editing flags specify a sequence of editing operations.
+//
+// The -fmt flag reformats the go.work file without making other changes.
+// This reformatting is also implied by any other modifications that use or
+// rewrite the go.work file. The only time this flag is needed is if no other
+// flags are specified, as in 'go work edit -fmt'.
+//
+// The -use=path and -dropuse=path flags
+// add and drop a use directive for the given module directory.
+//
+// The -use and -dropuse editing flags may be repeated,
+// and the changes are applied in the order given.
+//
+// The -go=version flag sets the expected Go language version.
+//
+// The -print flag prints the final go.work in its text format instead of
+// writing it back to go.work.
+//
+// The -json flag prints the final go.work file in JSON format instead of
+// writing it back to go.work. The JSON output corresponds to these Go types:
+//
+// 	type GoWork struct {
+// 		Go  string
+// 		Use []Use
+// 	}
+//
+// 	type Use struct {
+// 		DiskPath string
+// 	}
+//
+// See 'go help work' for more about workspaces.
+//
+//
+// Initialize workspace file
+//
+// Usage:
+//
+// 	go work init [moddirs]
+//
+// Init initializes and writes a new go.work file in the current directory,
+// in effect creating a new workspace there, or at the path named by the
+// GOWORK environment variable. The file must not already exist.
+//
+// Init optionally accepts paths to the workspace modules as arguments.
+// If an argument is omitted, an empty workspace with no modules is created;
+// use 'go work use' to add modules to it.
+//
+// See 'go help work' for more about workspaces.
+//
+//
+// Add modules to workspace file
+//
+// Usage:
+//
+// 	go work use [-r] moddirs
+//
+// Use adds the modules rooted at the given directories to the go.work file,
+// if they are not listed already. A directory that does not exist or does
+// not contain a go.mod file is instead removed from the go.work file, so
+// that 'go work use' can also be used to drop a module that was deleted.
+//
+// The -r flag searches recursively for modules in the argument
+// directories, and the use command operates as if each of the directories
+// were specified as arguments.
+//
+// See 'go help work' for more about workspaces.
+//
+//
 // Build constraints
 //
 // A build constraint, also known as a build tag, is a line comment that begins
@@ -1700,6 +1943,10 @@
 // 	GOCACHE
 // 		The directory where the go command will store cached
 // 		information for reuse in future builds.
+// 	GOCOVERDIR
+// 		The directory into which programs built with 'go build -cover'
+// 		write their coverage data files when they exit.
+// 		See 'go help build' and 'go tool covdata -help'.
 // 	GOMODCACHE
 // 		The directory where the go command will store downloaded modules.
 // 	GODEBUG
@@ -1742,6 +1989,13 @@
 // 	GOTMPDIR
 // 		The directory where the go command will write
 // 		temporary source files, packages, and binaries.
+// 	GOWORK
+// 		In module aware mode, the absolute path of the go.work file to use
+// 		as a workspace file. By default, the go command searches for a file
+// 		named go.work in the current directory and then containing directories
+// 		until one is found. If GOWORK is "off", workspace mode is disabled.
+// 		'go env GOWORK' reports the go.work file in use. See 'go help work'.
+// 		Cannot be set using 'go env -w'.
 //
 // Environment variables for use with cgo:
 //
@@ -1894,16 +2148,37 @@
 // 	require new/thing/v2 v2.3.4
 // 	exclude old/thing v1.2.3
 // 	replace bad/thing v1.4.5 => good/thing v1.4.5
+// 	retract v1.5.6
 //
 // The verbs are
 // 	module, to define the module path;
 // 	go, to set the expected language version;
 // 	require, to require a particular module at a given version or later;
-// 	exclude, to exclude a particular module version from use; and
-// 	replace, to replace a module version with a different module version.
+// 	exclude, to exclude a particular module version from use;
+// 	replace, to replace a module version with a different module version; and
+// 	retract, to indicate a previously released version of the module
+// 	should not be used.
 // Exclude and replace apply only in the main module's go.mod and are ignored
 // in dependencies.  See https://research.swtch.com/vgo-mvs for details.
 //
+// The retract directive marks a single version, or a closed range of
+// versions written [low, high], of the module defined by the go.mod file
+// as retracted. It is typically used when a version was published by
+// accident or was found to be severely broken. Comments just before or
+// after the directive give the rationale for the retraction:
+//
+// 	retract (
+// 		v1.0.0 // Published accidentally.
+// 		[v1.1.0, v1.1.5] // Data race in package thing/cache.
+// 	)
+//
+// Retractions are read from the go.mod file of the latest version of the
+// module, so to retract a version, an author publishes a new version whose
+// go.mod file lists it. A version may retract itself. Retracted versions
+// remain available to modules that already depend on them, but queries
+// such as @latest and 'go get -u' skip them. 'go get' and 'go list -m -u'
+// report the retraction when a module is at a retracted version.
+//
 // The leading verb can be factored out of adjacent lines to create a block,
 // like in Go imports:
 //
@@ -2547,6 +2822,21 @@
 // then at the end only the latest version (according to semantic version
 // ordering) is kept for use in the build.
 //
+// If the main module's go.mod file declares go 1.16 or higher, the module
+// graph is pruned: the go command follows the requirements of a dependency
+// whose go.mod file also declares go 1.16 or higher only if the main module
+// requires that dependency directly, and it ignores the requirements of such
+// a dependency that is itself only required indirectly. (Dependencies whose
+// go.mod files declare older versions, which may not list all the modules
+// they need, contribute all their requirements, recursively.) In exchange,
+// the main module's go.mod file lists every module that provides a package
+// imported by the main module or its dependencies; 'go mod tidy' adds the
+// missing requirements, marked "// indirect". With a pruned graph, the go
+// command loads packages from the modules listed in go.mod alone whenever
+// it can, and reads the go.mod files of other modules only when it needs them
+// to find a missing package or for commands like 'go list -m all' that
+// report on the whole module graph.
+//
 // The 'go list' command provides information about the main module
 // and the build list. For example:
 //
@@ -2704,6 +2994,11 @@
 // main module's go.mod are considered unavailable and cannot
 // be returned by queries.
 //
+// Module versions retracted by the module's author (see 'go help go.mod')
+// are also skipped by all queries except those naming a specific version
+// or revision, such as @v1.2.3 or @master. If the current version of a
+// module has been retracted, @upgrade and @patch still do not downgrade it.
+//
 // For example, these commands are all valid:
 //
 // 	go get github.com/gorilla/mux@latest    # same (@latest is default for 'go get')
@@ -3005,7 +3300,9 @@
 // trees. For example, 'go list all' lists all the packages on the local
 // system. When using modules, "all" expands to all packages in
 // the main module and their dependencies, including dependencies
-// needed by tests of any of those.
+// needed by tests of any of those. If the main module's go.mod file
+// declares go 1.16 or higher, "all" includes the dependencies needed by
+// tests of the packages in the main module only.
 //
 // - "std" is like all but expands to just the packages in the standard
 // Go library.
@@ -3098,6 +3395,9 @@
 // 	    Run each test and benchmark n times (default 1).
 // 	    If -cpu is set, run n times for each GOMAXPROCS value.
 // 	    Examples are always run once.
+// 	    If n is "untilfail", run the tests over and over until one of
+// 	    them fails, stopping early if another run would exceed the
+// 	    -timeout deadline. Benchmarks are run once.
 //
 // 	-cover
 // 	    Enable coverage analysis.
@@ -3131,6 +3431,39 @@
 // 	-failfast
 // 	    Do not start new tests after the first test failure.
 //
+// 	-fuzz regexp
+// 	    Run the fuzz target matching the regular expression. When specified,
+// 	    the command line argument must match exactly one package, and
+// 	    regexp must match exactly one fuzz target within that package.
+// 	    Fuzzing runs after tests, benchmarks, seed corpora of other fuzz
+// 	    targets, and examples have completed. Fuzzing is only supported
+// 	    on darwin, freebsd and linux, and coverage guidance is only
+// 	    available on amd64 and arm64. See the Fuzzing section of the
+// 	    testing package documentation for details.
+//
+// 	-fuzztime t
+// 	    Run enough iterations of the fuzz target during fuzzing to take t,
+// 	    specified as a time.Duration (for example, -fuzztime 1h30s).
+// 	    The default is to run forever.
+// 	    The special syntax Nx means to run the fuzz target N times
+// 	    (for example, -fuzztime 1000x).
+//
+// 	-fuzzminimizetime t
+// 	    Run enough iterations of the fuzz target during each minimization
+// 	    attempt to take t, as specified as a time.Duration (for example,
+// 	    -fuzzminimizetime 30s).
+// 	    The default is 60s.
+// 	    The special syntax Nx means to run the fuzz target N times
+// 	    (for example, -fuzzminimizetime 100x).
+//
+// 	-goroutineleakcheck
+// 	    Fail each top-level test that leaves goroutines behind that are
+// 	    blocked forever, as reported by the goroutineleak profile of
+// 	    runtime/pprof. The check runs after the test and its subtests
+// 	    and cleanups have finished, and is expensive. Goroutines leaked
+// 	    by parallel tests may be reported by another test that finishes
+// 	    at the same time.
+//
 // 	-list regexp
 // 	    List tests, benchmarks, or examples matching the regular expression.
 // 	    No tests, benchmarks or examples will be run. This will only
@@ -3161,6 +3494,22 @@
 // 	    the Go tree can run a sanity check but not spend time running
 // 	    exhaustive tests.
 //
+// 	-shuffle off,on,N
+// 	    Randomize the execution order of tests and benchmarks.
+// 	    It is off by default. If -shuffle is set to on, then it will seed
+// 	    the randomizer using the system clock. If -shuffle is set to an
+// 	    integer N, then N will be used as the seed value. In both cases,
+// 	    the seed will be reported for reproducibility.
+//
+// 	-testtimeout d
+// 	    If a single test or subtest runs longer than duration d, fail it,
+// 	    printing the stack of the goroutine running it, and continue
+// 	    with the remaining tests. The timed-out test's goroutine is
+// 	    abandoned, so its cleanup functions may not run.
+// 	    The time a parallel test spends waiting to run is not counted.
+// 	    A test can learn its deadline by calling t.Deadline.
+// 	    If d is 0, the default, there is no per-test timeout.
+//
 // 	-timeout d
 // 	    If a test binary runs longer than duration d, panic.
 // 	    If d is 0, the timeout is disabled.
@@ -3297,7 +3646,7 @@
 //
 // Testing functions
 //
-// The 'go test' command expects to find test, benchmark, and example functions
+// The 'go test' command expects to find test, benchmark, fuzz, and example functions
 // in the "*_test.go" files corresponding to the package under test.
 //
 // A test function is one named TestXxx (where Xxx does not start with a
@@ -3309,6 +3658,10 @@
 //
 // 	func BenchmarkXxx(b *testing.B) { ... }
 //
+// A fuzz target is one named FuzzXxx and should have the signature,
+//
+// 	func FuzzXxx(f *testing.F) { ... }
+//
 // An example function is similar to a test function but, instead of using
 // *testing.T to report success or failure, prints output to os.Stdout.
 // If the last comment in the function starts with "Output:" then the output
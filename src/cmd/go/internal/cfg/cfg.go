@@ -26,6 +26,9 @@ var (
 	BuildA                 bool   // -a flag
 	BuildBuildmode         string // -buildmode flag
 	BuildContext           = defaultContext()
+	BuildCover             bool               // -cover flag
+	BuildCoverMode         string             // -covermode flag
+	BuildCoverPkg          []string           // -coverpkg flag
 	BuildMod               string             // -mod flag
 	BuildModReason         string             // reason -mod flag is set, if set by default
 	BuildI                 bool               // -i flag
@@ -34,6 +37,7 @@ var (
 	BuildN                 bool               // -n flag
 	BuildO                 string             // -o flag
 	BuildP                 = runtime.NumCPU() // -p flag
+	BuildPGO               string             // -pgo flag
 	BuildPkgdir            string             // -pkgdir flag
 	BuildRace              bool               // -race flag
 	BuildToolexec          []string           // -toolexec flag
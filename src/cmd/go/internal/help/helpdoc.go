@@ -58,7 +58,9 @@ for packages to be built with the go tool:
 trees. For example, 'go list all' lists all the packages on the local
 system. When using modules, "all" expands to all packages in
 the main module and their dependencies, including dependencies
-needed by tests of any of those.
+needed by tests of any of those. If the main module's go.mod file
+declares go 1.16 or higher, "all" includes the dependencies needed by
+tests of the packages in the main module only.
 
 - "std" is like all but expands to just the packages in the standard
 Go library.
@@ -493,6 +495,10 @@ General-purpose environment variables:
 	GOCACHE
 		The directory where the go command will store cached
 		information for reuse in future builds.
+	GOCOVERDIR
+		The directory into which programs built with 'go build -cover'
+		write their coverage data files when they exit.
+		See 'go help build' and 'go tool covdata -help'.
 	GOMODCACHE
 		The directory where the go command will store downloaded modules.
 	GODEBUG
@@ -535,6 +541,13 @@ General-purpose environment variables:
 	GOTMPDIR
 		The directory where the go command will write
 		temporary source files, packages, and binaries.
+	GOWORK
+		In module aware mode, the absolute path of the go.work file to use
+		as a workspace file. By default, the go command searches for a file
+		named go.work in the current directory and then containing directories
+		until one is found. If GOWORK is "off", workspace mode is disabled.
+		'go env GOWORK' reports the go.work file in use. See 'go help work'.
+		Cannot be set using 'go env -w'.
 
 Environment variables for use with cgo:
 
@@ -84,6 +84,14 @@ to -f '{{.ImportPath}}'. The struct being passed to the template is:
         TestGoFiles     []string // _test.go files in package
         XTestGoFiles    []string // _test.go files outside package
 
+        // Embedded files
+        EmbedPatterns      []string // //go:embed patterns
+        EmbedFiles         []string // files matched by EmbedPatterns
+        TestEmbedPatterns  []string // //go:embed patterns in TestGoFiles
+        TestEmbedFiles     []string // files matched by TestEmbedPatterns
+        XTestEmbedPatterns []string // //go:embed patterns in XTestGoFiles
+        XTestEmbedFiles    []string // files matched by XTestEmbedPatterns
+
         // Cgo directives
         CgoCFLAGS    []string // cgo: flags for C compiler
         CgoCPPFLAGS  []string // cgo: flags for C preprocessor
@@ -208,6 +216,7 @@ applied to a Go struct, but now a Module struct:
         Replace   *Module      // replaced by this module
         Time      *time.Time   // time version was created
         Update    *Module      // available update, if any (with -u)
+        Retracted []string     // retraction information, if any (with -u)
         Main      bool         // is this the main module?
         Indirect  bool         // is this module only an indirect dependency of main module?
         Dir       string       // directory holding files for this module, if any
@@ -253,6 +262,15 @@ For example, 'go list -m -u all' might print:
     golang.org/x/text v0.3.0 [v0.4.0] => /tmp/text
     rsc.io/pdf v0.1.1 [v0.1.2]
 
+The -u flag also reports versions that have been retracted by the
+module's author. When the current version of a module has been retracted,
+list -u sets the Module's Retracted field to the rationale given by the
+author, and the String method marks the version with "(retracted)".
+Retracted versions are never reported as available upgrades.
+For example:
+
+    example.com/m v1.0.1 (retracted) [v1.0.2]
+
 (For tools, 'go list -m -u -json all' may be more convenient to parse.)
 
 The -versions flag causes list to set the Module's Versions field
@@ -0,0 +1,157 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package load
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"cmd/go/internal/base"
+	"cmd/go/internal/cfg"
+)
+
+// PrepareForCoverageBuild marks the packages in the build graph rooted
+// at pkgs for coverage instrumentation, as requested by the -cover,
+// -covermode and -coverpkg flags of 'go build', 'go install' and
+// 'go run'.
+//
+// By default, the packages named on the command line are instrumented,
+// along with, in module mode, every package in the main module.
+// Main packages are always instrumented: the cover tool makes them
+// import runtime/coverage, which writes out the counters when the
+// program exits.
+func PrepareForCoverageBuild(pkgs []*Package) {
+	var match []func(*Package) bool
+	var matched []bool
+	if len(cfg.BuildCoverPkg) > 0 {
+		match = make([]func(*Package) bool, len(cfg.BuildCoverPkg))
+		matched = make([]bool, len(cfg.BuildCoverPkg))
+		for i, pattern := range cfg.BuildCoverPkg {
+			match[i] = MatchPackage(pattern, base.Cwd)
+		}
+	}
+
+	for _, p := range PackageList(pkgs) {
+		selected := p.Name == "main" && p.Internal.CmdlinePkg
+		if match == nil {
+			selected = selected || p.Internal.CmdlinePkg || p.Internal.CmdlineFiles || (p.Module != nil && p.Module.Main)
+		} else {
+			for i := range match {
+				if match[i](p) {
+					matched[i] = true
+					selected = true
+				}
+			}
+		}
+		if !selected || skipCoverage(p) {
+			continue
+		}
+
+		p.Internal.CoverMode = cfg.BuildCoverMode
+		p.Internal.CoverRegister = true
+		var coverFiles []string
+		coverFiles = append(coverFiles, p.GoFiles...)
+		coverFiles = append(coverFiles, p.CgoFiles...)
+		p.Internal.CoverVars = DeclareCoverVars(p, coverFiles...)
+
+		// The cover tool inserts these imports into the annotated source.
+		if cfg.BuildCoverMode == "atomic" {
+			EnsureImport(p, "sync/atomic")
+		}
+		if p.Name == "main" {
+			EnsureImport(p, "runtime/coverage")
+		}
+	}
+
+	// Warn about -coverpkg arguments that are not actually used.
+	for i, pattern := range cfg.BuildCoverPkg {
+		if !matched[i] {
+			fmt.Fprintf(os.Stderr, "warning: no packages being built depend on matches for pattern %s\n", pattern)
+		}
+	}
+}
+
+// skipCoverage reports whether package p must not be instrumented by
+// 'go build -cover'. The runtime and the packages it is built from run
+// before counters can be registered, and the packages that write out
+// the coverage data would otherwise count their own work.
+func skipCoverage(p *Package) bool {
+	if !p.Standard {
+		return false
+	}
+	switch p.ImportPath {
+	case "unsafe", "runtime", "internal/bytealg", "internal/cpu", "runtime/coverage", "internal/coverage":
+		return true
+	case "sync/atomic":
+		// Atomic coverage mode uses sync/atomic, so we can't also
+		// do coverage on it.
+		return cfg.BuildCoverMode == "atomic"
+	}
+	return strings.HasPrefix(p.ImportPath, "runtime/internal/")
+}
+
+// EnsureImport ensures that package p imports the named package.
+func EnsureImport(p *Package, pkg string) {
+	for _, d := range p.Internal.Imports {
+		if d.Name == pkg {
+			return
+		}
+	}
+
+	p1 := LoadImportWithFlags(pkg, p.Dir, p, &ImportStack{}, nil, 0)
+	if p1.Error != nil {
+		base.Fatalf("load %s: %v", pkg, p1.Error)
+	}
+
+	p.Internal.Imports = append(p.Internal.Imports, p1)
+}
+
+// isTestFile reports whether the source file is a set of tests and should therefore
+// be excluded from coverage analysis.
+func isTestFile(file string) bool {
+	// We don't cover tests, only the code they test.
+	return strings.HasSuffix(file, "_test.go")
+}
+
+// DeclareCoverVars attaches the required cover variables names
+// to the files, to be used when annotating the files.
+func DeclareCoverVars(p *Package, files ...string) map[string]*CoverVar {
+	coverVars := make(map[string]*CoverVar)
+	coverIndex := 0
+	// We create the cover counters as new top-level variables in the package.
+	// We need to avoid collisions with user variables (GoCover_0 is unlikely but still)
+	// and more importantly with dot imports of other covered packages,
+	// so we append 12 hex digits from the SHA-256 of the import path.
+	// The point is only to avoid accidents, not to defeat users determined to
+	// break things.
+	sum := sha256.Sum256([]byte(p.ImportPath))
+	h := fmt.Sprintf("%x", sum[:6])
+	for _, file := range files {
+		if isTestFile(file) {
+			continue
+		}
+		// For a package that is "local" (imported via ./ import or command line, outside GOPATH),
+		// we record the full path to the file name.
+		// Otherwise we record the import path, then a forward slash, then the file name.
+		// This makes profiles within GOPATH file system-independent.
+		// These names appear in the cmd/cover HTML interface.
+		var longFile string
+		if p.Internal.Local {
+			longFile = filepath.Join(p.Dir, file)
+		} else {
+			longFile = path.Join(p.ImportPath, file)
+		}
+		coverVars[file] = &CoverVar{
+			File: longFile,
+			Var:  fmt.Sprintf("GoCover_%d_%x", coverIndex, h),
+		}
+		coverIndex++
+	}
+	return coverVars
+}
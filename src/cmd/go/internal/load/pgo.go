@@ -0,0 +1,63 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package load
+
+import (
+	"os"
+	"path/filepath"
+
+	"cmd/go/internal/base"
+	"cmd/go/internal/cfg"
+)
+
+// PreparePGO records the profile to use for profile-guided
+// optimization of the packages in the build graph rooted at pkgs,
+// as requested by the -pgo build flag.
+//
+// With -pgo=auto, a main package with a default.pgo file in its
+// directory is built with that profile, along with all of its
+// dependencies. A dependency shared by main packages that use
+// different profiles, or no profile, is built without one.
+// With -pgo=file, every package is built with that profile.
+func PreparePGO(pkgs []*Package) {
+	switch cfg.BuildPGO {
+	case "", "off":
+		return
+
+	case "auto":
+		profiles := make(map[*Package]string)
+		for _, p := range pkgs {
+			if p.Name != "main" {
+				continue
+			}
+			file := filepath.Join(p.Dir, "default.pgo")
+			if fi, err := os.Stat(file); err != nil || !fi.Mode().IsRegular() {
+				file = ""
+			}
+			for _, p1 := range PackageList([]*Package{p}) {
+				if old, ok := profiles[p1]; ok && old != file {
+					profiles[p1] = ""
+				} else {
+					profiles[p1] = file
+				}
+			}
+		}
+		for p, file := range profiles {
+			p.Internal.PGOProfile = file
+		}
+
+	default:
+		file := cfg.BuildPGO
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(base.Cwd, file)
+		}
+		if _, err := os.Stat(file); err != nil {
+			base.Fatalf("go: -pgo: %v", err)
+		}
+		for _, p := range PackageList(pkgs) {
+			p.Internal.PGOProfile = file
+		}
+	}
+}
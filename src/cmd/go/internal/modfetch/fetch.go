@@ -367,6 +367,11 @@ func RemoveAll(dir string) error {
 
 var GoSumFile string // path to go.sum; set by package modload
 
+// WorkspaceGoSumFiles holds the paths of the go.sum files of the other
+// modules in the workspace, if any. They are read in addition to GoSumFile,
+// but never written. Set by package modload.
+var WorkspaceGoSumFiles []string
+
 type modSum struct {
 	mod module.Version
 	sum string
@@ -402,6 +407,13 @@ func initGoSum() (bool, error) {
 	}
 	goSum.enabled = true
 	readGoSum(goSum.m, GoSumFile, data)
+	for _, f := range WorkspaceGoSumFiles {
+		data, err := lockedfile.Read(f)
+		if err != nil && !os.IsNotExist(err) {
+			return false, err
+		}
+		readGoSum(goSum.m, f, data)
+	}
 
 	// Add old go.modverify file.
 	// We'll delete go.modverify in WriteGoSum.
@@ -279,6 +279,7 @@ func runGet(cmd *base.Command, args []string) {
 
 	buildList := modload.LoadBuildList()
 	buildList = buildList[:len(buildList):len(buildList)] // copy on append
+	initialBuildList := buildList
 	versionByPath := make(map[string]string)
 	for _, m := range buildList {
 		versionByPath[m.Path] = m.Version
@@ -701,6 +702,7 @@ func runGet(cmd *base.Command, args []string) {
 	// Everything succeeded. Update go.mod.
 	modload.AllowWriteGoMod()
 	modload.WriteGoMod()
+	reportRetractions(byPath, initialBuildList)
 
 	// If -d was specified, we're done after the module work.
 	// We've already downloaded modules by loading packages above.
@@ -1034,6 +1036,37 @@ func (r *lostUpgradeReqs) Required(mod module.Version) ([]module.Version, error)
 	return r.Reqs.Required(mod)
 }
 
+// reportRetractions prints a warning for each module in the build list that
+// has been retracted by its author, if the module was named on the command
+// line or, with -u, its version was changed from the one in initialBuildList.
+func reportRetractions(byPath map[string]*query, initialBuildList []module.Version) {
+	initialVersion := make(map[string]string)
+	for _, m := range initialBuildList {
+		initialVersion[m.Path] = m.Version
+	}
+
+	var retractPaths []string
+	for _, m := range modload.BuildList() {
+		if m.Version == "" {
+			continue
+		}
+		if byPath[m.Path] == nil && (getU == "" || initialVersion[m.Path] == m.Version) {
+			continue
+		}
+		err := modload.CheckRetractions(m)
+		var rerr *modload.ModuleRetractedError
+		if errors.As(err, &rerr) {
+			fmt.Fprintf(os.Stderr, "go: warning: %v\n", err)
+			retractPaths = append(retractPaths, m.Path)
+		}
+	}
+	if len(retractPaths) == 1 {
+		fmt.Fprintf(os.Stderr, "go: to switch to the latest unretracted version, run:\n\tgo get %s@latest\n", retractPaths[0])
+	} else if len(retractPaths) > 1 {
+		fmt.Fprintf(os.Stderr, "go: to switch to the latest unretracted versions, run:\n\tgo get %s@latest\n", strings.Join(retractPaths, "@latest "))
+	}
+}
+
 var loggedLines sync.Map
 
 func logOncef(format string, args ...interface{}) {
@@ -16,6 +16,7 @@ type ModulePublic struct {
 	Replace   *ModulePublic `json:",omitempty"` // replaced by this module
 	Time      *time.Time    `json:",omitempty"` // time version was created
 	Update    *ModulePublic `json:",omitempty"` // available update (with -u)
+	Retracted []string      `json:",omitempty"` // retraction information, if any (with -u)
 	Main      bool          `json:",omitempty"` // is this the main module?
 	Indirect  bool          `json:",omitempty"` // module is only indirectly needed by main module
 	Dir       string        `json:",omitempty"` // directory holding local copy of files, if any
@@ -32,6 +33,9 @@ func (m *ModulePublic) String() string {
 	s := m.Path
 	if m.Version != "" {
 		s += " " + m.Version
+		if len(m.Retracted) > 0 {
+			s += " (retracted)"
+		}
 		if m.Update != nil {
 			s += " [" + m.Update.Version + "]"
 		}
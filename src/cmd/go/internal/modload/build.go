@@ -7,6 +7,7 @@ package modload
 import (
 	"bytes"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"internal/goroot"
 	"os"
@@ -98,6 +99,25 @@ func addUpdate(m *modinfo.ModulePublic) {
 	}
 }
 
+// addRetraction fills in m.Retracted if the module version has been retracted
+// by its author. Errors loading the retractions are ignored, as they are for
+// updates.
+func addRetraction(m *modinfo.ModulePublic) {
+	if m.Version == "" {
+		return
+	}
+
+	err := CheckRetractions(module.Version{Path: m.Path, Version: m.Version})
+	var rerr *ModuleRetractedError
+	if errors.As(err, &rerr) {
+		if len(rerr.Rationale) == 0 {
+			m.Retracted = []string{"retracted by module author"}
+		} else {
+			m.Retracted = rerr.Rationale
+		}
+	}
+}
+
 // addVersions fills in m.Versions with the list of known versions.
 func addVersions(m *modinfo.ModulePublic) {
 	m.Versions, _ = versions(m.Path)
@@ -119,6 +139,18 @@ func moduleInfo(m module.Version, fromBuildList bool) *modinfo.ModulePublic {
 		}
 		return info
 	}
+	if dir, ok := workModuleDir(m); ok {
+		info := &modinfo.ModulePublic{
+			Path:  m.Path,
+			Main:  true,
+			Dir:   dir,
+			GoMod: filepath.Join(dir, "go.mod"),
+		}
+		if f := workModFiles[m.Path]; f.Go != nil {
+			info.GoVersion = f.Go.Version
+		}
+		return info
+	}
 
 	info := &modinfo.ModulePublic{
 		Path:     m.Path,
@@ -115,6 +115,21 @@ If multiple versions of a particular module are added to the list,
 then at the end only the latest version (according to semantic version
 ordering) is kept for use in the build.
 
+If the main module's go.mod file declares go 1.16 or higher, the module
+graph is pruned: the go command follows the requirements of a dependency
+whose go.mod file also declares go 1.16 or higher only if the main module
+requires that dependency directly, and it ignores the requirements of such
+a dependency that is itself only required indirectly. (Dependencies whose
+go.mod files declare older versions, which may not list all the modules
+they need, contribute all their requirements, recursively.) In exchange,
+the main module's go.mod file lists every module that provides a package
+imported by the main module or its dependencies; 'go mod tidy' adds the
+missing requirements, marked "// indirect". With a pruned graph, the go
+command loads packages from the modules listed in go.mod alone whenever
+it can, and reads the go.mod files of other modules only when it needs them
+to find a missing package or for commands like 'go list -m all' that
+report on the whole module graph.
+
 The 'go list' command provides information about the main module
 and the build list. For example:
 
@@ -272,6 +287,11 @@ Module versions disallowed by exclude statements in the
 main module's go.mod are considered unavailable and cannot
 be returned by queries.
 
+Module versions retracted by the module's author (see 'go help go.mod')
+are also skipped by all queries except those naming a specific version
+or revision, such as @v1.2.3 or @master. If the current version of a
+module has been retracted, @upgrade and @patch still do not downgrade it.
+
 For example, these commands are all valid:
 
 	go get github.com/gorilla/mux@latest    # same (@latest is default for 'go get')
@@ -432,16 +452,37 @@ verb followed by arguments. For example:
 	require new/thing/v2 v2.3.4
 	exclude old/thing v1.2.3
 	replace bad/thing v1.4.5 => good/thing v1.4.5
+	retract v1.5.6
 
 The verbs are
 	module, to define the module path;
 	go, to set the expected language version;
 	require, to require a particular module at a given version or later;
-	exclude, to exclude a particular module version from use; and
-	replace, to replace a module version with a different module version.
+	exclude, to exclude a particular module version from use;
+	replace, to replace a module version with a different module version; and
+	retract, to indicate a previously released version of the module
+	should not be used.
 Exclude and replace apply only in the main module's go.mod and are ignored
 in dependencies.  See https://research.swtch.com/vgo-mvs for details.
 
+The retract directive marks a single version, or a closed range of
+versions written [low, high], of the module defined by the go.mod file
+as retracted. It is typically used when a version was published by
+accident or was found to be severely broken. Comments just before or
+after the directive give the rationale for the retraction:
+
+	retract (
+		v1.0.0 // Published accidentally.
+		[v1.1.0, v1.1.5] // Data race in package thing/cache.
+	)
+
+Retractions are read from the go.mod file of the latest version of the
+module, so to retract a version, an author publishes a new version whose
+go.mod file lists it. A version may retract itself. Retracted versions
+remain available to modules that already depend on them, but queries
+such as @latest and 'go get -u' skip them. 'go get' and 'go list -m -u'
+report the retraction when a module is at a retracted version.
+
 The leading verb can be factored out of adjacent lines to create a block,
 like in Go imports:
 
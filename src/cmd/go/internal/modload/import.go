@@ -111,6 +111,18 @@ var _ load.ImportPathError = &AmbiguousImportError{}
 // If Import can identify a module that could be added to supply the package,
 // the ImportMissingError records that module.
 func Import(path string) (m module.Version, dir string, err error) {
+	m, dir, err = importFromBuildList(path)
+	if _, ok := err.(*ImportMissingError); ok {
+		return queryImport(path)
+	}
+	return m, dir, err
+}
+
+// importFromBuildList is like Import, but it only looks for the package in
+// the modules in the build list. If none of them provides the package,
+// importFromBuildList returns an ImportMissingError that does not identify
+// a module to add.
+func importFromBuildList(path string) (m module.Version, dir string, err error) {
 	if strings.Contains(path, "@") {
 		return module.Version{}, "", fmt.Errorf("import path should not have @version")
 	}
@@ -189,8 +201,14 @@ func Import(path string) (m module.Version, dir string, err error) {
 		return module.Version{}, "", &AmbiguousImportError{importPath: path, Dirs: dirs, Modules: mods}
 	}
 
-	// Look up module containing the package, for addition to the build list.
-	// Goal is to determine the module, download it to dir, and return m, dir, ErrMissing.
+	return module.Version{}, "", &ImportMissingError{Path: path}
+}
+
+// queryImport looks up the module containing the package at path, which is
+// not provided by any module in the build list, for addition to the build list.
+// Goal is to determine the module, download it to dir, and return m, dir, ErrMissing.
+func queryImport(path string) (module.Version, string, error) {
+	pathIsStd := search.IsStandardImportPath(path)
 	if cfg.BuildMod == "readonly" {
 		var queryErr error
 		if !pathIsStd {
@@ -225,7 +243,7 @@ func Import(path string) (m module.Version, dir string, err error) {
 			}
 		}
 
-		mods = make([]module.Version, 0, len(latest))
+		mods := make([]module.Version, 0, len(latest))
 		for p, v := range latest {
 			// If the replacement didn't specify a version, synthesize a
 			// pseudo-version with an appropriate major version and a timestamp below
@@ -295,7 +313,7 @@ func Import(path string) (m module.Version, dir string, err error) {
 			return module.Version{}, "", err
 		}
 	}
-	m = candidates[0].Mod
+	m := candidates[0].Mod
 	newMissingVersion := ""
 	for _, c := range candidates {
 		cm := c.Mod
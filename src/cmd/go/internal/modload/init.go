@@ -135,6 +135,11 @@ func Init() {
 	if CmdModInit {
 		// Running 'go mod init': go.mod will be created in current directory.
 		modRoot = base.Cwd
+	} else if workFilePath = findWorkFile(base.Cwd); workFilePath != "" {
+		if cfg.ModFile != "" {
+			base.Fatalf("go: -modfile cannot be used in workspace mode")
+		}
+		modRoot = initWorkspace(workFilePath)
 	} else {
 		modRoot = findModuleRoot(base.Cwd)
 		if modRoot == "" {
@@ -208,6 +213,14 @@ func Init() {
 	} else {
 		modfetch.GoSumFile = strings.TrimSuffix(ModFilePath(), ".mod") + ".sum"
 		search.SetModRoot(modRoot)
+		if inWorkspaceMode() {
+			for _, dir := range workRoots {
+				if dir != modRoot {
+					modfetch.WorkspaceGoSumFiles = append(modfetch.WorkspaceGoSumFiles, filepath.Join(dir, "go.sum"))
+				}
+			}
+			search.SetWorkspaceRoots(workRoots)
+		}
 	}
 }
 
@@ -386,6 +399,9 @@ func InitMod() {
 		legacyModInit()
 	}
 
+	if inWorkspaceMode() {
+		loadWorkModules()
+	}
 	modFileToBuildList()
 	setDefaultBuildMod()
 	if cfg.BuildMod == "vendor" {
@@ -467,12 +483,28 @@ func modFileToBuildList() {
 	for _, r := range modFile.Require {
 		list = append(list, r.Mod)
 	}
+	// The other workspace modules are required by the main module
+	// without a version, so that they are selected over any version
+	// required elsewhere.
+	list = append(list, workModules...)
 	buildList = list
+	rootsOnly = true
 }
 
 // setDefaultBuildMod sets a default value for cfg.BuildMod
 // if it is currently empty.
 func setDefaultBuildMod() {
+	if inWorkspaceMode() {
+		// The go.mod files of the workspace modules are never updated
+		// implicitly: a requirement added to one of them for the sake
+		// of another would be wrong once the modules are used apart.
+		if cfg.BuildMod != "" && cfg.BuildMod != "readonly" {
+			base.Fatalf("go: -mod may only be set to readonly when in workspace mode, but it is set to %q\n\tRemove the -mod flag to use the default readonly value,\n\tor set GOWORK=off to disable workspace mode.", cfg.BuildMod)
+		}
+		cfg.BuildMod = "readonly"
+		cfg.BuildModReason = "go.mod files are not updated in workspace mode."
+		return
+	}
 	if cfg.BuildMod != "" {
 		// Don't override an explicit '-mod=' argument.
 		return
@@ -738,10 +770,26 @@ func AllowWriteGoMod() {
 // MinReqs returns a Reqs with minimal additional dependencies of Target,
 // as will be written to go.mod.
 func MinReqs() mvs.Reqs {
+	if rootsOnly {
+		// The build list is exactly what go.mod requires.
+		return newReqs(buildList)
+	}
+
+	// The roots of a pruned module graph must stay in go.mod, along with
+	// every module that provides a package: see TidyBuildList.
+	var roots map[string]bool
+	if pruned() {
+		roots = rootPaths()
+		for _, pkg := range loaded.pkgs {
+			if pkg.mod.Path != "" {
+				roots[pkg.mod.Path] = true
+			}
+		}
+	}
 	var retain []string
 	for _, m := range buildList[1:] {
 		_, explicit := index.require[m]
-		if explicit || loaded.direct[m.Path] {
+		if explicit || loaded.direct[m.Path] || roots[m.Path] {
 			retain = append(retain, m.Path)
 		}
 	}
@@ -749,7 +797,7 @@ func MinReqs() mvs.Reqs {
 	if err != nil {
 		base.Fatalf("go: %v", err)
 	}
-	return &mvsReqs{buildList: append([]module.Version{Target}, min...)}
+	return newReqs(append([]module.Version{Target}, min...))
 }
 
 // WriteGoMod writes the current build list back to go.mod.
@@ -766,6 +814,14 @@ func WriteGoMod() {
 		return
 	}
 
+	// In workspace mode, the build list includes the other workspace
+	// modules, which must not be recorded as requirements of the main
+	// module. Only check that no new go.sum entries are needed.
+	if inWorkspaceMode() {
+		modfetch.WriteGoSum()
+		return
+	}
+
 	if cfg.BuildMod != "readonly" {
 		addGoStmt()
 	}
@@ -38,6 +38,34 @@ import (
 //
 var buildList []module.Version
 
+// rootsOnly reports whether buildList holds only the main module and the
+// modules required by its go.mod file, because the module graph has not been
+// loaded. With a pruned module graph, packages are first loaded from those
+// modules alone, and the module graph is loaded only if they do not suffice.
+var rootsOnly bool
+
+// addedRoots holds the paths of the modules that the go command has added
+// to the build list or whose selected versions it has changed. Like the
+// modules required in go.mod, they are roots of a pruned module graph.
+var addedRoots = make(map[string]bool)
+
+// rootPaths returns the paths of the root modules of a pruned module graph:
+// the modules required by the main module's go.mod file, the other workspace
+// modules, and addedRoots.
+func rootPaths() map[string]bool {
+	roots := make(map[string]bool)
+	for _, r := range modFile.Require {
+		roots[r.Mod.Path] = true
+	}
+	for _, m := range workModules {
+		roots[m.Path] = true
+	}
+	for path := range addedRoots {
+		roots[path] = true
+	}
+	return roots
+}
+
 // loaded is the most recently-used package loader.
 // It holds details about individual packages.
 //
@@ -103,12 +131,20 @@ func ImportPathsQuiet(patterns []string, tags map[string]bool) []*search.Match {
 				matchPackages(m, loaded.tags, includeStd, buildList)
 
 			case m.Pattern() == "all":
-				loaded.testAll = true
+				if pruned() {
+					// With a pruned module graph, "all" includes the tests of
+					// the packages in the main module, but not of their
+					// dependencies.
+					loaded.testRoots = true
+				} else {
+					loaded.testAll = true
+				}
 				if iterating {
-					// Enumerate the packages in the main module.
+					// Enumerate the packages in the main module (and the
+					// other workspace modules, if any).
 					// We'll load the dependencies as we find them.
 					m.Errs = m.Errs[:0]
-					matchPackages(m, loaded.tags, omitStd, []module.Version{Target})
+					matchPackages(m, loaded.tags, omitStd, mainModules())
 				} else {
 					// Starting with the packages in the main module,
 					// enumerate the full list of "all".
@@ -189,7 +225,7 @@ func matchLocalDirs(m *search.Match) {
 		if !filepath.IsAbs(dir) {
 			absDir = filepath.Join(base.Cwd, dir)
 		}
-		if search.InDir(absDir, cfg.GOROOTsrc) == "" && search.InDir(absDir, ModRoot()) == "" && pathInModuleCache(absDir) == "" {
+		if search.InDir(absDir, cfg.GOROOTsrc) == "" && search.InDir(absDir, ModRoot()) == "" && !dirInWorkspace(absDir) && pathInModuleCache(absDir) == "" {
 			m.Dirs = []string{}
 			m.AddError(fmt.Errorf("directory prefix %s outside available modules", base.ShortPath(absDir)))
 			return
@@ -238,6 +274,20 @@ func resolveLocalPackage(dir string) (string, error) {
 		}
 	}
 
+	if mod, root, ok := workModuleForDir(absDir); ok {
+		suffix := filepath.ToSlash(absDir[len(root):])
+		if strings.HasPrefix(suffix, "/vendor/") {
+			return "", fmt.Errorf("without -mod=vendor, directory %s has no package path", absDir)
+		}
+		pkg := mod.Path + suffix
+		if _, ok, err := dirInModule(pkg, mod.Path, root, true); err != nil {
+			return "", err
+		} else if !ok {
+			return "", &PackageNotInModuleError{Mod: mod, Pattern: pkg}
+		}
+		return pkg, nil
+	}
+
 	if modRoot != "" && absDir == modRoot {
 		if absDir == cfg.GOROOTsrc {
 			return "", errPkgIsGorootSrc
@@ -355,7 +405,8 @@ func ImportFromFiles(gofiles []string) {
 }
 
 // DirImportPath returns the effective import path for dir,
-// provided it is within the main module, or else returns ".".
+// provided it is within the main module or another workspace module,
+// or else returns ".".
 func DirImportPath(dir string) string {
 	if modRoot == "" {
 		return "."
@@ -367,6 +418,9 @@ func DirImportPath(dir string) string {
 		dir = filepath.Clean(dir)
 	}
 
+	if mod, root, ok := workModuleForDir(dir); ok {
+		return mod.Path + filepath.ToSlash(dir[len(root):])
+	}
 	if dir == modRoot {
 		return targetPrefix
 	}
@@ -394,7 +448,7 @@ func LoadBuildList() []module.Version {
 
 func ReloadBuildList() []module.Version {
 	loaded = newLoader(imports.Tags())
-	loaded.load(func() []string { return nil })
+	loaded.load(nil)
 	return buildList
 }
 
@@ -404,6 +458,7 @@ func ReloadBuildList() []module.Version {
 // It adds modules to the build list as needed to satisfy new imports.
 // This set is useful for deciding whether a particular import is needed
 // anywhere in a module.
+// With a pruned module graph, LoadALL is the same as LoadVendor.
 func LoadALL() []string {
 	return loadAll(true)
 }
@@ -421,8 +476,8 @@ func loadAll(testAll bool) []string {
 
 	loaded = newLoader(imports.AnyTags())
 	loaded.isALL = true
-	loaded.testAll = testAll
-	if !testAll {
+	loaded.testAll = testAll && !pruned()
+	if !loaded.testAll {
 		loaded.testRoots = true
 	}
 	all := TargetPackages("...")
@@ -470,7 +525,17 @@ func BuildList() []module.Version {
 // The caller is responsible for ensuring that the list is valid.
 // SetBuildList does not retain a reference to the original list.
 func SetBuildList(list []module.Version) {
+	old := make(map[string]string, len(buildList))
+	for _, m := range buildList {
+		old[m.Path] = m.Version
+	}
+	for _, m := range list {
+		if v, ok := old[m.Path]; !ok || v != m.Version {
+			addedRoots[m.Path] = true
+		}
+	}
 	buildList = append([]module.Version{}, list...)
+	rootsOnly = false
 }
 
 // TidyBuildList trims the build list to the minimal requirements needed to
@@ -483,12 +548,16 @@ func TidyBuildList() {
 	}
 
 	keep := []module.Version{Target}
-	var direct []string
+	var retain []string
 	for _, m := range buildList[1:] {
 		if used[m] {
 			keep = append(keep, m)
-			if loaded.direct[m.Path] {
-				direct = append(direct, m.Path)
+			// A pruned module graph omits the requirements of most modules
+			// not listed in go.mod, so go.mod must list every module that
+			// provides a package.
+			if loaded.direct[m.Path] || pruned() {
+				retain = append(retain, m.Path)
+				addedRoots[m.Path] = true
 			}
 		} else if cfg.BuildV {
 			if _, ok := index.require[m]; ok {
@@ -497,7 +566,7 @@ func TidyBuildList() {
 		}
 	}
 
-	min, err := mvs.Req(Target, direct, &mvsReqs{buildList: keep})
+	min, err := mvs.Req(Target, retain, newReqs(keep))
 	if err != nil {
 		base.Fatalf("go: %v", err)
 	}
@@ -614,6 +683,7 @@ type loader struct {
 	isALL          bool            // created with LoadALL
 	testAll        bool            // include tests for all packages
 	forceStdVendor bool            // if true, load standard-library dependencies from the vendor subtree
+	lazy           bool            // load packages from the modules in go.mod only, without the module graph
 
 	// reset on each iteration
 	roots    []*loadPkg
@@ -671,9 +741,13 @@ var errMissing = errors.New("cannot find package")
 func (ld *loader) load(roots func() []string) {
 	var err error
 	reqs := Reqs()
-	buildList, err = mvs.BuildList(Target, reqs)
-	if err != nil {
-		base.Fatalf("go: %v", err)
+	ld.lazy = roots != nil && canLoadLazily() && !ld.isALL
+	if !ld.lazy {
+		buildList, err = mvs.BuildList(Target, reqs)
+		if err != nil {
+			base.Fatalf("go: %v", err)
+		}
+		rootsOnly = false
 	}
 
 	added := make(map[string]bool)
@@ -689,6 +763,21 @@ func (ld *loader) load(roots func() []string) {
 		}
 		ld.work.Do(10, ld.doPkg)
 		ld.buildStacks()
+		if ld.lazy {
+			ld.lazy = false
+			if ld.lazyLoadComplete(reqs.(*mvsReqs)) {
+				break
+			}
+			// Some imports are not provided by the modules listed in go.mod,
+			// or not at the versions the module graph would select.
+			// Load the module graph and try again.
+			buildList, err = mvs.BuildList(Target, reqs)
+			if err != nil {
+				base.Fatalf("go: %v", err)
+			}
+			rootsOnly = false
+			continue
+		}
 		numAdded := 0
 		haveMod := make(map[module.Version]bool)
 		for _, m := range buildList {
@@ -710,6 +799,7 @@ func (ld *loader) load(roots func() []string) {
 					haveMod[err.Module] = true
 					modAddedBy[err.Module] = pkg
 					buildList = append(buildList, err.Module)
+					addedRoots[err.Module.Path] = true
 				}
 				continue
 			}
@@ -768,6 +858,59 @@ func (ld *loader) load(roots func() []string) {
 	}
 }
 
+// canLoadLazily reports whether packages may be loaded from the modules
+// required in go.mod alone, without loading the module graph. That is the
+// case if the module graph is pruned and the build list has not been
+// computed from the graph: go.mod then lists every module that provides
+// a package, at its selected version, as 'go mod tidy' maintains it.
+func canLoadLazily() bool {
+	if !rootsOnly || !pruned() || cfg.BuildMod == "vendor" {
+		return false
+	}
+	seen := make(map[string]bool)
+	for _, m := range buildList[1:] {
+		if seen[m.Path] || index.exclude[m] {
+			// MVS must choose among the versions.
+			return false
+		}
+		seen[m.Path] = true
+	}
+	return true
+}
+
+// lazyLoadComplete reports whether the packages loaded from the modules
+// required in go.mod alone are the ones the module graph would provide:
+// no import is missing, and no module providing a package requires a
+// higher version of a module in the build list than go.mod does.
+// It reads the go.mod files of the modules providing packages, but not
+// of any others.
+func (ld *loader) lazyLoadComplete(r *mvsReqs) bool {
+	selected := make(map[string]string)
+	for _, m := range buildList {
+		selected[m.Path] = m.Version
+	}
+	seen := map[module.Version]bool{Target: true}
+	for _, pkg := range ld.pkgs {
+		if _, ok := pkg.err.(*ImportMissingError); ok {
+			return false
+		}
+		if pkg.mod.Path == "" || seen[pkg.mod] {
+			continue
+		}
+		seen[pkg.mod] = true
+		list, err := r.required(pkg.mod)
+		if err != nil {
+			return false
+		}
+		for _, m := range list {
+			if v, ok := selected[m.Path]; ok && r.Max(v, m.Version) != v {
+				return false
+			}
+		}
+	}
+	return true
+}
+
 // pkg returns the *loadPkg for path, creating and queuing it if needed.
 // If the package should be tested, its test is created but not queued
 // (the test is queued after processing pkg).
@@ -812,7 +955,11 @@ func (ld *loader) doPkg(item interface{}) {
 			return
 		}
 
-		pkg.mod, pkg.dir, pkg.err = Import(pkg.path)
+		if ld.lazy {
+			pkg.mod, pkg.dir, pkg.err = importFromBuildList(pkg.path)
+		} else {
+			pkg.mod, pkg.dir, pkg.err = Import(pkg.path)
+		}
 		if pkg.dir == "" {
 			return
 		}
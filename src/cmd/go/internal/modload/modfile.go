@@ -5,11 +5,21 @@
 package modload
 
 import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"unicode"
+
 	"cmd/go/internal/base"
 	"cmd/go/internal/cfg"
+	"cmd/go/internal/lockedfile"
+	"cmd/go/internal/modfetch"
+	"cmd/go/internal/par"
 
 	"golang.org/x/mod/modfile"
 	"golang.org/x/mod/module"
+	"golang.org/x/mod/semver"
 )
 
 var modFile *modfile.File
@@ -41,7 +51,16 @@ func Allowed(m module.Version) bool {
 // Replacement returns the replacement for mod, if any, from go.mod.
 // If there is no replacement for mod, Replacement returns
 // a module.Version with Path == "".
+//
+// In workspace mode, every version of a workspace module other than the
+// unversioned one in the build list is replaced by the module's directory.
 func Replacement(mod module.Version) module.Version {
+	if dir, ok := workReplacement(mod.Path); ok {
+		if mod.Version == "" {
+			return module.Version{}
+		}
+		return module.Version{Path: dir}
+	}
 	if index != nil {
 		if r, ok := index.replace[mod]; ok {
 			return r
@@ -162,3 +181,165 @@ func (i *modFileIndex) modFileIsDirty(modFile *modfile.File) bool {
 
 	return false
 }
+
+// retractCache caches the retractions declared by the latest version of each
+// module, keyed by module path.
+var retractCache par.Cache
+
+// loadRetractions returns the retract directives in the go.mod file of the
+// latest version of the module with the given path: the highest release
+// version not excluded by the main module, or else the highest such
+// pre-release version, or else the latest commit. Retractions are not
+// considered in choosing that version: a version may retract itself.
+func loadRetractions(path string) ([]*modfile.Retract, error) {
+	type cached struct {
+		retract []*modfile.Retract
+		err     error
+	}
+	c := retractCache.Do(path, func() interface{} {
+		var latest module.Version
+		err := modfetch.TryProxies(func(proxy string) error {
+			repo, err := modfetch.Lookup(proxy, path)
+			if err != nil {
+				return err
+			}
+			versions, err := repo.Versions("")
+			if err != nil {
+				return err
+			}
+			var release, prerelease string
+			for _, v := range versions {
+				if !Allowed(module.Version{Path: path, Version: v}) {
+					continue
+				}
+				if semver.Prerelease(v) == "" {
+					release = v
+				} else {
+					prerelease = v
+				}
+			}
+			switch {
+			case release != "":
+				latest = module.Version{Path: path, Version: release}
+			case prerelease != "":
+				latest = module.Version{Path: path, Version: prerelease}
+			default:
+				info, err := repo.Latest()
+				if err != nil {
+					return err
+				}
+				latest = module.Version{Path: path, Version: info.Version}
+			}
+			return nil
+		})
+		if err != nil {
+			return cached{nil, err}
+		}
+
+		var data []byte
+		gomod := "go.mod"
+		if repl := Replacement(latest); repl.Path != "" && repl.Version == "" {
+			dir := repl.Path
+			if !filepath.IsAbs(dir) {
+				dir = filepath.Join(ModRoot(), dir)
+			}
+			gomod = filepath.Join(dir, "go.mod")
+			data, err = lockedfile.Read(gomod)
+		} else {
+			if repl.Path != "" {
+				latest = repl
+			}
+			data, err = modfetch.GoMod(latest.Path, latest.Version)
+		}
+		if err != nil {
+			return cached{nil, err}
+		}
+		f, err := modfile.ParseLax(gomod, data, nil)
+		if err != nil {
+			return cached{nil, module.VersionError(latest, fmt.Errorf("parsing go.mod: %v", err))}
+		}
+		return cached{f.Retract, nil}
+	}).(cached)
+	return c.retract, c.err
+}
+
+// CheckRetractions returns an error if m has been retracted by the author of
+// its module, as declared by a retract directive in the go.mod file of the
+// latest version of the module. The error wraps a *ModuleRetractedError.
+//
+// Versions of the main module and of local replacements cannot be retracted.
+// CheckRetractions also returns an error if the retractions cannot be loaded.
+func CheckRetractions(m module.Version) error {
+	if m.Version == "" || m.Path == Target.Path {
+		return nil
+	}
+	if _, ok := workReplacement(m.Path); ok {
+		return nil
+	}
+	retract, err := loadRetractions(m.Path)
+	if err != nil {
+		return err
+	}
+	var rationale []string
+	isRetracted := false
+	for _, r := range retract {
+		if semver.Compare(r.Low, m.Version) <= 0 && semver.Compare(m.Version, r.High) <= 0 {
+			isRetracted = true
+			if r.Rationale != "" {
+				rationale = append(rationale, r.Rationale)
+			}
+		}
+	}
+	if isRetracted {
+		return module.VersionError(m, &ModuleRetractedError{Rationale: rationale})
+	}
+	return nil
+}
+
+// isRetracted reports whether m has been retracted by the author of its
+// module. Versions whose retractions cannot be loaded are not considered
+// retracted.
+func isRetracted(m module.Version) bool {
+	var rerr *ModuleRetractedError
+	return errors.As(CheckRetractions(m), &rerr)
+}
+
+// A ModuleRetractedError indicates that a module version was retracted by
+// the module's author.
+type ModuleRetractedError struct {
+	Rationale []string
+}
+
+func (e *ModuleRetractedError) Error() string {
+	msg := "retracted by module author"
+	if len(e.Rationale) > 0 {
+		// This is meant to be a short error printed on a terminal, so just
+		// print the first rationale.
+		msg += ": " + ShortRetractionRationale(e.Rationale[0])
+	}
+	return msg
+}
+
+// ShortRetractionRationale returns a retraction rationale string that is safe
+// to print in a terminal. It returns hard-coded strings if the rationale
+// is empty, too long, or contains non-printable characters.
+func ShortRetractionRationale(rationale string) string {
+	const maxRationaleBytes = 500
+	if i := strings.Index(rationale, "\n"); i >= 0 {
+		rationale = rationale[:i]
+	}
+	rationale = strings.TrimSpace(rationale)
+	if rationale == "" {
+		return "retracted by module author"
+	}
+	if len(rationale) > maxRationaleBytes {
+		return "(rationale omitted: too long)"
+	}
+	for _, r := range rationale {
+		if !unicode.IsGraphic(r) && !unicode.IsSpace(r) {
+			return "(rationale omitted: contains non-printable characters)"
+		}
+	}
+	// NOTE: the go.mod parser rejects invalid UTF-8, so we don't check that here.
+	return rationale
+}
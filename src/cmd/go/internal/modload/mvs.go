@@ -24,25 +24,115 @@ import (
 	"golang.org/x/mod/semver"
 )
 
+// pruningGoVersion is the earliest go version in a go.mod file at which
+// the module graph is pruned: if the main module's go.mod file declares
+// this version or higher, the requirements of a dependency that declares it
+// too are followed only when that dependency is a root (see rootPaths).
+const pruningGoVersion = "1.16"
+
 // mvsReqs implements mvs.Reqs for module semantic versions,
 // with any exclusions or replacements applied internally.
 type mvsReqs struct {
 	buildList []module.Version
 	cache     par.Cache
 	versions  sync.Map
+
+	// roots, if non-nil, holds the paths of the root modules of a pruned
+	// module graph. Only the requirements of the modules in expand,
+	// computed from roots, are part of the graph.
+	roots     map[string]bool
+	pruneOnce sync.Once
+	expand    map[module.Version]bool
 }
 
 // Reqs returns the current module requirement graph.
 // Future calls to SetBuildList do not affect the operation
 // of the returned Reqs.
 func Reqs() mvs.Reqs {
+	return newReqs(buildList)
+}
+
+// newReqs returns the module requirement graph in which the main module
+// requires the modules in list[1:], pruned if the main module's go.mod file
+// asks for it.
+func newReqs(list []module.Version) *mvsReqs {
 	r := &mvsReqs{
-		buildList: buildList,
+		buildList: list,
+	}
+	if pruned() && cfg.BuildMod != "vendor" {
+		r.roots = rootPaths()
 	}
 	return r
 }
 
+// pruned reports whether the module graph of the main module is pruned,
+// because its go.mod file declares go 1.16 or higher.
+func pruned() bool {
+	return modFile != nil && modFile.Go != nil && goVersionPrunes(modFile.Go.Version)
+}
+
+// goVersionPrunes reports whether the go version v in a go.mod file
+// is pruningGoVersion or higher.
+func goVersionPrunes(v string) bool {
+	return semver.Compare("v"+v, "v"+pruningGoVersion) >= 0
+}
+
+// Required returns the requirements of mod, or nil if the requirements of
+// mod are outside the pruned module graph.
 func (r *mvsReqs) Required(mod module.Version) ([]module.Version, error) {
+	if r.roots != nil && mod != Target {
+		r.pruneOnce.Do(r.prune)
+		if !r.expand[mod] {
+			return nil, nil
+		}
+	}
+	return r.loadRequired(mod)
+}
+
+// prune computes the set of modules whose requirements are part of the
+// pruned module graph: the root modules required by the main module, and
+// every module reachable from a root through a module whose go.mod file
+// declares a go version lower than pruningGoVersion. The go.mod files of
+// the other modules in the graph are never read.
+func (r *mvsReqs) prune() {
+	type item struct {
+		m   module.Version
+		all bool // follow requirements whether or not m is pruned
+	}
+	r.expand = make(map[module.Version]bool)
+	var mu sync.Mutex
+	var work par.Work
+	list, _ := r.loadRequired(Target)
+	for _, m := range list {
+		if r.roots[m.Path] {
+			work.Add(item{m, false})
+		}
+	}
+	work.Do(10, func(x interface{}) {
+		it := x.(item)
+		mu.Lock()
+		r.expand[it.m] = true
+		mu.Unlock()
+
+		list, err := r.loadRequired(it.m)
+		if err != nil {
+			// Reported when mvs asks for the requirements of it.m.
+			return
+		}
+		if v, ok := r.versions.Load(it.m); !it.all && ok && goVersionPrunes(v.(string)) {
+			return
+		}
+		for _, m := range list {
+			if m.Version != "none" {
+				work.Add(item{m, true})
+			}
+		}
+	})
+}
+
+// loadRequired returns the requirements of mod listed in its go.mod file,
+// with exclusions applied.
+func (r *mvsReqs) loadRequired(mod module.Version) ([]module.Version, error) {
 	type cached struct {
 		list []module.Version
 		err  error
@@ -92,6 +182,14 @@ func (r *mvsReqs) required(mod module.Version) ([]module.Version, error) {
 		return append([]module.Version(nil), r.buildList[1:]...), nil
 	}
 
+	if _, ok := workModuleDir(mod); ok {
+		f := workModFiles[mod.Path]
+		if f.Go != nil {
+			r.versions.LoadOrStore(mod, f.Go.Version)
+		}
+		return r.modFileToList(f), nil
+	}
+
 	if cfg.BuildMod == "vendor" {
 		// For every module other than the target,
 		// return the full list of modules from modules.txt.
@@ -228,6 +326,9 @@ func fetch(mod module.Version) (dir string, isLocal bool, err error) {
 	if mod == Target {
 		return ModRoot(), true, nil
 	}
+	if dir, ok := workModuleDir(mod); ok {
+		return dir, true, nil
+	}
 	if r := Replacement(mod); r.Path != "" {
 		if r.Version == "" {
 			dir = r.Path
@@ -53,6 +53,10 @@ import (
 // If the allowed function is non-nil, Query excludes any versions for which
 // allowed returns false.
 //
+// Except when query names a specific version or revision, Query also excludes
+// versions that have been retracted by the module's author (see
+// CheckRetractions).
+//
 // If path is the path of the main module and the query is "latest",
 // Query returns Target.Version as the version.
 func Query(path, query, current string, allowed func(module.Version) bool) (*modfetch.RevInfo, error) {
@@ -234,6 +238,14 @@ func queryProxy(proxy, path, query, current string, allowed func(module.Version)
 		return nil, fmt.Errorf("explicit requirement on standard-library module %s not allowed", path)
 	}
 
+	// Skip retracted versions. The retractions are only loaded if some
+	// version passes the other checks: they require the go.mod file of the
+	// latest version.
+	allowedOK := ok
+	ok = func(m module.Version) bool {
+		return allowedOK(m) && !isRetracted(m)
+	}
+
 	// Load versions and execute query.
 	repo, err := modfetch.Lookup(proxy, path)
 	if err != nil {
@@ -287,7 +299,7 @@ func queryProxy(proxy, path, query, current string, allowed func(module.Version)
 		// provided it is not excluded.
 		latest, err := repo.Latest()
 		if err == nil {
-			if allowed(module.Version{Path: path, Version: latest.Version}) {
+			if ok(module.Version{Path: path, Version: latest.Version}) {
 				return lookup(latest.Version)
 			}
 		} else if !errors.Is(err, os.ErrNotExist) {
@@ -662,11 +674,11 @@ type PackageNotInModuleError struct {
 }
 
 func (e *PackageNotInModuleError) Error() string {
-	if e.Mod == Target {
+	if _, ok := workModuleDir(e.Mod); ok || e.Mod == Target {
 		if strings.Contains(e.Pattern, "...") {
-			return fmt.Sprintf("main module (%s) does not contain packages matching %s", Target.Path, e.Pattern)
+			return fmt.Sprintf("main module (%s) does not contain packages matching %s", e.Mod.Path, e.Pattern)
 		}
-		return fmt.Sprintf("main module (%s) does not contain package %s", Target.Path, e.Pattern)
+		return fmt.Sprintf("main module (%s) does not contain package %s", e.Mod.Path, e.Pattern)
 	}
 
 	found := ""
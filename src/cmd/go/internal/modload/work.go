@@ -0,0 +1,238 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package modload
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"cmd/go/internal/base"
+	"cmd/go/internal/cfg"
+	"cmd/go/internal/lockedfile"
+	"cmd/go/internal/search"
+	"cmd/go/internal/workfile"
+
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+)
+
+var (
+	// workFilePath is the path of the go.work file in use,
+	// or "" if the go command is not running in workspace mode.
+	workFilePath string
+
+	// workRoots holds the root directories of all the modules listed
+	// in the go.work file, including the main module.
+	workRoots []string
+
+	// workModules holds the modules listed in the go.work file other than
+	// the main module, sorted by path. Like the main module, they have no
+	// version.
+	workModules []module.Version
+
+	workModDirs  map[string]string        // module path → root directory, for workModules
+	workModFiles map[string]*modfile.File // module path → parsed go.mod file, for workModules
+)
+
+// FindGoWork returns the path of the go.work file selected by the GOWORK
+// environment variable, or else the first go.work file found in dir or one
+// of its parents. It returns "" if GOWORK=off or no go.work file is found.
+func FindGoWork(dir string) string {
+	switch gowork := cfg.Getenv("GOWORK"); gowork {
+	case "off":
+		return ""
+	case "":
+		// Search for go.work below.
+	default:
+		return gowork
+	}
+
+	dir = filepath.Clean(dir)
+	for {
+		if fi, err := os.Stat(filepath.Join(dir, "go.work")); err == nil && !fi.IsDir() {
+			return filepath.Join(dir, "go.work")
+		}
+		d := filepath.Dir(dir)
+		if d == dir {
+			break
+		}
+		dir = d
+	}
+	return ""
+}
+
+// findWorkFile is like FindGoWork, but it returns "" for the commands that
+// edit the go.mod file of a single module and so ignore the workspace.
+func findWorkFile(dir string) string {
+	if cfg.CmdName == "get" || strings.HasPrefix(cfg.CmdName, "mod ") {
+		return ""
+	}
+	return FindGoWork(dir)
+}
+
+// WorkFilePath returns the path of the go.work file in use,
+// or "" if the go command is not running in workspace mode.
+func WorkFilePath() string {
+	Init()
+	return workFilePath
+}
+
+// inWorkspaceMode reports whether the go command is running in
+// workspace mode.
+func inWorkspaceMode() bool {
+	return workFilePath != ""
+}
+
+// initWorkspace reads the go.work file at path, sets workRoots, and returns
+// the root of the main module: the workspace module containing the current
+// directory or, if there is none, the first module listed in the file.
+func initWorkspace(path string) string {
+	if !filepath.IsAbs(path) {
+		base.Fatalf("go: invalid GOWORK: %s is not an absolute path", path)
+	}
+	data, err := lockedfile.Read(path)
+	if err != nil {
+		base.Fatalf("go: %v", err)
+	}
+	wf, err := workfile.Parse(path, data)
+	if err != nil {
+		// Errors returned by workfile.Parse begin with file:line.
+		base.Fatalf("go: errors parsing %s:\n%s\n", base.ShortPath(path), err)
+	}
+	if len(wf.Use) == 0 {
+		base.Fatalf("go: %s does not list any modules\n\tto add one, run:\n\tgo work use <dir>", base.ShortPath(path))
+	}
+
+	root := ""
+	seen := make(map[string]bool)
+	for _, u := range wf.Use {
+		dir := u.Path
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(filepath.Dir(path), dir)
+		}
+		dir = filepath.Clean(dir)
+		if seen[dir] {
+			base.Fatalf("go: %s: directory %s is listed more than once", base.ShortPath(path), u.Path)
+		}
+		seen[dir] = true
+		if fi, err := os.Stat(filepath.Join(dir, "go.mod")); err != nil || fi.IsDir() {
+			base.Fatalf("go: %s: directory %s does not contain a go.mod file", base.ShortPath(path), u.Path)
+		}
+		workRoots = append(workRoots, dir)
+		if search.InDir(base.Cwd, dir) != "" && len(dir) > len(root) {
+			root = dir
+		}
+	}
+	if root == "" {
+		root = workRoots[0]
+	}
+	return root
+}
+
+// loadWorkModules reads the go.mod files of the workspace modules other
+// than the main module. Their replace and exclude directives are added to
+// those of the main module.
+func loadWorkModules() {
+	workModDirs = make(map[string]string)
+	workModFiles = make(map[string]*modfile.File)
+	for _, dir := range workRoots {
+		if dir == modRoot {
+			continue
+		}
+		gomod := filepath.Join(dir, "go.mod")
+		data, err := lockedfile.Read(gomod)
+		if err != nil {
+			base.Fatalf("go: %v", err)
+		}
+		f, err := modfile.Parse(gomod, data, nil)
+		if err != nil {
+			base.Fatalf("go: errors parsing %s:\n%s\n", base.ShortPath(gomod), err)
+		}
+		if f.Module == nil {
+			base.Fatalf("go: %s: missing module declaration", base.ShortPath(gomod))
+		}
+		path := f.Module.Mod.Path
+		if prev, ok := workModDirs[path]; ok || path == Target.Path {
+			if !ok {
+				prev = modRoot
+			}
+			base.Fatalf("go: module %s appears multiple times in workspace:\n\t%s\n\t%s", path, base.ShortPath(prev), base.ShortPath(dir))
+		}
+		workModDirs[path] = dir
+		workModFiles[path] = f
+		workModules = append(workModules, module.Version{Path: path})
+
+		for _, r := range f.Replace {
+			repl := r.New
+			if repl.Version == "" && !filepath.IsAbs(repl.Path) {
+				repl.Path = filepath.Join(dir, repl.Path)
+			}
+			if prev, dup := index.replace[r.Old]; dup && prev != repl {
+				base.Fatalf("go: conflicting replacements for %v:\n\t%v\n\t%v", r.Old, prev, repl)
+			}
+			index.replace[r.Old] = repl
+		}
+		for _, x := range f.Exclude {
+			index.exclude[x.Mod] = true
+		}
+	}
+	sort.Slice(workModules, func(i, j int) bool { return workModules[i].Path < workModules[j].Path })
+}
+
+// workModuleDir returns the root directory of m if m is one of the
+// workspace modules other than the main module.
+func workModuleDir(m module.Version) (string, bool) {
+	if m.Version != "" {
+		return "", false
+	}
+	dir, ok := workModDirs[m.Path]
+	return dir, ok
+}
+
+// workReplacement returns the directory that replaces every version of the
+// module with the given path, if the path is that of a workspace module.
+func workReplacement(path string) (string, bool) {
+	if !inWorkspaceMode() {
+		return "", false
+	}
+	if path == Target.Path {
+		return modRoot, true
+	}
+	dir, ok := workModDirs[path]
+	return dir, ok
+}
+
+// mainModules returns the main module followed by the other workspace
+// modules, if any.
+func mainModules() []module.Version {
+	return append([]module.Version{Target}, workModules...)
+}
+
+// workModuleForDir returns the workspace module whose root is the closest
+// parent of dir, unless that module is the main module.
+func workModuleForDir(dir string) (m module.Version, root string, ok bool) {
+	for path, d := range workModDirs {
+		if search.InDir(dir, d) != "" && len(d) > len(root) {
+			m, root = module.Version{Path: path}, d
+		}
+	}
+	if root == "" || (search.InDir(dir, modRoot) != "" && len(modRoot) > len(root)) {
+		return module.Version{}, "", false
+	}
+	return m, root, true
+}
+
+// dirInWorkspace reports whether dir is in one of the workspace modules or
+// contains the root of one.
+func dirInWorkspace(dir string) bool {
+	for _, root := range workRoots {
+		if search.InDir(dir, root) != "" || search.InDir(root, dir) != "" {
+			return true
+		}
+	}
+	return false
+}
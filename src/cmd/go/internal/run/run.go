@@ -50,6 +50,7 @@ func init() {
 	CmdRun.Run = runRun // break init loop
 
 	work.AddBuildFlags(CmdRun, work.DefaultBuildFlags)
+	work.AddCoverFlags(CmdRun)
 	CmdRun.Flag.Var((*base.StringsFlag)(&work.ExecCmd), "exec", "")
 }
 
@@ -100,6 +101,10 @@ func runRun(cmd *base.Command, args []string) {
 	}
 
 	p.Internal.OmitDebug = true
+	if cfg.BuildCover {
+		load.PrepareForCoverageBuild([]*load.Package{p})
+	}
+	load.PreparePGO([]*load.Package{p})
 	if len(p.DepsErrors) > 0 {
 		// Since these are errors in dependencies,
 		// the same error might show up multiple times,
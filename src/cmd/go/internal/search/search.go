@@ -206,6 +206,44 @@ func SetModRoot(dir string) {
 	modRoot = dir
 }
 
+// workRoots holds the roots of the modules in the workspace,
+// if the go command is running in workspace mode.
+var workRoots []string
+
+// SetWorkspaceRoots records the roots of the modules in the workspace,
+// so that directory patterns may match packages in any of them.
+func SetWorkspaceRoots(dirs []string) {
+	workRoots = dirs
+}
+
+// inWorkspace reports whether dir is in one of the workspace modules
+// or contains the root of one.
+func inWorkspace(dir string) bool {
+	for _, root := range workRoots {
+		if hasFilepathPrefix(dir, root) || hasFilepathPrefix(root, dir) {
+			return true
+		}
+	}
+	return false
+}
+
+// isWorkspaceRoot reports whether dir is the root of a workspace module.
+func isWorkspaceRoot(dir string) bool {
+	if len(workRoots) == 0 {
+		return false
+	}
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return false
+	}
+	for _, root := range workRoots {
+		if abs == root {
+			return true
+		}
+	}
+	return false
+}
+
 // MatchDirs sets m.Dirs to a non-nil slice containing all directories that
 // potentially match a local pattern. The pattern must begin with an absolute
 // path, or "./", or "../". On Windows, the pattern may use slash or backslash
@@ -257,7 +295,7 @@ func (m *Match) MatchDirs() {
 			m.AddError(err)
 			return
 		}
-		if !hasFilepathPrefix(abs, modRoot) {
+		if !hasFilepathPrefix(abs, modRoot) && !inWorkspace(abs) {
 			m.AddError(fmt.Errorf("directory %s is outside module root (%s)", abs, modRoot))
 			return
 		}
@@ -292,8 +330,9 @@ func (m *Match) MatchDirs() {
 		}
 
 		if !top && cfg.ModulesEnabled {
-			// Ignore other modules found in subdirectories.
-			if fi, err := os.Stat(filepath.Join(path, "go.mod")); err == nil && !fi.IsDir() {
+			// Ignore other modules found in subdirectories,
+			// unless they are part of the workspace.
+			if fi, err := os.Stat(filepath.Join(path, "go.mod")); err == nil && !fi.IsDir() && !isWorkspaceRoot(path) {
 				return filepath.SkipDir
 			}
 		}
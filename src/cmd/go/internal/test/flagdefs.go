@@ -19,6 +19,10 @@ var passFlagToTest = map[string]bool{
 	"cpu":                  true,
 	"cpuprofile":           true,
 	"failfast":             true,
+	"fuzz":                 true,
+	"fuzzminimizetime":     true,
+	"fuzztime":             true,
+	"goroutineleakcheck":   true,
 	"list":                 true,
 	"memprofile":           true,
 	"memprofilerate":       true,
@@ -28,6 +32,8 @@ var passFlagToTest = map[string]bool{
 	"parallel":             true,
 	"run":                  true,
 	"short":                true,
+	"shuffle":              true,
+	"testtimeout":          true,
 	"timeout":              true,
 	"trace":                true,
 	"v":                    true,
@@ -62,9 +62,10 @@ func testFlags() []string {
 		}
 		name := strings.TrimPrefix(f.Name, "test.")
 
-		if name == "testlogfile" {
-			// test.testlogfile is “for use only by cmd/go”
-		} else {
+		switch name {
+		case "testlogfile", "fuzzcachedir", "fuzzworker":
+			// These flags are only for use by cmd/go.
+		default:
 			names = append(names, name)
 		}
 	})
@@ -6,7 +6,6 @@ package test
 
 import (
 	"bytes"
-	"crypto/sha256"
 	"errors"
 	"fmt"
 	"go/build"
@@ -14,7 +13,6 @@ import (
 	"io/ioutil"
 	"os"
 	"os/exec"
-	"path"
 	"path/filepath"
 	"regexp"
 	"sort"
@@ -31,6 +29,7 @@ import (
 	"cmd/go/internal/modload"
 	"cmd/go/internal/str"
 	"cmd/go/internal/work"
+	"cmd/internal/sys"
 	"cmd/internal/test2json"
 )
 
@@ -126,7 +125,7 @@ root (usually $GOPATH) or that consult environment variables only
 match future runs in which the files and environment variables are unchanged.
 A cached test result is treated as executing in no time at all,
 so a successful package test result will be cached and reused
-regardless of -timeout setting.
+regardless of -timeout and -testtimeout settings.
 
 In addition to the build flags, the flags handled by 'go test' itself are:
 
@@ -153,6 +152,13 @@ In addition to the build flags, the flags handled by 'go test' itself are:
 	    Convert test output to JSON suitable for automated processing.
 	    See 'go doc test2json' for the encoding details.
 
+	-benchmetrics
+	    With -json, also report the results of each benchmark run
+	    as a "metrics" event holding the number of iterations and the
+	    value of each metric, such as ns/op, B/op, or a custom metric
+	    added with b.ReportMetric. The 'go tool benchdiff' command
+	    compares two files of such results.
+
 	-o file
 	    Compile the test binary to the named file.
 	    The test still runs (unless -c or -i is specified).
@@ -206,6 +212,9 @@ control the execution of any test:
 	    Run each test and benchmark n times (default 1).
 	    If -cpu is set, run n times for each GOMAXPROCS value.
 	    Examples are always run once.
+	    If n is "untilfail", run the tests over and over until one of
+	    them fails, stopping early if another run would exceed the
+	    -timeout deadline. Benchmarks are run once.
 
 	-cover
 	    Enable coverage analysis.
@@ -239,6 +248,39 @@ control the execution of any test:
 	-failfast
 	    Do not start new tests after the first test failure.
 
+	-fuzz regexp
+	    Run the fuzz target matching the regular expression. When specified,
+	    the command line argument must match exactly one package, and
+	    regexp must match exactly one fuzz target within that package.
+	    Fuzzing runs after tests, benchmarks, seed corpora of other fuzz
+	    targets, and examples have completed. Fuzzing is only supported
+	    on darwin, freebsd and linux, and coverage guidance is only
+	    available on amd64 and arm64. See the Fuzzing section of the
+	    testing package documentation for details.
+
+	-fuzztime t
+	    Run enough iterations of the fuzz target during fuzzing to take t,
+	    specified as a time.Duration (for example, -fuzztime 1h30s).
+	    The default is to run forever.
+	    The special syntax Nx means to run the fuzz target N times
+	    (for example, -fuzztime 1000x).
+
+	-fuzzminimizetime t
+	    Run enough iterations of the fuzz target during each minimization
+	    attempt to take t, as specified as a time.Duration (for example,
+	    -fuzzminimizetime 30s).
+	    The default is 60s.
+	    The special syntax Nx means to run the fuzz target N times
+	    (for example, -fuzzminimizetime 100x).
+
+	-goroutineleakcheck
+	    Fail each top-level test that leaves goroutines behind that are
+	    blocked forever, as reported by the goroutineleak profile of
+	    runtime/pprof. The check runs after the test and its subtests
+	    and cleanups have finished, and is expensive. Goroutines leaked
+	    by parallel tests may be reported by another test that finishes
+	    at the same time.
+
 	-list regexp
 	    List tests, benchmarks, or examples matching the regular expression.
 	    No tests, benchmarks or examples will be run. This will only
@@ -269,6 +311,22 @@ control the execution of any test:
 	    the Go tree can run a sanity check but not spend time running
 	    exhaustive tests.
 
+	-shuffle off,on,N
+	    Randomize the execution order of tests and benchmarks.
+	    It is off by default. If -shuffle is set to on, then it will seed
+	    the randomizer using the system clock. If -shuffle is set to an
+	    integer N, then N will be used as the seed value. In both cases,
+	    the seed will be reported for reproducibility.
+
+	-testtimeout d
+	    If a single test or subtest runs longer than duration d, fail it,
+	    printing the stack of the goroutine running it, and continue
+	    with the remaining tests. The timed-out test's goroutine is
+	    abandoned, so its cleanup functions may not run.
+	    The time a parallel test spends waiting to run is not counted.
+	    A test can learn its deadline by calling t.Deadline.
+	    If d is 0, the default, there is no per-test timeout.
+
 	-timeout d
 	    If a test binary runs longer than duration d, panic.
 	    If d is 0, the timeout is disabled.
@@ -408,7 +466,7 @@ var HelpTestfunc = &base.Command{
 	UsageLine: "testfunc",
 	Short:     "testing functions",
 	Long: `
-The 'go test' command expects to find test, benchmark, and example functions
+The 'go test' command expects to find test, benchmark, fuzz, and example functions
 in the "*_test.go" files corresponding to the package under test.
 
 A test function is one named TestXxx (where Xxx does not start with a
@@ -420,6 +478,10 @@ A benchmark function is one named BenchmarkXxx and should have the signature,
 
 	func BenchmarkXxx(b *testing.B) { ... }
 
+A fuzz target is one named FuzzXxx and should have the signature,
+
+	func FuzzXxx(f *testing.F) { ... }
+
 An example function is similar to a test function but, instead of using
 *testing.T to report success or failure, prints output to os.Stdout.
 If the last comment in the function starts with "Output:" then the output
@@ -467,12 +529,14 @@ See the documentation of the testing package for more information.
 
 var (
 	testBench        string                            // -bench flag
+	testBenchMetrics bool                              // -benchmetrics flag
 	testC            bool                              // -c flag
 	testCover        bool                              // -cover flag
 	testCoverMode    string                            // -covermode flag
 	testCoverPaths   []string                          // -coverpkg flag
 	testCoverPkgs    []*load.Package                   // -coverpkg flag
 	testCoverProfile string                            // -coverprofile flag
+	testFuzz         string                            // -fuzz flag
 	testJSON         bool                              // -json flag
 	testList         string                            // -list flag
 	testO            string                            // -o flag
@@ -580,7 +644,11 @@ func runTest(cmd *base.Command, args []string) {
 	if len(pkgs) == 0 {
 		base.Fatalf("no packages to test")
 	}
+	load.PreparePGO(pkgs)
 
+	if testBenchMetrics && !testJSON {
+		base.Fatalf("cannot use -benchmetrics flag without -json")
+	}
 	if testC && len(pkgs) != 1 {
 		base.Fatalf("cannot use -c flag with multiple packages")
 	}
@@ -590,6 +658,14 @@ func runTest(cmd *base.Command, args []string) {
 	if testProfile() != "" && len(pkgs) != 1 {
 		base.Fatalf("cannot use %s flag with multiple packages", testProfile())
 	}
+	if testFuzz != "" {
+		if !sys.FuzzSupported(cfg.Goos, cfg.Goarch) {
+			base.Fatalf("-fuzz flag is not supported on %s/%s", cfg.Goos, cfg.Goarch)
+		}
+		if len(pkgs) != 1 {
+			base.Fatalf("cannot use -fuzz flag with multiple packages")
+		}
+	}
 	initCoverProfile()
 	defer closeCoverProfile()
 
@@ -597,7 +673,10 @@ func runTest(cmd *base.Command, args []string) {
 	// to that timeout plus one minute. This is a backup alarm in case
 	// the test wedges with a goroutine spinning and its background
 	// timer does not get a chance to fire.
-	if testTimeout > 0 {
+	// Fuzzing runs until it is interrupted or -fuzztime expires,
+	// and the test binary disables its own alarm before fuzzing,
+	// so don't set a kill timeout in that case.
+	if testTimeout > 0 && testFuzz == "" {
 		testKillTimeout = testTimeout + 1*time.Minute
 	}
 
@@ -731,18 +810,32 @@ func runTest(cmd *base.Command, args []string) {
 			coverFiles = append(coverFiles, p.GoFiles...)
 			coverFiles = append(coverFiles, p.CgoFiles...)
 			coverFiles = append(coverFiles, p.TestGoFiles...)
-			p.Internal.CoverVars = declareCoverVars(p, coverFiles...)
+			p.Internal.CoverVars = load.DeclareCoverVars(p, coverFiles...)
 			if testCover && testCoverMode == "atomic" {
-				ensureImport(p, "sync/atomic")
+				load.EnsureImport(p, "sync/atomic")
 			}
 		}
 	}
 
+	if testFuzz != "" && sys.FuzzInstrumented(cfg.Goos, cfg.Goarch) {
+		// Instrument the package under test and its dependencies so that
+		// the fuzzing engine can use coverage to guide mutation. Packages
+		// that are always busy while the engine runs, or that the engine
+		// itself depends on, are skipped: counters in them would only add
+		// noise to the coverage signal.
+		for _, p := range load.TestPackageList(pkgs) {
+			if p.Standard && (skipFuzzInstrumentation[p.ImportPath] || strings.HasPrefix(p.ImportPath, "runtime/") || strings.HasPrefix(p.ImportPath, "internal/")) {
+				continue
+			}
+			p.Internal.FuzzInstrument = true
+		}
+	}
+
 	// Prepare build + run + print actions for all packages being tested.
 	for _, p := range pkgs {
 		// sync/atomic import is inserted by the cover tool. See #18486
 		if testCover && testCoverMode == "atomic" {
-			ensureImport(p, "sync/atomic")
+			load.EnsureImport(p, "sync/atomic")
 		}
 
 		buildTest, runTest, printTest, err := builderTest(&b, p)
@@ -789,20 +882,17 @@ func runTest(cmd *base.Command, args []string) {
 	b.Do(root)
 }
 
-// ensures that package p imports the named package
-func ensureImport(p *load.Package, pkg string) {
-	for _, d := range p.Internal.Imports {
-		if d.Name == pkg {
-			return
-		}
-	}
-
-	p1 := load.LoadImportWithFlags(pkg, p.Dir, p, &load.ImportStack{}, nil, 0)
-	if p1.Error != nil {
-		base.Fatalf("load %s: %v", pkg, p1.Error)
-	}
-
-	p.Internal.Imports = append(p.Internal.Imports, p1)
+// skipFuzzInstrumentation lists standard packages that are not
+// instrumented for coverage when fuzzing.
+var skipFuzzInstrumentation = map[string]bool{
+	"context":     true,
+	"reflect":     true,
+	"runtime":     true,
+	"sync":        true,
+	"sync/atomic": true,
+	"syscall":     true,
+	"testing":     true,
+	"time":        true,
 }
 
 var windowsBadWords = []string{
@@ -832,7 +922,7 @@ func builderTest(b *work.Builder, p *load.Package) (buildAction, runAction, prin
 			Local:    testCover && testCoverPaths == nil,
 			Pkgs:     testCoverPkgs,
 			Paths:    testCoverPaths,
-			DeclVars: declareCoverVars,
+			DeclVars: load.DeclareCoverVars,
 		}
 	}
 	pmain, ptest, pxtest, err := load.TestPackagesFor(p, cover)
@@ -998,50 +1088,6 @@ func addTestVet(b *work.Builder, p *load.Package, runAction, installAction *work
 	}
 }
 
-// isTestFile reports whether the source file is a set of tests and should therefore
-// be excluded from coverage analysis.
-func isTestFile(file string) bool {
-	// We don't cover tests, only the code they test.
-	return strings.HasSuffix(file, "_test.go")
-}
-
-// declareCoverVars attaches the required cover variables names
-// to the files, to be used when annotating the files.
-func declareCoverVars(p *load.Package, files ...string) map[string]*load.CoverVar {
-	coverVars := make(map[string]*load.CoverVar)
-	coverIndex := 0
-	// We create the cover counters as new top-level variables in the package.
-	// We need to avoid collisions with user variables (GoCover_0 is unlikely but still)
-	// and more importantly with dot imports of other covered packages,
-	// so we append 12 hex digits from the SHA-256 of the import path.
-	// The point is only to avoid accidents, not to defeat users determined to
-	// break things.
-	sum := sha256.Sum256([]byte(p.ImportPath))
-	h := fmt.Sprintf("%x", sum[:6])
-	for _, file := range files {
-		if isTestFile(file) {
-			continue
-		}
-		// For a package that is "local" (imported via ./ import or command line, outside GOPATH),
-		// we record the full path to the file name.
-		// Otherwise we record the import path, then a forward slash, then the file name.
-		// This makes profiles within GOPATH file system-independent.
-		// These names appear in the cmd/cover HTML interface.
-		var longFile string
-		if p.Internal.Local {
-			longFile = filepath.Join(p.Dir, file)
-		} else {
-			longFile = path.Join(p.ImportPath, file)
-		}
-		coverVars[file] = &load.CoverVar{
-			File: longFile,
-			Var:  fmt.Sprintf("GoCover_%d_%x", coverIndex, h),
-		}
-		coverIndex++
-	}
-	return coverVars
-}
-
 var noTestsToRun = []byte("\ntesting: warning: no tests to run\n")
 
 type runCache struct {
@@ -1081,7 +1127,7 @@ func (c *runCache) builderRunTest(b *work.Builder, a *work.Action) error {
 	var stdout io.Writer = os.Stdout
 	var err error
 	if testJSON {
-		json := test2json.NewConverter(lockedStdout{}, a.Package.ImportPath, test2json.Timestamp)
+		json := test2json.NewConverter(lockedStdout{}, a.Package.ImportPath, testJSONMode())
 		defer func() {
 			json.Exited(err)
 			json.Close()
@@ -1090,10 +1136,10 @@ func (c *runCache) builderRunTest(b *work.Builder, a *work.Action) error {
 	}
 
 	var buf bytes.Buffer
-	if len(pkgArgs) == 0 || (testBench != "") {
+	if len(pkgArgs) == 0 || testBench != "" || testFuzz != "" {
 		// Stream test output (no buffering) when no package has
 		// been given on the command line (implicit current directory)
-		// or when benchmarking.
+		// or when benchmarking or fuzzing.
 		// No change to stdout.
 	} else {
 		// If we're only running a single package under test or if parallelism is
@@ -1145,7 +1191,12 @@ func (c *runCache) builderRunTest(b *work.Builder, a *work.Action) error {
 	if !c.disableCache && len(execCmd) == 0 {
 		testlogArg = []string{"-test.testlogfile=" + a.Objdir + "testlog.txt"}
 	}
-	args := str.StringList(execCmd, a.Deps[0].BuiltTarget(), testlogArg, testArgs)
+	var fuzzArg []string
+	if testFuzz != "" {
+		fuzzCacheDir := filepath.Join(cache.Default().FuzzDir(), a.Package.ImportPath)
+		fuzzArg = []string{"-test.fuzzcachedir=" + fuzzCacheDir}
+	}
+	args := str.StringList(execCmd, a.Deps[0].BuiltTarget(), testlogArg, fuzzArg, testArgs)
 
 	if testCoverProfile != "" {
 		// Write coverage to temporary profile, for merging later.
@@ -1307,6 +1358,7 @@ func (c *runCache) tryCacheWithID(b *work.Builder, a *work.Action, id string) bo
 			"-test.parallel",
 			"-test.run",
 			"-test.short",
+			"-test.testtimeout",
 			"-test.timeout",
 			"-test.v":
 			// These are cacheable.
@@ -1667,11 +1719,20 @@ func builderPrintTest(b *work.Builder, a *work.Action) error {
 	return nil
 }
 
+// testJSONMode returns the test2json conversion mode for -json.
+func testJSONMode() test2json.Mode {
+	mode := test2json.Timestamp
+	if testBenchMetrics {
+		mode |= test2json.BenchMetrics
+	}
+	return mode
+}
+
 // builderNoTest is the action for testing a package with no test files.
 func builderNoTest(b *work.Builder, a *work.Action) error {
 	var stdout io.Writer = os.Stdout
 	if testJSON {
-		json := test2json.NewConverter(lockedStdout{}, a.Package.ImportPath, test2json.Timestamp)
+		json := test2json.NewConverter(lockedStdout{}, a.Package.ImportPath, testJSONMode())
 		defer json.Close()
 		stdout = json
 	}
@@ -10,6 +10,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
@@ -40,6 +41,7 @@ func init() {
 
 	cf.Var((*base.StringsFlag)(&work.ExecCmd), "exec", "")
 	cf.BoolVar(&testJSON, "json", false, "")
+	cf.BoolVar(&testBenchMetrics, "benchmetrics", false, "")
 	cf.Var(&testVet, "vet", "")
 
 	// Register flags to be forwarded to the test binary. We retain variables for
@@ -51,11 +53,15 @@ func init() {
 	cf.String("benchtime", "", "")
 	cf.StringVar(&testBlockProfile, "blockprofile", "", "")
 	cf.String("blockprofilerate", "", "")
-	cf.Int("count", 0, "")
+	cf.Var(new(countFlag), "count", "")
 	cf.Var(coverFlag{stringFlag{&testCoverProfile}}, "coverprofile", "")
 	cf.String("cpu", "", "")
 	cf.StringVar(&testCPUProfile, "cpuprofile", "", "")
 	cf.Bool("failfast", false, "")
+	cf.StringVar(&testFuzz, "fuzz", "", "")
+	cf.String("fuzzminimizetime", "", "")
+	cf.String("fuzztime", "", "")
+	cf.Bool("goroutineleakcheck", false, "")
 	cf.StringVar(&testList, "list", "", "")
 	cf.StringVar(&testMemProfile, "memprofile", "", "")
 	cf.String("memprofilerate", "", "")
@@ -65,6 +71,8 @@ func init() {
 	cf.Int("parallel", 0, "")
 	cf.String("run", "", "")
 	cf.Bool("short", false, "")
+	cf.String("shuffle", "", "")
+	cf.Duration("testtimeout", 0, "")
 	cf.DurationVar(&testTimeout, "timeout", 10*time.Minute, "")
 	cf.StringVar(&testTrace, "trace", "", "")
 	cf.BoolVar(&testV, "v", false, "")
@@ -100,6 +108,21 @@ func (f *coverModeFlag) Set(value string) error {
 	}
 }
 
+// countFlag implements the -count flag, which is either a non-negative
+// integer or "untilfail".
+type countFlag string
+
+func (f *countFlag) String() string { return string(*f) }
+func (f *countFlag) Set(value string) error {
+	if value != "untilfail" {
+		if n, err := strconv.Atoi(value); err != nil || n < 0 {
+			return errors.New(`must be a non-negative integer or "untilfail"`)
+		}
+	}
+	*f = countFlag(value)
+	return nil
+}
+
 // A commaListFlag is a flag.Value representing a comma-separated list.
 type commaListFlag struct{ vals *[]string }
 
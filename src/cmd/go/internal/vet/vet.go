@@ -40,6 +40,29 @@ For example, the 'shadow' analyzer can be built and run using these commands:
   go install golang.org/x/tools/go/analysis/passes/shadow/cmd/shadow
   go vet -vettool=$(which shadow)
 
+If the root directory of a package's module contains a file named go.vet,
+vet reads it to decide which checkers to run on the package and how to
+configure them. Each line of the file holds one directive:
+
+	enable name...      run the named checkers, including optional ones
+	disable name...     do not run the named checkers
+	flag name=value     set a checker flag, such as printf.funcs
+
+The enable and disable directives are ignored when checkers are selected
+on the command line, and the command line also overrides flag directives.
+A comment of the form
+
+	//vet:ignore name...
+
+placed before the package clause of a Go source file suppresses the
+diagnostics of the named checkers in that file. Placed after the package
+clause, it suppresses them on its own line if it follows code there, and
+otherwise on the next line.
+
+The -fix flag makes vet apply the fixes that checkers suggest for some of
+their diagnostics, rewriting the source files in place. Diagnostics that
+were fixed are not reported.
+
 The build flags supported by go vet are those that control package resolution
 and execution, such as -n, -x, -v, -tags, and -toolexec.
 For more about these flags, see 'go help build'.
@@ -49,6 +49,34 @@ then any resulting executables will be written to that directory.
 
 The -i flag installs the packages that are dependencies of the target.
 
+The coverage flags build executables instrumented for coverage analysis.
+They are accepted by the build, install and run commands; 'go test' has
+coverage flags of its own (see 'go help testflag').
+
+	-cover
+		enable code coverage instrumentation. When an instrumented
+		program exits, it writes coverage data files into the directory
+		named by the GOCOVERDIR environment variable. Use
+		'go tool covdata' to merge those files and to convert them into
+		coverage profiles or per-package percentages.
+	-covermode set,count,atomic
+		set the mode for coverage analysis.
+		The default is "set" unless -race is enabled,
+		in which case it is "atomic".
+		The values:
+		set: bool: does this statement run?
+		count: int: how many times does this statement run?
+		atomic: int: count, but correct in multithreaded programs;
+			significantly more expensive.
+		Sets -cover.
+	-coverpkg pattern1,pattern2,pattern3
+		apply coverage analysis to each package matching the patterns.
+		The default is to apply coverage analysis to the packages in
+		the main module, or, in GOPATH mode, to the packages named on
+		the command line. The main package being built is always
+		instrumented. See 'go help packages' for a description of
+		package patterns. Sets -cover.
+
 The build flags are shared by the build, clean, get, install, list, run,
 and test commands:
 
@@ -112,6 +140,19 @@ and test commands:
 		directory, but it is not accessed. When -modfile is specified, an
 		alternate go.sum file is also used: its path is derived from the
 		-modfile flag by trimming the ".mod" extension and appending ".sum".
+	-pgo file
+		specify the file path of a profile for profile-guided optimization
+		(PGO), such as a CPU profile collected with net/http/pprof.
+		The compiler uses the profile to inline hot calls and to
+		devirtualize hot interface method calls.
+		The special name "auto" lets the go command select a file named
+		"default.pgo" in the directory of each main package being built,
+		if one exists; the profile applies to the main package and its
+		dependencies, except for dependencies shared by main packages
+		with different profiles. The special name "off" turns off PGO.
+		A file given explicitly applies to all packages in the build.
+		The default is auto. The profile's contents are part of the
+		build cache key.
 	-pkgdir dir
 		install and load all packages from dir instead of the usual locations.
 		For example, when building with a non-standard configuration,
@@ -179,6 +220,8 @@ func init() {
 
 	AddBuildFlags(CmdBuild, DefaultBuildFlags)
 	AddBuildFlags(CmdInstall, DefaultBuildFlags)
+	AddCoverFlags(CmdBuild)
+	AddCoverFlags(CmdInstall)
 }
 
 // Note that flags consulted by other parts of the code
@@ -260,6 +303,7 @@ func AddBuildFlags(cmd *base.Command, mask BuildFlagMask) {
 	cmd.Flag.StringVar(&cfg.BuildContext.InstallSuffix, "installsuffix", "", "")
 	cmd.Flag.Var(&load.BuildLdflags, "ldflags", "")
 	cmd.Flag.BoolVar(&cfg.BuildLinkshared, "linkshared", false, "")
+	cmd.Flag.StringVar(&cfg.BuildPGO, "pgo", "auto", "")
 	cmd.Flag.StringVar(&cfg.BuildPkgdir, "pkgdir", "", "")
 	cmd.Flag.BoolVar(&cfg.BuildRace, "race", false, "")
 	cmd.Flag.BoolVar(&cfg.BuildMSan, "msan", false, "")
@@ -272,6 +316,46 @@ func AddBuildFlags(cmd *base.Command, mask BuildFlagMask) {
 	cmd.Flag.StringVar(&cfg.DebugActiongraph, "debug-actiongraph", "", "")
 }
 
+// AddCoverFlags adds the coverage flags (-cover, -covermode and -coverpkg)
+// used by the build, install and run commands.
+func AddCoverFlags(cmd *base.Command) {
+	cmd.Flag.BoolVar(&cfg.BuildCover, "cover", false, "")
+	cmd.Flag.Var(coverModeFlag{}, "covermode", "")
+	cmd.Flag.Var(coverPkgFlag{}, "coverpkg", "")
+}
+
+// coverModeFlag implements the -covermode flag, which implies -cover.
+type coverModeFlag struct{}
+
+func (coverModeFlag) String() string { return cfg.BuildCoverMode }
+
+func (coverModeFlag) Set(value string) error {
+	switch value {
+	case "set", "count", "atomic":
+		cfg.BuildCoverMode = value
+		cfg.BuildCover = true
+		return nil
+	default:
+		return errors.New(`valid modes are "set", "count", or "atomic"`)
+	}
+}
+
+// coverPkgFlag implements the -coverpkg flag, a comma-separated list
+// of package patterns, which implies -cover.
+type coverPkgFlag struct{}
+
+func (coverPkgFlag) String() string { return strings.Join(cfg.BuildCoverPkg, ",") }
+
+func (coverPkgFlag) Set(value string) error {
+	if value == "" {
+		cfg.BuildCoverPkg = nil
+	} else {
+		cfg.BuildCoverPkg = strings.Split(value, ",")
+	}
+	cfg.BuildCover = true
+	return nil
+}
+
 // AddModCommonFlags adds the module-related flags common to build commands
 // and 'go mod' subcommands.
 func AddModCommonFlags(cmd *base.Command) {
@@ -349,6 +433,10 @@ func runBuild(cmd *base.Command, args []string) {
 	b.Init()
 
 	pkgs := load.PackagesForBuild(args)
+	if cfg.BuildCover {
+		load.PrepareForCoverageBuild(pkgs)
+	}
+	load.PreparePGO(pkgs)
 
 	explicitO := len(cfg.BuildO) > 0
 
@@ -516,7 +604,12 @@ func libname(args []string, pkgs []*load.Package) (string, error) {
 
 func runInstall(cmd *base.Command, args []string) {
 	BuildInit()
-	InstallPackages(args, load.PackagesForBuild(args))
+	pkgs := load.PackagesForBuild(args)
+	if cfg.BuildCover {
+		load.PrepareForCoverageBuild(pkgs)
+	}
+	load.PreparePGO(pkgs)
+	InstallPackages(args, pkgs)
 }
 
 // omitTestOnly returns pkgs with test-only packages removed.
@@ -240,6 +240,12 @@ func (b *Builder) buildActionID(a *Action) cache.ActionID {
 	}
 	if p.Internal.CoverMode != "" {
 		fmt.Fprintf(h, "cover %q %q\n", p.Internal.CoverMode, b.toolID("cover"))
+		if p.Internal.CoverRegister {
+			fmt.Fprintf(h, "coverregister\n")
+		}
+	}
+	if p.Internal.FuzzInstrument {
+		fmt.Fprintf(h, "fuzz\n")
 	}
 	fmt.Fprintf(h, "modinfo %q\n", p.Internal.BuildInfo)
 
@@ -249,6 +255,9 @@ func (b *Builder) buildActionID(a *Action) cache.ActionID {
 		base.Fatalf("buildActionID: unknown build toolchain %q", cfg.BuildToolchainName)
 	case "gc":
 		fmt.Fprintf(h, "compile %s %q %q\n", b.toolID("compile"), forcedGcflags, p.Internal.Gcflags)
+		if p.Internal.PGOProfile != "" {
+			fmt.Fprintf(h, "pgofile %s\n", b.fileHash(p.Internal.PGOProfile))
+		}
 		if len(p.SFiles) > 0 {
 			fmt.Fprintf(h, "asm %q %q %q\n", b.toolID("asm"), forcedAsmflags, p.Internal.Asmflags)
 		}
@@ -324,6 +333,9 @@ func (b *Builder) buildActionID(a *Action) cache.ActionID {
 	for _, file := range inputFiles {
 		fmt.Fprintf(h, "file %s %s\n", file, b.fileHash(filepath.Join(p.Dir, file)))
 	}
+	for _, file := range p.EmbedFiles {
+		fmt.Fprintf(h, "embed %s %s\n", file, b.fileHash(filepath.Join(p.Dir, file)))
+	}
 	for _, a1 := range a.Deps {
 		p1 := a1.Package
 		if p1 != nil {
@@ -552,7 +564,7 @@ func (b *Builder) build(a *Action) (err error) {
 				// Not covering this file.
 				continue
 			}
-			if err := b.cover(a, coverFile, sourceFile, cover.Var); err != nil {
+			if err := b.cover(a, coverFile, sourceFile, cover); err != nil {
 				return err
 			}
 			if i < len(gofiles) {
@@ -674,6 +686,26 @@ func (b *Builder) build(a *Action) (err error) {
 		fmt.Fprintf(&icfg, "packagefile %s=%s\n", p1.ImportPath, a1.built)
 	}
 
+	// Prepare Go embed config if needed.
+	// Unlike the import config, it's okay for the embed config to be empty.
+	var embedcfg []byte
+	if len(p.Internal.Embed) > 0 {
+		var embed struct {
+			Patterns map[string][]string
+			Files    map[string]string
+		}
+		embed.Patterns = p.Internal.Embed
+		embed.Files = make(map[string]string)
+		for _, file := range p.EmbedFiles {
+			embed.Files[file] = filepath.Join(p.Dir, file)
+		}
+		js, err := json.MarshalIndent(&embed, "", "\t")
+		if err != nil {
+			return fmt.Errorf("marshal embedcfg: %v", err)
+		}
+		embedcfg = js
+	}
+
 	if p.Internal.BuildInfo != "" && cfg.ModulesEnabled {
 		if err := b.writeFile(objdir+"_gomod_.go", load.ModInfoProg(p.Internal.BuildInfo, cfg.BuildToolchainName == "gccgo")); err != nil {
 			return err
@@ -683,7 +715,7 @@ func (b *Builder) build(a *Action) (err error) {
 
 	// Compile Go.
 	objpkg := objdir + "_pkg_.a"
-	ofile, out, err := BuildToolchain.gc(b, a, objpkg, icfg.Bytes(), symabis, len(sfiles) > 0, gofiles)
+	ofile, out, err := BuildToolchain.gc(b, a, objpkg, icfg.Bytes(), embedcfg, symabis, len(sfiles) > 0, gofiles)
 	if len(out) > 0 {
 		output := b.processOutput(out)
 		if p.Module != nil && !allowedVersion(p.Module.GoVersion) {
@@ -918,6 +950,8 @@ type vetConfig struct {
 	VetxOnly    bool              // only compute vetx data; don't report detected problems
 	VetxOutput  string            // write vetx data to this output file
 
+	VetConfigFile string // module's go.vet file, if any
+
 	SucceedOnTypecheckFailure bool // awful hack; see #18395 and below
 }
 
@@ -1054,6 +1088,19 @@ func (b *Builder) vet(a *Action) error {
 	// It seems better to let the flags disable export analysis too.
 	fmt.Fprintf(h, "vetflags %q\n", vetFlags)
 
+	// The go.vet file in the root directory of the package's module
+	// configures the analyzers, but only for the packages that are
+	// being checked: the facts about the dependencies are computed
+	// the same way for every module.
+	vcfg.VetConfigFile = ""
+	if m := a.Package.Module; !vcfg.VetxOnly && m != nil && m.Dir != "" {
+		file := filepath.Join(m.Dir, "go.vet")
+		if fi, err := os.Stat(file); err == nil && fi.Mode().IsRegular() {
+			vcfg.VetConfigFile = file
+			fmt.Fprintf(h, "vetconfig %s\n", b.fileHash(file))
+		}
+	}
+
 	fmt.Fprintf(h, "pkg %q\n", a.Deps[0].actionID)
 	for _, a1 := range a.Deps {
 		if a1.Mode == "vet" && a1.built != "" {
@@ -1731,13 +1778,19 @@ func (b *Builder) installHeader(a *Action) error {
 }
 
 // cover runs, in effect,
-//	go tool cover -mode=b.coverMode -var="varName" -o dst.go src.go
-func (b *Builder) cover(a *Action, dst, src string, varName string) error {
+//	go tool cover -mode=b.coverMode -var="cv.Var" -o dst.go src.go
+// adding -register="cv.File" for packages built with go build -cover.
+func (b *Builder) cover(a *Action, dst, src string, cv *load.CoverVar) error {
+	var register []string
+	if a.Package.Internal.CoverRegister {
+		register = []string{"-register", cv.File}
+	}
 	return b.run(a, a.Objdir, "cover "+a.Package.ImportPath, nil,
 		cfg.BuildToolexec,
 		base.Tool("cover"),
 		"-mode", a.Package.Internal.CoverMode,
-		"-var", varName,
+		"-var", cv.Var,
+		register,
 		"-o", dst,
 		src)
 }
@@ -2080,7 +2133,7 @@ type toolchain interface {
 	// and returns the name of the generated output file.
 	//
 	// TODO: This argument list is long. Consider putting it in a struct.
-	gc(b *Builder, a *Action, archive string, importcfg []byte, symabis string, asmhdr bool, gofiles []string) (ofile string, out []byte, err error)
+	gc(b *Builder, a *Action, archive string, importcfg, embedcfg []byte, symabis string, asmhdr bool, gofiles []string) (ofile string, out []byte, err error)
 	// cc runs the toolchain's C compiler in a directory on a C file
 	// to produce an output file.
 	cc(b *Builder, a *Action, ofile, cfile string) error
@@ -2120,7 +2173,7 @@ func (noToolchain) linker() string {
 	return ""
 }
 
-func (noToolchain) gc(b *Builder, a *Action, archive string, importcfg []byte, symabis string, asmhdr bool, gofiles []string) (ofile string, out []byte, err error) {
+func (noToolchain) gc(b *Builder, a *Action, archive string, importcfg, embedcfg []byte, symabis string, asmhdr bool, gofiles []string) (ofile string, out []byte, err error) {
 	return "", nil, noCompiler()
 }
 
@@ -2960,7 +3013,7 @@ func (b *Builder) swigDoIntSize(objdir string) (intsize string, err error) {
 
 	p := load.GoFilesPackage(srcs)
 
-	if _, _, e := BuildToolchain.gc(b, &Action{Mode: "swigDoIntSize", Package: p, Objdir: objdir}, "", nil, "", false, srcs); e != nil {
+	if _, _, e := BuildToolchain.gc(b, &Action{Mode: "swigDoIntSize", Package: p, Objdir: objdir}, "", nil, nil, "", false, srcs); e != nil {
 		return "32", nil
 	}
 	return "64", nil
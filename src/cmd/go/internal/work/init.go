@@ -24,6 +24,7 @@ func BuildInit() {
 	load.ModInit()
 	instrumentInit()
 	buildModeInit()
+	coverInit()
 
 	// Make sure -pkgdir is absolute, because we run commands
 	// in different directories.
@@ -52,6 +53,25 @@ func BuildInit() {
 	}
 }
 
+func coverInit() {
+	if !cfg.BuildCover {
+		return
+	}
+	if cfg.BuildCoverMode == "" {
+		cfg.BuildCoverMode = "set"
+		if cfg.BuildRace {
+			// Default coverage mode is atomic when -race is set.
+			cfg.BuildCoverMode = "atomic"
+		}
+	}
+	if cfg.BuildRace && cfg.BuildCoverMode != "atomic" {
+		base.Fatalf(`-covermode must be "atomic", not %q, when -race is enabled`, cfg.BuildCoverMode)
+	}
+	if cfg.BuildToolchainName == "gccgo" {
+		base.Fatalf("-cover is not supported with gccgo")
+	}
+}
+
 func instrumentInit() {
 	if !cfg.BuildRace && !cfg.BuildMSan {
 		return
@@ -0,0 +1,190 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// go work edit
+
+package workcmd
+
+import (
+	"encoding/json"
+	"os"
+
+	"cmd/go/internal/base"
+	"cmd/go/internal/workfile"
+
+	"golang.org/x/mod/modfile"
+)
+
+var cmdEdit = &base.Command{
+	UsageLine: "go work edit [editing flags] [go.work]",
+	Short:     "edit go.work from tools or scripts",
+	Long: `
+Edit provides a command-line interface for editing go.work,
+for use primarily by tools or scripts. It only reads go.work;
+it does not look up information about the modules involved.
+If no file is specified, Edit looks for a go.work file in the current
+directory and its parent directories, as the go command does when
+selecting the workspace.
+
+The editing flags specify a sequence of editing operations.
+
+The -fmt flag reformats the go.work file without making other changes.
+This reformatting is also implied by any other modifications that use or
+rewrite the go.work file. The only time this flag is needed is if no other
+flags are specified, as in 'go work edit -fmt'.
+
+The -use=path and -dropuse=path flags
+add and drop a use directive for the given module directory.
+
+The -use and -dropuse editing flags may be repeated,
+and the changes are applied in the order given.
+
+The -go=version flag sets the expected Go language version.
+
+The -print flag prints the final go.work in its text format instead of
+writing it back to go.work.
+
+The -json flag prints the final go.work file in JSON format instead of
+writing it back to go.work. The JSON output corresponds to these Go types:
+
+	type GoWork struct {
+		Go  string
+		Use []Use
+	}
+
+	type Use struct {
+		DiskPath string
+	}
+
+See 'go help work' for more about workspaces.
+	`,
+}
+
+var (
+	editFmt   = cmdEdit.Flag.Bool("fmt", false, "")
+	editGo    = cmdEdit.Flag.String("go", "", "")
+	editJSON  = cmdEdit.Flag.Bool("json", false, "")
+	editPrint = cmdEdit.Flag.Bool("print", false, "")
+	workedits []func(*workfile.File) // edits specified in flags
+)
+
+type flagFunc func(string)
+
+func (f flagFunc) String() string     { return "" }
+func (f flagFunc) Set(s string) error { f(s); return nil }
+
+func init() {
+	cmdEdit.Run = runEdit // break init cycle
+
+	cmdEdit.Flag.Var(flagFunc(flagUse), "use", "")
+	cmdEdit.Flag.Var(flagFunc(flagDropUse), "dropuse", "")
+}
+
+func runEdit(cmd *base.Command, args []string) {
+	anyFlags :=
+		*editGo != "" ||
+			*editJSON ||
+			*editPrint ||
+			*editFmt ||
+			len(workedits) > 0
+
+	if !anyFlags {
+		base.Fatalf("go work edit: no flags specified (see 'go help work edit').")
+	}
+
+	if *editJSON && *editPrint {
+		base.Fatalf("go work edit: cannot use both -json and -print")
+	}
+
+	if len(args) > 1 {
+		base.Fatalf("go work edit: too many arguments")
+	}
+	var gowork string
+	if len(args) == 1 {
+		gowork = args[0]
+	} else {
+		gowork = mustFindWorkFile()
+	}
+
+	if *editGo != "" {
+		if !modfile.GoVersionRE.MatchString(*editGo) {
+			base.Fatalf(`go work: invalid -go option; expecting something like "-go 1.15"`)
+		}
+	}
+
+	wf, data := readWorkFile(gowork)
+
+	if *editGo != "" {
+		if err := wf.AddGoStmt(*editGo); err != nil {
+			base.Fatalf("go: internal error: %v", err)
+		}
+	}
+
+	for _, edit := range workedits {
+		edit(wf)
+	}
+
+	if *editJSON {
+		wf.Cleanup()
+		editPrintJSON(wf)
+		return
+	}
+
+	if *editPrint {
+		wf.Cleanup()
+		out, err := wf.Format()
+		if err != nil {
+			base.Fatalf("go: %v", err)
+		}
+		os.Stdout.Write(out)
+		return
+	}
+
+	writeWorkFile(gowork, wf, data)
+}
+
+// flagUse implements the -use flag.
+func flagUse(arg string) {
+	workedits = append(workedits, func(f *workfile.File) {
+		if err := f.AddUse(arg); err != nil {
+			base.Fatalf("go work: -use=%s: %v", arg, err)
+		}
+	})
+}
+
+// flagDropUse implements the -dropuse flag.
+func flagDropUse(arg string) {
+	workedits = append(workedits, func(f *workfile.File) {
+		if err := f.DropUse(arg); err != nil {
+			base.Fatalf("go work: -dropuse=%s: %v", arg, err)
+		}
+	})
+}
+
+// workfileJSON is the -json output data structure.
+type workfileJSON struct {
+	Go  string `json:",omitempty"`
+	Use []useJSON
+}
+
+type useJSON struct {
+	DiskPath string
+}
+
+// editPrintJSON prints the -json output.
+func editPrintJSON(workFile *workfile.File) {
+	var f workfileJSON
+	if workFile.Go != nil {
+		f.Go = workFile.Go.Version
+	}
+	for _, u := range workFile.Use {
+		f.Use = append(f.Use, useJSON{DiskPath: u.Path})
+	}
+	data, err := json.MarshalIndent(&f, "", "\t")
+	if err != nil {
+		base.Fatalf("go: internal error: %v", err)
+	}
+	data = append(data, '\n')
+	os.Stdout.Write(data)
+}
@@ -0,0 +1,67 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// go work init
+
+package workcmd
+
+import (
+	"go/build"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"cmd/go/internal/base"
+	"cmd/go/internal/cfg"
+	"cmd/go/internal/workfile"
+
+	"golang.org/x/mod/modfile"
+)
+
+var cmdInit = &base.Command{
+	UsageLine: "go work init [moddirs]",
+	Short:     "initialize workspace file",
+	Long: `
+Init initializes and writes a new go.work file in the current directory,
+in effect creating a new workspace there, or at the path named by the
+GOWORK environment variable. The file must not already exist.
+
+Init optionally accepts paths to the workspace modules as arguments.
+If an argument is omitted, an empty workspace with no modules is created;
+use 'go work use' to add modules to it.
+
+See 'go help work' for more about workspaces.
+	`,
+	Run: runInit,
+}
+
+func runInit(cmd *base.Command, args []string) {
+	path := filepath.Join(base.Cwd, "go.work")
+	if gowork := cfg.Getenv("GOWORK"); gowork != "" && gowork != "off" {
+		path = gowork
+	}
+	if _, err := os.Stat(path); err == nil {
+		base.Fatalf("go work init: %s already exists", base.ShortPath(path))
+	}
+
+	wf := &workfile.File{Syntax: new(modfile.FileSyntax)}
+	tags := build.Default.ReleaseTags
+	if err := wf.AddGoStmt(strings.TrimPrefix(tags[len(tags)-1], "go")); err != nil {
+		base.Fatalf("go: internal error: %v", err)
+	}
+	for _, dir := range args {
+		if err := checkModuleDir(dir); err != nil {
+			base.Fatalf("go work init: %v", err)
+		}
+		wf.AddUse(usePath(filepath.Dir(path), dir))
+	}
+	out, err := wf.Format()
+	if err != nil {
+		base.Fatalf("go: %v", err)
+	}
+	if err := ioutil.WriteFile(path, out, 0666); err != nil {
+		base.Fatalf("go: %v", err)
+	}
+}
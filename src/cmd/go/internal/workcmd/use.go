@@ -0,0 +1,107 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// go work use
+
+package workcmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"cmd/go/internal/base"
+)
+
+var cmdUse = &base.Command{
+	UsageLine: "go work use [-r] moddirs",
+	Short:     "add modules to workspace file",
+	Long: `
+Use adds the modules rooted at the given directories to the go.work file,
+if they are not listed already. A directory that does not exist or does
+not contain a go.mod file is instead removed from the go.work file, so
+that 'go work use' can also be used to drop a module that was deleted.
+
+The -r flag searches recursively for modules in the argument
+directories, and the use command operates as if each of the directories
+were specified as arguments.
+
+See 'go help work' for more about workspaces.
+	`,
+}
+
+var useR = cmdUse.Flag.Bool("r", false, "")
+
+func init() {
+	cmdUse.Run = runUse // break init cycle
+}
+
+func runUse(cmd *base.Command, args []string) {
+	if len(args) == 0 {
+		base.Fatalf("go work use: no directories specified (see 'go help work use').")
+	}
+	path := mustFindWorkFile()
+	wf, data := readWorkFile(path)
+	workDir := filepath.Dir(path)
+
+	for _, dir := range args {
+		if !*useR {
+			if checkModuleDir(dir) == nil {
+				wf.AddUse(usePath(workDir, dir))
+			} else {
+				wf.DropUse(usePath(workDir, dir))
+			}
+			continue
+		}
+
+		if fi, err := os.Stat(dir); err != nil || !fi.IsDir() {
+			// Drop the directory and any listed modules below it.
+			path := usePath(workDir, dir)
+			for _, u := range wf.Use {
+				if u.Path == path || strings.HasPrefix(u.Path, path+"/") {
+					wf.DropUse(u.Path)
+				}
+			}
+			continue
+		}
+		err := filepath.Walk(dir, func(p string, fi os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if !fi.IsDir() {
+				return nil
+			}
+			if p != dir {
+				if name := fi.Name(); name == "testdata" || name == "vendor" || name[0] == '.' || name[0] == '_' {
+					return filepath.SkipDir
+				}
+			}
+			if checkModuleDir(p) == nil {
+				wf.AddUse(usePath(workDir, p))
+			}
+			return nil
+		})
+		if err != nil {
+			base.Fatalf("go work use: %v", err)
+		}
+	}
+
+	writeWorkFile(path, wf, data)
+}
+
+// checkModuleDir reports an error unless dir is the root of a module.
+func checkModuleDir(dir string) error {
+	fi, err := os.Stat(filepath.Join(dir, "go.mod"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("directory %s does not contain a go.mod file", base.ShortPath(dir))
+		}
+		return err
+	}
+	if fi.IsDir() {
+		return fmt.Errorf("%s is a directory", base.ShortPath(filepath.Join(dir, "go.mod")))
+	}
+	return nil
+}
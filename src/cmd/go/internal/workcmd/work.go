@@ -0,0 +1,129 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package workcmd implements the ``go work'' command.
+package workcmd
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"strings"
+
+	"cmd/go/internal/base"
+	"cmd/go/internal/lockedfile"
+	"cmd/go/internal/modload"
+	"cmd/go/internal/workfile"
+)
+
+var CmdWork = &base.Command{
+	UsageLine: "go work",
+	Short:     "workspace maintenance",
+	Long: `Go work provides access to operations on workspaces.
+
+A workspace is a set of modules on the local file system that are
+developed together. It is described by a go.work file, which lists the
+root directories of the modules with use directives:
+
+	go 1.15
+
+	use (
+		./app
+		./lib
+	)
+
+Directories are relative to the directory containing the go.work file.
+
+The go command runs in workspace mode whenever it finds a go.work file in
+the current directory or one of its parents. The GOWORK environment
+variable may instead name the go.work file to use, as an absolute path,
+or be set to "off" to disable workspace mode.
+
+In workspace mode, all the listed modules are main modules: a package in
+any of them may be named on the command line, by import path or by
+directory, and imports of packages in another workspace module resolve to
+that module's directory, whatever version of it the importing module
+requires. The 'all' pattern matches the packages in all the workspace
+modules and their dependencies. The main module reported by 'go env GOMOD'
+is the workspace module containing the current directory, or the first
+one listed if there is none; 'go list -m' lists all the workspace modules.
+
+The replace and exclude directives of all the workspace modules apply.
+
+The go command never updates the go.mod or go.sum files of the workspace
+modules in workspace mode, as if invoked with -mod=readonly: a
+requirement that one module's code needs must be added to that module's
+go.mod file. The 'go get' and 'go mod' commands edit the go.mod file of a
+single module, and so ignore the go.work file.
+
+'go env GOWORK' prints the path of the go.work file in use, if any.
+	`,
+
+	Commands: []*base.Command{
+		cmdEdit,
+		cmdInit,
+		cmdUse,
+	},
+}
+
+// readWorkFile reads and parses the go.work file at path.
+func readWorkFile(path string) (*workfile.File, []byte) {
+	data, err := lockedfile.Read(path)
+	if err != nil {
+		base.Fatalf("go: %v", err)
+	}
+	wf, err := workfile.Parse(path, data)
+	if err != nil {
+		base.Fatalf("go: errors parsing %s:\n%s", base.ShortPath(path), err)
+	}
+	return wf, data
+}
+
+// writeWorkFile formats wf and writes it to path,
+// provided the file still holds old.
+func writeWorkFile(path string, wf *workfile.File, old []byte) {
+	wf.Cleanup()
+	out, err := wf.Format()
+	if err != nil {
+		base.Fatalf("go: %v", err)
+	}
+	err = lockedfile.Transform(path, func(lockedData []byte) ([]byte, error) {
+		if !bytes.Equal(lockedData, old) {
+			return nil, errors.New("go.work changed during editing; not overwriting")
+		}
+		return out, nil
+	})
+	if err != nil {
+		base.Fatalf("go: %v", err)
+	}
+}
+
+// mustFindWorkFile returns the path of the go.work file to edit,
+// as found by modload.FindGoWork.
+func mustFindWorkFile() string {
+	path := modload.FindGoWork(base.Cwd)
+	if path == "" {
+		base.Fatalf("go: no go.work file found\n\t(run 'go work init' first or specify path using GOWORK environment variable)")
+	}
+	return path
+}
+
+// usePath returns the form of the module directory dir to record in a use
+// directive of the go.work file in workDir: relative to workDir, with
+// forward slashes, if dir is not an absolute path.
+func usePath(workDir, dir string) string {
+	if filepath.IsAbs(dir) {
+		return filepath.Clean(dir)
+	}
+	abs := filepath.Join(base.Cwd, dir)
+	rel, err := filepath.Rel(workDir, abs)
+	if err != nil {
+		return abs
+	}
+	rel = filepath.ToSlash(rel)
+	if rel != "." && rel != ".." && !strings.HasPrefix(rel, "../") {
+		rel = "./" + rel
+	}
+	return rel
+}
@@ -0,0 +1,234 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package workfile parses and edits go.work files.
+//
+// A go.work file has the same syntax as a go.mod file, so the syntax
+// is read and printed by golang.org/x/mod/modfile. This package
+// interprets the directives: a go directive and any number of use
+// directives, each naming the directory of a module.
+package workfile
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+)
+
+// A File is the parsed, interpreted form of a go.work file.
+type File struct {
+	Go  *modfile.Go
+	Use []*Use
+
+	Syntax *modfile.FileSyntax
+}
+
+// A Use is a single use statement, naming the directory of a module.
+type Use struct {
+	Path   string // directory, relative to the go.work file or absolute
+	Syntax *modfile.Line
+}
+
+// Parse parses and returns a go.work file.
+//
+// file is the name of the file, used in positions and errors.
+//
+// data is the content of the file.
+func Parse(file string, data []byte) (*File, error) {
+	// ParseLax checks the go directive and ignores the others,
+	// which are checked below.
+	mf, err := modfile.ParseLax(file, data, nil)
+	if err != nil {
+		return nil, err
+	}
+	f := &File{
+		Go:     mf.Go,
+		Syntax: mf.Syntax,
+	}
+	var errs modfile.ErrorList
+
+	for _, x := range f.Syntax.Stmt {
+		switch x := x.(type) {
+		case *modfile.Line:
+			f.add(&errs, x, x.Token[0], x.Token[1:])
+
+		case *modfile.LineBlock:
+			if len(x.Token) > 1 || x.Token[0] != "use" {
+				errs = append(errs, modfile.Error{
+					Filename: file,
+					Pos:      x.Start,
+					Err:      fmt.Errorf("unknown block type: %s", strings.Join(x.Token, " ")),
+				})
+				continue
+			}
+			for _, l := range x.Line {
+				f.add(&errs, l, x.Token[0], l.Token)
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return f, nil
+}
+
+func (f *File) add(errs *modfile.ErrorList, line *modfile.Line, verb string, args []string) {
+	errorf := func(format string, args ...interface{}) {
+		*errs = append(*errs, modfile.Error{
+			Filename: f.Syntax.Name,
+			Pos:      line.Start,
+			Verb:     verb,
+			Err:      fmt.Errorf(format, args...),
+		})
+	}
+
+	switch verb {
+	default:
+		errorf("unknown directive: %s", verb)
+
+	case "go":
+		// Checked by modfile.ParseLax.
+
+	case "use":
+		if len(args) != 1 {
+			errorf("usage: %s local/dir", verb)
+			return
+		}
+		s, err := parseString(args[0])
+		if err != nil {
+			errorf("invalid quoted string: %v", err)
+			return
+		}
+		f.Use = append(f.Use, &Use{
+			Path:   s,
+			Syntax: line,
+		})
+	}
+}
+
+// parseString returns the string denoted by the token s,
+// which may be quoted.
+func parseString(s string) (string, error) {
+	if strings.HasPrefix(s, `"`) {
+		return strconv.Unquote(s)
+	}
+	if strings.ContainsAny(s, "\"'`") {
+		return "", fmt.Errorf("unquoted string cannot contain quote")
+	}
+	return s, nil
+}
+
+// Format returns the formatted contents of the go.work file.
+func (f *File) Format() ([]byte, error) {
+	return modfile.Format(f.Syntax), nil
+}
+
+// Cleanup cleans up the file f after any edit operations.
+// To avoid quadratic behavior, modifications like DropUse
+// clear the entry but do not remove it from the slice.
+// Cleanup cleans out all the cleared entries.
+func (f *File) Cleanup() {
+	w := 0
+	for _, d := range f.Use {
+		if d.Path != "" {
+			f.Use[w] = d
+			w++
+		}
+	}
+	f.Use = f.Use[:w]
+
+	f.Syntax.Cleanup()
+}
+
+// AddGoStmt sets the go statement of f to version.
+func (f *File) AddGoStmt(version string) error {
+	if !modfile.GoVersionRE.MatchString(version) {
+		return fmt.Errorf("invalid language version string %q", version)
+	}
+	if f.Go == nil {
+		stmt := &modfile.Line{Token: []string{"go", version}}
+		f.Go = &modfile.Go{
+			Version: version,
+			Syntax:  stmt,
+		}
+		// Find the first non-comment-only block and add
+		// the go statement before it. That will keep file comments at the top.
+		i := 0
+		for i = 0; i < len(f.Syntax.Stmt); i++ {
+			if _, ok := f.Syntax.Stmt[i].(*modfile.CommentBlock); !ok {
+				break
+			}
+		}
+		f.Syntax.Stmt = append(append(f.Syntax.Stmt[:i:i], stmt), f.Syntax.Stmt[i:]...)
+	} else {
+		f.Go.Version = version
+		if f.Go.Syntax.InBlock {
+			f.Go.Syntax.Token = []string{version}
+		} else {
+			f.Go.Syntax.Token = []string{"go", version}
+		}
+	}
+	return nil
+}
+
+// AddUse adds a use statement for diskPath to f, unless f already has one.
+func (f *File) AddUse(diskPath string) error {
+	for _, d := range f.Use {
+		if d.Path == diskPath {
+			return nil
+		}
+	}
+	f.Use = append(f.Use, &Use{Path: diskPath, Syntax: f.addUseLine(modfile.AutoQuote(diskPath))})
+	return nil
+}
+
+// addUseLine adds a use line for dir, which may be quoted, to the last
+// use block, turning a single use line into a block if needed. If f has
+// no use lines, it adds one at the end of the file.
+func (f *File) addUseLine(dir string) *modfile.Line {
+	x := f.Syntax
+	for i := len(x.Stmt) - 1; i >= 0; i-- {
+		switch stmt := x.Stmt[i].(type) {
+		case *modfile.Line:
+			if stmt.Token == nil || stmt.Token[0] != "use" {
+				continue
+			}
+			// Convert the line to a block.
+			stmt.InBlock = true
+			block := &modfile.LineBlock{Token: stmt.Token[:1], Line: []*modfile.Line{stmt}}
+			stmt.Token = stmt.Token[1:]
+			x.Stmt[i] = block
+			line := &modfile.Line{Token: []string{dir}, InBlock: true}
+			block.Line = append(block.Line, line)
+			return line
+
+		case *modfile.LineBlock:
+			if stmt.Token[0] != "use" {
+				continue
+			}
+			line := &modfile.Line{Token: []string{dir}, InBlock: true}
+			stmt.Line = append(stmt.Line, line)
+			return line
+		}
+	}
+	line := &modfile.Line{Token: []string{"use", dir}}
+	x.Stmt = append(x.Stmt, line)
+	return line
+}
+
+// DropUse removes the use statement for diskPath from f.
+func (f *File) DropUse(diskPath string) error {
+	for _, d := range f.Use {
+		if d.Path == diskPath {
+			// Like modfile's removeLine: Cleanup removes lines
+			// with no tokens.
+			d.Syntax.Token = nil
+			*d = Use{}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package workfile
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	f, err := Parse("go.work", []byte(`go 1.15
+
+use ./a
+use (
+	b
+	"c d"
+)
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Go == nil || f.Go.Version != "1.15" {
+		t.Errorf("Go = %v, want 1.15", f.Go)
+	}
+	var dirs []string
+	for _, u := range f.Use {
+		dirs = append(dirs, u.Path)
+	}
+	if got, want := strings.Join(dirs, ","), "./a,b,c d"; got != want {
+		t.Errorf("Use = %s, want %s", got, want)
+	}
+}
+
+func TestParseError(t *testing.T) {
+	for _, test := range []struct {
+		data string
+		err  string
+	}{
+		{"go 1.x\n", "invalid go version"},
+		{"go 1.15\ngo 1.16\n", "repeated go statement"},
+		{"module m\n", "unknown directive: module"},
+		{"replace (\n\ta => b\n)\n", "unknown block type: replace"},
+		{"use a b\n", "usage: use local/dir"},
+		{"use a'b\n", "invalid quoted string"},
+	} {
+		_, err := Parse("go.work", []byte(test.data))
+		if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("Parse(%q): error %v, want %q", test.data, err, test.err)
+		}
+	}
+}
+
+func TestEdit(t *testing.T) {
+	f, err := Parse("go.work", []byte("// A comment.\n\nuse ./a\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := f.AddGoStmt("1.15"); err != nil {
+		t.Fatal(err)
+	}
+	f.AddUse("./b")
+	f.AddUse("./a")
+	f.DropUse("./a")
+	f.AddUse("c d")
+	f.Cleanup()
+	out, err := f.Format()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `// A comment.
+
+go 1.15
+
+use (
+	./b
+	"c d"
+)
+`
+	if string(out) != want {
+		t.Errorf("Format() =\n%s\nwant:\n%s", out, want)
+	}
+	if len(f.Use) != 2 {
+		t.Errorf("len(Use) = %d, want 2", len(f.Use))
+	}
+}
@@ -24,7 +24,8 @@ import (
 type Mode int
 
 const (
-	Timestamp Mode = 1 << iota // include Time in events
+	Timestamp    Mode = 1 << iota // include Time in events
+	BenchMetrics                  // emit a metrics event for each benchmark result line
 )
 
 // event is the JSON struct we emit.
@@ -35,6 +36,10 @@ type event struct {
 	Test    string     `json:",omitempty"`
 	Elapsed *float64   `json:",omitempty"`
 	Output  *textBytes `json:",omitempty"`
+
+	// Set for "metrics" events only.
+	Iterations int64              `json:",omitempty"`
+	Metrics    map[string]float64 `json:",omitempty"`
 }
 
 // textBytes is a hack to get JSON to emit a []byte as a string
@@ -58,6 +63,7 @@ type Converter struct {
 	result   string     // overall test result if seen
 	input    lineBuffer // input buffer
 	output   lineBuffer // output buffer
+	bench    []byte     // benchmark result line being received in parts
 }
 
 // inBuffer and outBuffer are the input and output buffer sizes.
@@ -110,7 +116,7 @@ func NewConverter(w io.Writer, pkg string, mode Mode) *Converter {
 		input: lineBuffer{
 			b:    make([]byte, 0, inBuffer),
 			line: c.handleInputLine,
-			part: c.output.write,
+			part: c.handleInputPart,
 		},
 		output: lineBuffer{
 			b:    make([]byte, 0, outBuffer),
@@ -233,6 +239,9 @@ func (c *Converter) handleInputLine(line []byte) {
 			c.testName = c.report[indent-1].Test
 		}
 		c.output.write(origLine)
+		if indent == 0 {
+			c.benchResult(origLine)
+		}
 		return
 	}
 
@@ -294,6 +303,72 @@ func (c *Converter) handleInputLine(line []byte) {
 	return
 }
 
+// handleInputPart handles a part of a test output line that was too
+// long to buffer whole or that the test has not finished writing,
+// like the name of a running benchmark.
+func (c *Converter) handleInputPart(part []byte) {
+	c.output.write(part)
+	if c.mode&BenchMetrics == 0 {
+		return
+	}
+	if len(c.bench) == 0 {
+		i := bytes.IndexByte(part, '\t')
+		if i < 0 || !isBenchmarkName(bytes.TrimRight(part[:i], " ")) {
+			return
+		}
+	}
+	if len(c.bench)+len(part) > maxBenchLine {
+		// Not a result line we can make sense of.
+		// Drop it but keep track of where it ends.
+		c.bench = append(c.bench[:0], '\n')
+	} else {
+		c.bench = append(c.bench, part...)
+	}
+	if bytes.HasSuffix(part, []byte("\n")) {
+		c.benchResult(c.bench)
+		c.bench = c.bench[:0]
+	}
+}
+
+// maxBenchLine is the length of the longest benchmark result line
+// that the converter reports as a metrics event.
+const maxBenchLine = 4096
+
+// benchResult emits a metrics event for line if the converter
+// is in BenchMetrics mode and line is a benchmark result line like
+//
+//	BenchmarkFoo-8   	 1000000	      1234 ns/op	      64 B/op
+//
+// giving the name of the benchmark, the number of iterations
+// and a list of value-unit pairs.
+func (c *Converter) benchResult(line []byte) {
+	if c.mode&BenchMetrics == 0 || !bytes.HasPrefix(line, benchmark) {
+		return
+	}
+	f := strings.Fields(string(line))
+	if len(f) < 4 || len(f)%2 != 0 || !isBenchmarkName([]byte(f[0])) {
+		return
+	}
+	n, err := strconv.ParseInt(f[1], 10, 64)
+	if err != nil || n <= 0 {
+		return
+	}
+	metrics := make(map[string]float64)
+	for i := 2; i < len(f); i += 2 {
+		v, err := strconv.ParseFloat(f[i], 64)
+		if err != nil {
+			return
+		}
+		metrics[f[i+1]] = v
+	}
+	c.writeEvent(&event{
+		Action:     "metrics",
+		Test:       f[0],
+		Iterations: n,
+		Metrics:    metrics,
+	})
+}
+
 // flushReport flushes all pending PASS/FAIL reports at levels >= depth.
 func (c *Converter) flushReport(depth int) {
 	c.testName = ""
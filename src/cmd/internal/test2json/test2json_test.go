@@ -33,10 +33,15 @@ func TestGolden(t *testing.T) {
 				t.Fatal(err)
 			}
 
+			var mode Mode
+			if strings.HasPrefix(name, "benchmetrics") {
+				mode = BenchMetrics
+			}
+
 			// Test one line written to c at a time.
 			// Assume that's the most likely to be handled correctly.
 			var buf bytes.Buffer
-			c := NewConverter(&buf, "", 0)
+			c := NewConverter(&buf, "", mode)
 			in := append([]byte{}, orig...)
 			for _, line := range bytes.SplitAfter(in, []byte("\n")) {
 				writeAndKill(c, line)
@@ -65,7 +70,7 @@ func TestGolden(t *testing.T) {
 			// Write entire input in bulk.
 			t.Run("bulk", func(t *testing.T) {
 				buf.Reset()
-				c = NewConverter(&buf, "", 0)
+				c = NewConverter(&buf, "", mode)
 				in = append([]byte{}, orig...)
 				writeAndKill(c, in)
 				c.Close()
@@ -75,7 +80,7 @@ func TestGolden(t *testing.T) {
 			// Write 2 bytes at a time on even boundaries.
 			t.Run("even2", func(t *testing.T) {
 				buf.Reset()
-				c = NewConverter(&buf, "", 0)
+				c = NewConverter(&buf, "", mode)
 				in = append([]byte{}, orig...)
 				for i := 0; i < len(in); i += 2 {
 					if i+2 <= len(in) {
@@ -91,7 +96,7 @@ func TestGolden(t *testing.T) {
 			// Write 2 bytes at a time on odd boundaries.
 			t.Run("odd2", func(t *testing.T) {
 				buf.Reset()
-				c = NewConverter(&buf, "", 0)
+				c = NewConverter(&buf, "", mode)
 				in = append([]byte{}, orig...)
 				if len(in) > 0 {
 					writeAndKill(c, in[:1])
@@ -120,7 +125,7 @@ func TestGolden(t *testing.T) {
 					inBuffer = 64
 					outBuffer = b
 					buf.Reset()
-					c = NewConverter(&buf, "", 0)
+					c = NewConverter(&buf, "", mode)
 					in = append([]byte{}, orig...)
 					writeAndKill(c, in)
 					c.Close()
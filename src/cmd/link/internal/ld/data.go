@@ -1731,7 +1731,14 @@ func (state *dodataState) allocateDataSections2(ctxt *Link) {
 
 	// Coverage instrumentation counters for libfuzzer.
 	if len(state.data2[sym.SLIBFUZZER_EXTRA_COUNTER]) > 0 {
-		state.allocateNamedSectionAndAssignSyms2(&Segdata, "__libfuzzer_extra_counters", sym.SLIBFUZZER_EXTRA_COUNTER, sym.Sxxx, 06)
+		sect := state.allocateNamedSectionAndAssignSyms2(&Segdata, "__libfuzzer_extra_counters", sym.SLIBFUZZER_EXTRA_COUNTER, sym.Sxxx, 06)
+		// The native fuzzer in internal/fuzz locates the counters
+		// through these symbols; their values are set in address.
+		for _, name := range []string{"internal/fuzz._counters", "internal/fuzz._ecounters"} {
+			if s := ldr.Lookup(name, 0); s != 0 {
+				ldr.SetSymSect(s, sect)
+			}
+		}
 	}
 
 	if len(state.data2[sym.STLSBSS]) > 0 {
@@ -2349,6 +2356,7 @@ func (ctxt *Link) address() []*sym.Segment {
 	var noptr *sym.Section
 	var bss *sym.Section
 	var noptrbss *sym.Section
+	var fuzzCounters *sym.Section
 	for i, s := range Segdata.Sections {
 		if (ctxt.IsELF || ctxt.HeadType == objabi.Haix) && s.Name == ".tbss" {
 			continue
@@ -2372,6 +2380,9 @@ func (ctxt *Link) address() []*sym.Segment {
 		if s.Name == ".noptrbss" {
 			noptrbss = s
 		}
+		if s.Name == "__libfuzzer_extra_counters" {
+			fuzzCounters = s
+		}
 	}
 
 	// Assign Segdata's Filelen omitting the BSS. We do this here
@@ -2495,6 +2506,17 @@ func (ctxt *Link) address() []*sym.Segment {
 	ctxt.xdefine2("runtime.enoptrbss", sym.SNOPTRBSS, int64(noptrbss.Vaddr+noptrbss.Length))
 	ctxt.xdefine2("runtime.end", sym.SBSS, int64(Segdata.Vaddr+Segdata.Length))
 
+	if s := ldr.Lookup("internal/fuzz._counters", 0); s != 0 && ldr.Lookup("internal/fuzz._ecounters", 0) != 0 {
+		if fuzzCounters != nil {
+			ctxt.xdefine2("internal/fuzz._counters", sym.SLIBFUZZER_EXTRA_COUNTER, int64(fuzzCounters.Vaddr))
+			ctxt.xdefine2("internal/fuzz._ecounters", sym.SLIBFUZZER_EXTRA_COUNTER, int64(fuzzCounters.Vaddr+fuzzCounters.Length))
+		} else {
+			// Without instrumentation there are no counters. Place the
+			// end marker at the start so the fuzzer sees an empty range.
+			ctxt.xdefine2("internal/fuzz._ecounters", ldr.SymType(s), ldr.SymValue(s))
+		}
+	}
+
 	if ctxt.IsSolaris() {
 		// On Solaris, in the runtime it sets the external names of the
 		// end symbols. Unset them and define separate symbols, so we
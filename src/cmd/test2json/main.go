@@ -6,7 +6,7 @@
 //
 // Usage:
 //
-//	go tool test2json [-p pkg] [-t] [./pkg.test -test.v]
+//	go tool test2json [-p pkg] [-t] [-m] [./pkg.test -test.v]
 //
 // Test2json runs the given test command and converts its output to JSON;
 // with no command specified, test2json expects test output on standard input.
@@ -18,6 +18,9 @@
 //
 // The -t flag requests that time stamps be added to each test event.
 //
+// The -m flag requests that a "metrics" event be added for each
+// benchmark result line, as described below.
+//
 // Note that test2json is only intended for converting a single test
 // binary's output. To convert the output of a "go test" command,
 // use "go test -json" instead of invoking test2json directly.
@@ -34,6 +37,10 @@
 //		Test    string
 //		Elapsed float64 // seconds
 //		Output  string
+//
+//		// Set for "metrics" events only.
+//		Iterations int64
+//		Metrics    map[string]float64
 //	}
 //
 // The Time field holds the time the event happened.
@@ -41,14 +48,15 @@
 //
 // The Action field is one of a fixed set of action descriptions:
 //
-//	run    - the test has started running
-//	pause  - the test has been paused
-//	cont   - the test has continued running
-//	pass   - the test passed
-//	bench  - the benchmark printed log output but did not fail
-//	fail   - the test or benchmark failed
-//	output - the test printed output
-//	skip   - the test was skipped or the package contained no tests
+//	run     - the test has started running
+//	pause   - the test has been paused
+//	cont    - the test has continued running
+//	pass    - the test passed
+//	bench   - the benchmark printed log output but did not fail
+//	fail    - the test or benchmark failed
+//	output  - the test printed output
+//	metrics - the benchmark reported the results of one run (only with -m)
+//	skip    - the test was skipped or the package contained no tests
 //
 // The Package field, if present, specifies the package being tested.
 // When the go command runs parallel tests in -json mode, events from
@@ -77,6 +85,17 @@
 // by a final event with Action == "bench" or "fail".
 // Benchmarks have no events with Action == "run", "pause", or "cont".
 //
+// With the -m flag, or 'go test -json -benchmetrics', each benchmark
+// result line is also decoded into an event with Action == "metrics",
+// which follows the output event for the line. Such an event has Test
+// set to the benchmark name as printed, including any -N suffix giving
+// the value of GOMAXPROCS, Iterations set to the number of times
+// the benchmark loop ran, and Metrics mapping each unit on the line
+// (such as "ns/op", "B/op", or a unit passed to b.ReportMetric)
+// to its value. A benchmark run with -count=N reports N such events.
+// The "go tool benchdiff" command compares two sets of results
+// in this form.
+//
 package main
 
 import (
@@ -92,10 +111,11 @@ import (
 var (
 	flagP = flag.String("p", "", "report `pkg` as the package being tested in each event")
 	flagT = flag.Bool("t", false, "include timestamps in events")
+	flagM = flag.Bool("m", false, "include metrics events for benchmark results")
 )
 
 func usage() {
-	fmt.Fprintf(os.Stderr, "usage: go tool test2json [-p pkg] [-t] [./pkg.test -test.v]\n")
+	fmt.Fprintf(os.Stderr, "usage: go tool test2json [-p pkg] [-t] [-m] [./pkg.test -test.v]\n")
 	os.Exit(2)
 }
 
@@ -107,6 +127,9 @@ func main() {
 	if *flagT {
 		mode |= test2json.Timestamp
 	}
+	if *flagM {
+		mode |= test2json.BenchMetrics
+	}
 	c := test2json.NewConverter(os.Stdout, *flagP, mode)
 	defer c.Close()
 
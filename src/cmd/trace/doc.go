@@ -7,6 +7,7 @@ Trace is a tool for viewing trace files.
 
 Trace files can be generated with:
 	- runtime/trace.Start
+	- runtime/trace.FlightRecorder
 	- net/http/pprof package
 	- go test -trace
 
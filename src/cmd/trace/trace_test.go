@@ -7,6 +7,7 @@
 package main
 
 import (
+	"bytes"
 	"context"
 	"internal/trace"
 	"io/ioutil"
@@ -266,3 +267,62 @@ func TestDirectSemaphoreHandoff(t *testing.T) {
 		t.Fatalf("failed to parse the trace: %v", err)
 	}
 }
+
+// TestFlightRecorderTrace tests that a flight recorder snapshot made of
+// several trace partitions produces a consistent view of the goroutines.
+func TestFlightRecorderTrace(t *testing.T) {
+	if rtrace.IsEnabled() {
+		t.Skip("skipping because -test.trace is set")
+	}
+	var wg sync.WaitGroup
+	done := make(chan bool)
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			<-done
+			wg.Done()
+		}()
+	}
+
+	fr := rtrace.NewFlightRecorder(rtrace.FlightRecorderConfig{MinAge: time.Hour})
+	if err := fr.Start(); err != nil {
+		t.Fatalf("failed to start flight recorder: %v", err)
+	}
+	defer fr.Stop()
+	for i := 0; i < 3; i++ {
+		c := make(chan bool)
+		go func() { c <- true }()
+		<-c
+		// End a partition.
+		if _, err := fr.WriteTo(ioutil.Discard); err != nil {
+			t.Fatalf("WriteTo failed: %v", err)
+		}
+	}
+	close(done)
+	wg.Wait()
+
+	var buf bytes.Buffer
+	if _, err := fr.WriteTo(&buf); err != nil {
+		t.Fatalf("WriteTo failed: %v", err)
+	}
+	res, err := trace.Parse(&buf, "")
+	if err == trace.ErrTimeOrder {
+		t.Skipf("skipping due to golang.org/issue/16755: %v", err)
+	} else if err != nil {
+		t.Fatalf("failed to parse trace: %v", err)
+	}
+
+	params := &traceParams{
+		parsed:  res,
+		endTime: int64(1<<63 - 1),
+	}
+	// If the goroutine counts drop below 0, generateTrace will return an error.
+	c := viewerDataTraceConsumer(ioutil.Discard, 0, 1<<63-1)
+	if err := generateTrace(params, c); err != nil {
+		t.Fatalf("generateTrace failed: %v", err)
+	}
+	gs := trace.GoroutineStats(res.Events)
+	if len(gs) == 0 {
+		t.Errorf("no goroutines in the trace")
+	}
+}
@@ -36,6 +36,7 @@ To list the available checks, run "go tool vet help":
     cgocall      detect some violations of the cgo pointer passing rules
     composites   check for unkeyed composite literals
     copylocks    check for locks erroneously passed by value
+    deferclose   check for unchecked errors from deferred Close of files opened for writing (optional)
     httpresponse check for mistakes using HTTP responses
     loopclosure  check references to loop variables from within nested functions
     lostcancel   check cancel func returned by context.WithCancel is called
@@ -44,26 +45,61 @@ To list the available checks, run "go tool vet help":
     shift        check for shifts that equal or exceed the width of the integer
     stdmethods   check signature of methods of well-known interfaces
     structtag    check that struct field tags conform to reflect.StructTag.Get
+    testinggoroutine
+                 report calls to (*testing.T).Fatal from goroutines started by a test
     tests        check for common mistaken usages of tests and examples
+    tickerstop   check that tickers created by time.NewTicker are stopped
     unmarshal    report passing non-pointer or non-interface values to unmarshal
     unreachable  check for unreachable code
     unsafeptr    check for invalid conversions of uintptr to unsafe.Pointer
     unusedresult check for unused results of calls to some functions
+    waitgroup    check for misuses of sync.WaitGroup
 
 For details and flags of a particular check, such as printf, run "go tool vet help printf".
 
-By default, all checks are performed.
+By default, all checks except the optional ones are performed.
+An optional check, marked (optional) above, runs only when its flag is
+explicitly set to true, as in -deferclose.
 If any flags are explicitly set to true, only those tests are run.
 Conversely, if any flag is explicitly set to false, only those tests are disabled.
 Thus -printf=true runs the printf check,
 and -printf=false runs all checks except the printf check.
 
+The go.vet file in the root directory of a module can enable or disable
+checks and set their flags for the packages in that module. Each line of
+the file holds one directive, and // starts a comment:
+
+	// Run the optional checks in addition to the default ones.
+	enable name...
+	// Do not run these checks.
+	disable name...
+	// Set a check's flag, as -name.flag=value would.
+	flag printf.funcs=Wrapf,Logf
+
+The enable and disable directives are ignored when the command line
+selects checks with -NAME flags, and the command line takes precedence
+over flag directives for the same flag.
+
+A Go source file can suppress the reports of some checks with a comment
+before its package clause:
+
+	//vet:ignore printf composites
+
+After the package clause, the same comment suppresses the reports on
+its own line if it follows code there, and otherwise on the next line.
+
+Some checks suggest fixes for the problems they report, such as removing
+a self-assignment. The -fix flag applies those fixes to the source files
+and reports only the problems that remain.
+
 For information on writing a new check, see golang.org/x/tools/go/analysis.
 
 Core flags:
 
   -c=N
     	display offending line plus N lines of surrounding context
+  -fix
+    	apply all suggested fixes
   -json
     	emit analysis diagnostics (and errors) in JSON format
 
@@ -4,6 +4,9 @@
 
 // Package analysisflags defines helpers for processing flags of
 // analysis driver tools.
+//
+// It is a copy of golang.org/x/tools/go/analysis/internal/analysisflags
+// with support for the -fix flag and for optional analyzers.
 package analysisflags
 
 import (
@@ -27,8 +30,14 @@ import (
 var (
 	JSON    = false // -json
 	Context = -1    // -c=N: if N>0, display offending line plus N lines of context
+	Fix     = false // -fix: apply suggested fixes
 )
 
+// Optional holds the analyzers that are run only when enabled
+// explicitly, by a -NAME flag or, in unitchecker, by the build
+// system's configuration. Drivers set it before calling Parse.
+var Optional = make(map[*analysis.Analyzer]bool)
+
 // Parse creates a flag for each of the analyzer's flags,
 // including (in multi mode) a flag named after the analyzer,
 // parses the flags, then filters and returns the list of
@@ -75,6 +84,7 @@ func Parse(analyzers []*analysis.Analyzer, multi bool) []*analysis.Analyzer {
 	// flags common to all checkers
 	flag.BoolVar(&JSON, "json", JSON, "emit JSON output")
 	flag.IntVar(&Context, "c", Context, `display offending line with this many lines of context`)
+	flag.BoolVar(&Fix, "fix", Fix, "apply all suggested fixes")
 
 	// Add shims for legacy vet flags to enable existing
 	// scripts that run vet to continue to work.
@@ -101,14 +111,12 @@ func Parse(analyzers []*analysis.Analyzer, multi bool) []*analysis.Analyzer {
 
 	// If any -NAME flag is true,  run only those analyzers. Otherwise,
 	// if any -NAME flag is false, run all but those analyzers.
+	// Optional analyzers run only if their -NAME flag is true.
 	if multi {
-		var hasTrue, hasFalse bool
+		var hasTrue bool
 		for _, ts := range enabled {
-			switch *ts {
-			case setTrue:
+			if *ts == setTrue {
 				hasTrue = true
-			case setFalse:
-				hasFalse = true
 			}
 		}
 
@@ -120,9 +128,9 @@ func Parse(analyzers []*analysis.Analyzer, multi bool) []*analysis.Analyzer {
 				}
 			}
 			analyzers = keep
-		} else if hasFalse {
+		} else {
 			for _, a := range analyzers {
-				if *enabled[a] != setFalse {
+				if *enabled[a] != setFalse && !Optional[a] {
 					keep = append(keep, a)
 				}
 			}
@@ -168,10 +176,10 @@ func printFlags() {
 	var flags []jsonFlag = nil
 	flag.VisitAll(func(f *flag.Flag) {
 		// Don't report {single,multi}checker debugging
-		// flags or fix as these have no effect on unitchecker
+		// flags as these have no effect on unitchecker
 		// (as invoked by 'go vet').
 		switch f.Name {
-		case "debug", "cpuprofile", "memprofile", "trace", "fix":
+		case "debug", "cpuprofile", "memprofile", "trace":
 			return
 		}
 
@@ -31,11 +31,20 @@ func Help(progname string, analyzers []*analysis.Analyzer, args []string) {
 		sort.Slice(analyzers, func(i, j int) bool {
 			return analyzers[i].Name < analyzers[j].Name
 		})
+		hasOptional := false
 		for _, a := range analyzers {
 			title := strings.Split(a.Doc, "\n\n")[0]
+			if Optional[a] {
+				title += " (optional)"
+				hasOptional = true
+			}
 			fmt.Printf("    %-12s %s\n", a.Name, title)
 		}
-		fmt.Println("\nBy default all analyzers are run.")
+		if hasOptional {
+			fmt.Println("\nBy default all analyzers except the optional ones are run.")
+		} else {
+			fmt.Println("\nBy default all analyzers are run.")
+		}
 		fmt.Println("To select specific analyzers, use the -NAME flag for each one,")
 		fmt.Println(" or -NAME=false to run all analyzers not explicitly disabled.")
 
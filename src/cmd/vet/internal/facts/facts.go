@@ -34,6 +34,9 @@
 // defined as interface{f()}. Exported thus means "described in export
 // data".
 //
+// This is a copy of golang.org/x/tools/go/analysis/internal/facts,
+// which cmd/vet/internal/unitchecker cannot import.
+//
 package facts
 
 import (
@@ -0,0 +1,134 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package deferclose defines an Analyzer that reports deferred calls
+// to Close that ignore the error of closing a file opened for writing.
+package deferclose
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/types"
+
+	"cmd/vet/internal/passes/internal/analysisutil"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+const Doc = `check for unchecked errors from deferred Close of files opened for writing
+
+Data written to an *os.File may not reach the disk until the file is
+closed, so the error returned by Close is the last chance to learn that
+a write failed. A deferred call discards that error:
+
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close() // error: the error from f.Close is not checked
+	_, err = f.Write(data)
+	return err
+
+This checker reports such deferred calls for files returned by
+os.Create, and by os.OpenFile with a constant flag that opens the
+file for writing. Files opened only for reading are not reported.`
+
+var Analyzer = &analysis.Analyzer{
+	Name:     "deferclose",
+	Doc:      Doc,
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Run:      run,
+}
+
+// Flags of os.OpenFile. O_RDONLY, O_WRONLY and O_RDWR have these
+// values on every system supported by the os package.
+const (
+	oWRONLY = 0x1
+	oRDWR   = 0x2
+)
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	inspect := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+
+	if !analysisutil.Imports(pass.Pkg, "os") {
+		return nil, nil
+	}
+
+	// Find the variables holding files opened for writing.
+	writable := make(map[types.Object]bool)
+	nodeFilter := []ast.Node{
+		(*ast.AssignStmt)(nil),
+		(*ast.ValueSpec)(nil),
+		(*ast.DeferStmt)(nil),
+	}
+	inspect.Preorder(nodeFilter, func(n ast.Node) {
+		var lhs []*ast.Ident
+		var rhs []ast.Expr
+		switch n := n.(type) {
+		case *ast.AssignStmt:
+			for _, x := range n.Lhs {
+				id, _ := x.(*ast.Ident)
+				lhs = append(lhs, id)
+			}
+			rhs = n.Rhs
+		case *ast.ValueSpec:
+			lhs, rhs = n.Names, n.Values
+		case *ast.DeferStmt:
+			checkDefer(pass, n, writable)
+			return
+		}
+		if len(rhs) != 1 || len(lhs) == 0 || lhs[0] == nil {
+			return
+		}
+		call, ok := rhs[0].(*ast.CallExpr)
+		if !ok || !opensForWriting(pass.TypesInfo, call) {
+			return
+		}
+		if obj := pass.TypesInfo.ObjectOf(lhs[0]); obj != nil {
+			writable[obj] = true
+		}
+	})
+	return nil, nil
+}
+
+// checkDefer reports d if it defers a call to the Close method
+// of a file opened for writing.
+func checkDefer(pass *analysis.Pass, d *ast.DeferStmt, writable map[types.Object]bool) {
+	sel, ok := d.Call.Fun.(*ast.SelectorExpr)
+	if !ok || sel.Sel.Name != "Close" {
+		return
+	}
+	id, ok := sel.X.(*ast.Ident)
+	if !ok || !writable[pass.TypesInfo.Uses[id]] {
+		return
+	}
+	pass.ReportRangef(d, "the error from %s.Close is not checked, but %s was opened for writing", id.Name, id.Name)
+}
+
+// opensForWriting reports whether call is a call to os.Create, or to
+// os.OpenFile with a constant flag that includes O_WRONLY or O_RDWR.
+func opensForWriting(info *types.Info, call *ast.CallExpr) bool {
+	fn, ok := typeutil.Callee(info, call).(*types.Func)
+	if !ok || fn.Pkg() == nil || fn.Pkg().Path() != "os" {
+		return false
+	}
+	switch fn.Name() {
+	case "Create":
+		return true
+	case "OpenFile":
+		if len(call.Args) != 3 {
+			return false
+		}
+		flag := info.Types[call.Args[1]].Value
+		if flag == nil || flag.Kind() != constant.Int {
+			return false
+		}
+		v, ok := constant.Int64Val(flag)
+		return ok && v&(oWRONLY|oRDWR) != 0
+	}
+	return false
+}
@@ -4,6 +4,10 @@
 
 // Package lostcancel defines an Analyzer that checks for failure to
 // call a context cancellation function.
+//
+// It is a copy of golang.org/x/tools/go/analysis/passes/lostcancel
+// that also checks the Cause variants of the context functions,
+// such as WithCancelCause.
 package lostcancel
 
 import (
@@ -21,8 +25,9 @@ import (
 const Doc = `check cancel func returned by context.WithCancel is called
 
 The cancellation function returned by context.WithCancel, WithTimeout,
-and WithDeadline must be called or the new context will remain live
-until its parent context is cancelled.
+WithDeadline, or one of their Cause variants, such as WithCancelCause,
+must be called or the new context will remain live until its parent
+context is cancelled.
 (The background context is never cancelled.)`
 
 var Analyzer = &analysis.Analyzer{
@@ -192,14 +197,16 @@ func hasImport(pkg *types.Package, path string) bool {
 }
 
 // isContextWithCancel reports whether n is one of the qualified identifiers
-// context.With{Cancel,Timeout,Deadline}.
+// context.With{Cancel,Timeout,Deadline} or one of their Cause variants.
 func isContextWithCancel(info *types.Info, n ast.Node) bool {
 	sel, ok := n.(*ast.SelectorExpr)
 	if !ok {
 		return false
 	}
 	switch sel.Sel.Name {
-	case "WithCancel", "WithTimeout", "WithDeadline":
+	case "WithCancel", "WithCancelCause",
+		"WithTimeout", "WithTimeoutCause",
+		"WithDeadline", "WithDeadlineCause":
 	default:
 		return false
 	}
@@ -4,6 +4,10 @@
 
 // Package printf defines an Analyzer that checks consistency
 // of Printf format strings and arguments.
+//
+// It is a copy of golang.org/x/tools/go/analysis/passes/printf
+// that accepts more than one %w verb in a call to fmt.Errorf,
+// as fmt.Errorf does.
 package printf
 
 import (
@@ -20,9 +24,10 @@ import (
 	"strings"
 	"unicode/utf8"
 
+	"cmd/vet/internal/passes/internal/analysisutil"
+
 	"golang.org/x/tools/go/analysis"
 	"golang.org/x/tools/go/analysis/passes/inspect"
-	"golang.org/x/tools/go/analysis/passes/internal/analysisutil"
 	"golang.org/x/tools/go/ast/inspector"
 	"golang.org/x/tools/go/types/typeutil"
 )
@@ -571,7 +576,6 @@ func checkPrintf(pass *analysis.Pass, kind Kind, call *ast.CallExpr, fn *types.F
 	argNum := firstArg
 	maxArgNum := firstArg
 	anyIndex := false
-	anyW := false
 	for i, w := 0, 0; i < len(format); i += w {
 		w = 1
 		if format[i] != '%' {
@@ -593,11 +597,6 @@ func checkPrintf(pass *analysis.Pass, kind Kind, call *ast.CallExpr, fn *types.F
 				pass.Reportf(call.Pos(), "%s call has error-wrapping directive %%w", state.name)
 				return
 			}
-			if anyW {
-				pass.Reportf(call.Pos(), "%s call has more than one error-wrapping directive %%w", state.name)
-				return
-			}
-			anyW = true
 		}
 		if len(state.argNums) > 0 {
 			// Continue with the next sequential argument.
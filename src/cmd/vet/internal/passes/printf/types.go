@@ -4,8 +4,9 @@ import (
 	"go/ast"
 	"go/types"
 
+	"cmd/vet/internal/passes/internal/analysisutil"
+
 	"golang.org/x/tools/go/analysis"
-	"golang.org/x/tools/go/analysis/passes/internal/analysisutil"
 )
 
 var errorType = types.Universe.Lookup("error").Type().Underlying().(*types.Interface)
@@ -0,0 +1,136 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package tests extends the tests analyzer of golang.org/x/tools for
+// cmd/vet. Besides the checks of the vendored analyzer, it reports
+// calls that panic in parallel tests.
+package tests
+
+import (
+	"go/ast"
+	"go/types"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/tests"
+)
+
+const Doc = `check for common mistaken usages of tests and examples
+
+The tests checker walks Test, Benchmark and Example functions checking
+malformed names, wrong signatures and examples documenting non-existent
+identifiers. It also reports calls to T.Setenv and T.Chdir in tests
+that call T.Parallel, or whose ancestor tests do, which panic at run
+time.
+
+Please see the documentation for package testing in golang.org/pkg/testing
+for the conventions that are enforced for Tests, Benchmarks, and Examples.`
+
+var Analyzer = &analysis.Analyzer{
+	Name: "tests",
+	Doc:  Doc,
+	Run:  run,
+}
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	if _, err := tests.Analyzer.Run(pass); err != nil {
+		return nil, err
+	}
+	for _, f := range pass.Files {
+		if !strings.HasSuffix(pass.Fset.File(f.Pos()).Name(), "_test.go") {
+			continue
+		}
+		for _, decl := range f.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Recv != nil || fn.Body == nil {
+				continue
+			}
+			if strings.HasPrefix(fn.Name.Name, "Test") {
+				checkParallelState(pass, fn.Body)
+			}
+		}
+	}
+	return nil, nil
+}
+
+// checkParallelState reports calls to t.Setenv and t.Chdir in tests
+// that call t.Parallel, or that are subtests of tests that do. Such
+// calls change the state of the whole process, and the testing package
+// panics when they are made in a parallel test.
+func checkParallelState(pass *analysis.Pass, body *ast.BlockStmt) {
+	var (
+		parallel = make(map[types.Object]bool)         // t.Parallel is called
+		parent   = make(map[types.Object]types.Object) // t.Run(name, func(t2 *testing.T) ...) makes t the parent of t2
+		calls    []*ast.CallExpr                       // t.Setenv and t.Chdir calls
+	)
+	ast.Inspect(body, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+		t, method := testingTMethod(pass, call)
+		if t == nil {
+			return true
+		}
+		switch method {
+		case "Parallel":
+			parallel[t] = true
+		case "Setenv", "Chdir":
+			calls = append(calls, call)
+		case "Run":
+			if len(call.Args) != 2 {
+				break
+			}
+			lit, ok := call.Args[1].(*ast.FuncLit)
+			if !ok || len(lit.Type.Params.List) != 1 || len(lit.Type.Params.List[0].Names) != 1 {
+				break
+			}
+			if sub := pass.TypesInfo.Defs[lit.Type.Params.List[0].Names[0]]; sub != nil {
+				parent[sub] = t
+			}
+		}
+		return true
+	})
+
+	for _, call := range calls {
+		t, method := testingTMethod(pass, call)
+		for p := t; p != nil; p = parent[p] {
+			if parallel[p] {
+				what := "a parallel test"
+				if p != t {
+					what = "a subtest of a parallel test"
+				}
+				pass.Reportf(call.Pos(), "%s.%s called in %s; it panics because it changes the state of the whole process", t.Name(), method, what)
+				break
+			}
+		}
+	}
+}
+
+// testingTMethod reports whether call is a method call t.M(...) where
+// t is a variable of type *testing.T. If so, it returns the variable
+// and the method name; otherwise it returns nil.
+func testingTMethod(pass *analysis.Pass, call *ast.CallExpr) (types.Object, string) {
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return nil, ""
+	}
+	id, ok := sel.X.(*ast.Ident)
+	if !ok {
+		return nil, ""
+	}
+	obj, ok := pass.TypesInfo.Uses[id].(*types.Var)
+	if !ok {
+		return nil, ""
+	}
+	ptr, ok := obj.Type().(*types.Pointer)
+	if !ok {
+		return nil, ""
+	}
+	named, ok := ptr.Elem().(*types.Named)
+	if !ok || named.Obj().Name() != "T" || named.Obj().Pkg() == nil || named.Obj().Pkg().Path() != "testing" {
+		return nil, ""
+	}
+	return obj, sel.Sel.Name
+}
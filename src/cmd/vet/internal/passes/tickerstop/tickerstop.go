@@ -0,0 +1,148 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package tickerstop defines an Analyzer that checks that tickers
+// created by time.NewTicker are stopped.
+package tickerstop
+
+import (
+	"go/ast"
+	"go/types"
+
+	"cmd/vet/internal/passes/internal/analysisutil"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+const Doc = `check that tickers created by time.NewTicker are stopped
+
+A time.Ticker keeps running, and cannot be garbage collected, until
+its Stop method is called. This checker reports tickers stored in a
+local variable that is only ever used to receive from the ticker's
+channel or to reset it, and tickers whose channel is used directly:
+
+	t := time.NewTicker(d) // error: t is never stopped
+	for range t.C {
+		...
+	}
+
+Any other use of the variable, such as passing it to a function or
+returning it, is assumed to take care of stopping the ticker.`
+
+var Analyzer = &analysis.Analyzer{
+	Name:     "tickerstop",
+	Doc:      Doc,
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Run:      run,
+}
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	inspect := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+
+	if !analysisutil.Imports(pass.Pkg, "time") {
+		return nil, nil
+	}
+
+	// Find the calls to time.NewTicker and the local variables
+	// that hold their results.
+	tickers := make(map[types.Object]*ast.CallExpr)
+	var order []types.Object
+	nodeFilter := []ast.Node{
+		(*ast.CallExpr)(nil),
+	}
+	inspect.WithStack(nodeFilter, func(n ast.Node, push bool, stack []ast.Node) bool {
+		if !push {
+			return true
+		}
+		call := n.(*ast.CallExpr)
+		if !isNewTicker(pass.TypesInfo, call) {
+			return true
+		}
+		switch parent := stack[len(stack)-2].(type) {
+		case *ast.SelectorExpr:
+			// time.NewTicker(d).C
+			if parent.Sel.Name == "C" {
+				pass.ReportRangef(call, "the ticker returned by time.NewTicker is never stopped (possible resource leak)")
+			}
+		case *ast.AssignStmt:
+			if len(parent.Lhs) == 1 && len(parent.Rhs) == 1 {
+				if id, ok := parent.Lhs[0].(*ast.Ident); ok {
+					if obj := pass.TypesInfo.ObjectOf(id); isLocal(obj) && tickers[obj] == nil {
+						tickers[obj] = call
+						order = append(order, obj)
+					}
+				}
+			}
+		case *ast.ValueSpec:
+			if len(parent.Names) == 1 && len(parent.Values) == 1 {
+				if obj := pass.TypesInfo.ObjectOf(parent.Names[0]); isLocal(obj) {
+					tickers[obj] = call
+					order = append(order, obj)
+				}
+			}
+		}
+		return true
+	})
+	if len(tickers) == 0 {
+		return nil, nil
+	}
+
+	// A ticker is stopped, or handed to other code that may stop it,
+	// if its variable is used other than as t.C or t.Reset.
+	stopped := make(map[types.Object]bool)
+	nodeFilter = []ast.Node{
+		(*ast.Ident)(nil),
+	}
+	inspect.WithStack(nodeFilter, func(n ast.Node, push bool, stack []ast.Node) bool {
+		if !push {
+			return true
+		}
+		obj := pass.TypesInfo.Uses[n.(*ast.Ident)]
+		if tickers[obj] == nil {
+			return true
+		}
+		if sel, ok := stack[len(stack)-2].(*ast.SelectorExpr); ok && sel.X == n {
+			if sel.Sel.Name == "C" || sel.Sel.Name == "Reset" {
+				return true
+			}
+		}
+		if asg, ok := stack[len(stack)-2].(*ast.AssignStmt); ok && isLHS(asg, n) {
+			return true // reassignment
+		}
+		stopped[obj] = true
+		return true
+	})
+
+	for _, obj := range order {
+		if !stopped[obj] {
+			pass.ReportRangef(tickers[obj], "the ticker %s is never stopped (possible resource leak)", obj.Name())
+		}
+	}
+	return nil, nil
+}
+
+// isNewTicker reports whether call is a call to time.NewTicker.
+func isNewTicker(info *types.Info, call *ast.CallExpr) bool {
+	fn, ok := typeutil.Callee(info, call).(*types.Func)
+	return ok && fn.Pkg() != nil && fn.Pkg().Path() == "time" && fn.Name() == "NewTicker"
+}
+
+// isLocal reports whether obj is a variable declared in a function.
+func isLocal(obj types.Object) bool {
+	v, ok := obj.(*types.Var)
+	return ok && !v.IsField() && v.Parent() != nil && v.Parent() != v.Pkg().Scope()
+}
+
+// isLHS reports whether n is one of the operands assigned to by asg.
+func isLHS(asg *ast.AssignStmt, n ast.Node) bool {
+	for _, lhs := range asg.Lhs {
+		if lhs == n {
+			return true
+		}
+	}
+	return false
+}
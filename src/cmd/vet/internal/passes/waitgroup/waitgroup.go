@@ -0,0 +1,134 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package waitgroup defines an Analyzer that detects calls to
+// sync.WaitGroup.Add made by the goroutine being waited for.
+package waitgroup
+
+import (
+	"go/ast"
+	"go/types"
+
+	"cmd/vet/internal/passes/internal/analysisutil"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+const Doc = `check for misuses of sync.WaitGroup
+
+This analyzer detects mistaken calls to the (*sync.WaitGroup).Add
+method from inside the new goroutine, causing Add to race with Wait:
+
+	// WRONG
+	var wg sync.WaitGroup
+	go func() {
+		wg.Add(1) // "WaitGroup.Add called from inside new goroutine"
+		defer wg.Done()
+		...
+	}()
+	wg.Wait() // (may return prematurely before new goroutine starts)
+
+The correct code calls Add before starting the goroutine:
+
+	// RIGHT
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		...
+	}()
+	wg.Wait()`
+
+var Analyzer = &analysis.Analyzer{
+	Name:     "waitgroup",
+	Doc:      Doc,
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Run:      run,
+}
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	inspect := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+
+	if !analysisutil.Imports(pass.Pkg, "sync") {
+		return nil, nil
+	}
+
+	nodeFilter := []ast.Node{
+		(*ast.GoStmt)(nil),
+	}
+	inspect.Preorder(nodeFilter, func(n ast.Node) {
+		lit, ok := n.(*ast.GoStmt).Call.Fun.(*ast.FuncLit)
+		if !ok {
+			return
+		}
+
+		// Find the WaitGroups on which the goroutine calls Add and
+		// Done, directly or in a deferred call. A goroutine that
+		// calls Add for a WaitGroup it later marks Done is waited for
+		// using a counter it may increment too late. One that calls
+		// Done first was already counted, so it may safely call Add
+		// on behalf of other goroutines.
+		var adds []*ast.CallExpr
+		done := make(map[string]bool)
+		for _, stmt := range lit.Body.List {
+			var call *ast.CallExpr
+			switch stmt := stmt.(type) {
+			case *ast.ExprStmt:
+				call, _ = stmt.X.(*ast.CallExpr)
+			case *ast.DeferStmt:
+				call = stmt.Call
+			}
+			if call == nil {
+				continue
+			}
+			method := waitGroupMethod(pass.TypesInfo, call)
+			if method == "" {
+				continue
+			}
+			wg := analysisutil.Format(pass.Fset, call.Fun.(*ast.SelectorExpr).X)
+			switch method {
+			case "Add":
+				if _, ok := stmt.(*ast.ExprStmt); ok && !done[wg] {
+					adds = append(adds, call)
+				}
+			case "Done":
+				done[wg] = true
+			}
+		}
+		for _, call := range adds {
+			if done[analysisutil.Format(pass.Fset, call.Fun.(*ast.SelectorExpr).X)] {
+				pass.ReportRangef(call, "WaitGroup.Add called from inside new goroutine")
+			}
+		}
+	})
+	return nil, nil
+}
+
+// waitGroupMethod returns the name of the method of sync.WaitGroup
+// that call calls, or "" if it does not call one.
+func waitGroupMethod(info *types.Info, call *ast.CallExpr) string {
+	fn, ok := typeutil.Callee(info, call).(*types.Func)
+	if !ok || fn.Pkg() == nil || fn.Pkg().Path() != "sync" {
+		return ""
+	}
+	recv := fn.Type().(*types.Signature).Recv()
+	if recv == nil {
+		return ""
+	}
+	ptr, ok := recv.Type().(*types.Pointer)
+	if !ok {
+		return ""
+	}
+	named, ok := ptr.Elem().(*types.Named)
+	if !ok || named.Obj().Name() != "WaitGroup" {
+		return ""
+	}
+	if _, ok := call.Fun.(*ast.SelectorExpr); !ok {
+		return ""
+	}
+	return fn.Name()
+}
@@ -0,0 +1,345 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package unitchecker
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// A moduleConfig holds the settings read from a module's go.vet file.
+//
+// The file is line-oriented, with // comments, like go.mod.
+// Each line holds one directive:
+//
+//	enable name...      run the named analyzers, even optional ones
+//	disable name...     do not run the named analyzers
+//	flag name=value     set an analyzer flag, such as printf.funcs
+//
+// The enable and disable directives apply only when the command line
+// does not select analyzers with -NAME flags, and a flag directive
+// applies only when the command line does not set the same flag.
+type moduleConfig struct {
+	enable  []string
+	disable []string
+	flags   []moduleFlag
+}
+
+type moduleFlag struct {
+	pos         string // file:line, for errors
+	name, value string
+}
+
+// parseModuleConfig parses the contents of the go.vet file.
+func parseModuleConfig(file string, data []byte) (*moduleConfig, error) {
+	mc := new(moduleConfig)
+	for i, line := range strings.Split(string(data), "\n") {
+		pos := fmt.Sprintf("%s:%d", file, i+1)
+		if j := strings.Index(line, "//"); j >= 0 {
+			line = line[:j]
+		}
+		f := strings.Fields(line)
+		if len(f) == 0 {
+			continue
+		}
+		switch f[0] {
+		case "enable":
+			mc.enable = append(mc.enable, f[1:]...)
+		case "disable":
+			mc.disable = append(mc.disable, f[1:]...)
+		case "flag":
+			if len(f) != 2 {
+				return nil, fmt.Errorf("%s: usage: flag name=value", pos)
+			}
+			eq := strings.Index(f[1], "=")
+			if eq < 0 {
+				return nil, fmt.Errorf("%s: usage: flag name=value", pos)
+			}
+			mc.flags = append(mc.flags, moduleFlag{pos, f[1][:eq], f[1][eq+1:]})
+			continue
+		default:
+			return nil, fmt.Errorf("%s: unknown directive %q", pos, f[0])
+		}
+		if len(f) == 1 {
+			return nil, fmt.Errorf("%s: usage: %s name...", pos, f[0])
+		}
+	}
+	return mc, nil
+}
+
+// applyModuleConfig applies the go.vet file named by file to the
+// analyzers selected by the command line and returns the analyzers
+// to run. It sets the analyzer flags listed in the file.
+func applyModuleConfig(file string, selected []*analysis.Analyzer) ([]*analysis.Analyzer, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	mc, err := parseModuleConfig(file, data)
+	if err != nil {
+		return nil, err
+	}
+
+	all := allAnalyzers
+	if all == nil {
+		all = selected
+	}
+	byName := make(map[string]*analysis.Analyzer)
+	for _, a := range all {
+		byName[a.Name] = a
+	}
+	set := make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) { set[f.Name] = true })
+
+	for _, list := range [][]string{mc.enable, mc.disable} {
+		for _, name := range list {
+			if byName[name] == nil {
+				return nil, fmt.Errorf("%s: unknown analyzer %q", file, name)
+			}
+		}
+	}
+	explicit := false
+	for name := range byName {
+		if set[name] {
+			explicit = true
+		}
+	}
+	if !explicit && (len(mc.enable) > 0 || len(mc.disable) > 0) {
+		run := make(map[string]bool)
+		for _, a := range selected {
+			run[a.Name] = true
+		}
+		for _, name := range mc.enable {
+			run[name] = true
+		}
+		for _, name := range mc.disable {
+			run[name] = false
+		}
+		selected = nil
+		for _, a := range all {
+			if run[a.Name] {
+				selected = append(selected, a)
+			}
+		}
+	}
+
+	for _, f := range mc.flags {
+		if !strings.Contains(f.name, ".") || flag.Lookup(f.name) == nil {
+			return nil, fmt.Errorf("%s: unknown analyzer flag %q", f.pos, f.name)
+		}
+		if set[f.name] {
+			continue
+		}
+		if err := flag.Set(f.name, f.value); err != nil {
+			return nil, fmt.Errorf("%s: invalid value %q for flag %s: %v", f.pos, f.value, f.name, err)
+		}
+	}
+	return selected, nil
+}
+
+// ignoreDirective is the prefix of the comments that suppress
+// the diagnostics of some analyzers in a file or on a line.
+const ignoreDirective = "//vet:ignore "
+
+// An ignoreSet records the analyzers whose diagnostics a file
+// suppresses with //vet:ignore comments.
+type ignoreSet struct {
+	file  map[string]bool         // in the whole file
+	lines map[int]map[string]bool // by line
+}
+
+// ignores reports whether the diagnostics of analyzer name on line
+// are suppressed.
+func (s *ignoreSet) ignores(name string, line int) bool {
+	return s != nil && (s.file[name] || s.lines[line][name])
+}
+
+// ignoredAnalyzers returns the analyzers listed in the //vet:ignore
+// comments of f, or nil if there are none. A comment before the package
+// clause applies to the whole file. Any other comment applies to its
+// own line if it follows code, and otherwise to the next line.
+func ignoredAnalyzers(fset *token.FileSet, f *ast.File) *ignoreSet {
+	var s *ignoreSet
+	var code map[int]token.Pos
+	for _, g := range f.Comments {
+		for _, c := range g.List {
+			if !strings.HasPrefix(c.Text, ignoreDirective) {
+				continue
+			}
+			names := strings.FieldsFunc(c.Text[len(ignoreDirective):], func(r rune) bool {
+				return r == ',' || r == ' ' || r == '\t'
+			})
+			if s == nil {
+				s = &ignoreSet{lines: make(map[int]map[string]bool)}
+			}
+			if c.Pos() < f.Package {
+				s.file = addNames(s.file, names)
+				continue
+			}
+			if code == nil {
+				code = firstCode(fset, f)
+			}
+			line := fset.Position(c.Pos()).Line
+			if p, ok := code[line]; !ok || p > c.Pos() {
+				line++
+			}
+			s.lines[line] = addNames(s.lines[line], names)
+		}
+	}
+	return s
+}
+
+// firstCode returns, by line, the position of the first node of f
+// that starts or ends on that line, not counting comments.
+func firstCode(fset *token.FileSet, f *ast.File) map[int]token.Pos {
+	first := make(map[int]token.Pos)
+	add := func(pos token.Pos) {
+		line := fset.Position(pos).Line
+		if p, ok := first[line]; !ok || pos < p {
+			first[line] = pos
+		}
+	}
+	ast.Inspect(f, func(n ast.Node) bool {
+		switch n.(type) {
+		case nil, *ast.Comment, *ast.CommentGroup:
+			return false
+		}
+		add(n.Pos())
+		add(n.End() - 1)
+		return true
+	})
+	return first
+}
+
+// addNames adds names to the set m, allocating it if needed, and
+// returns it.
+func addNames(m map[string]bool, names []string) map[string]bool {
+	for _, name := range names {
+		if m == nil {
+			m = make(map[string]bool)
+		}
+		m[name] = true
+	}
+	return m
+}
+
+// applyFixes applies the first suggested fix of each diagnostic in
+// results to the source files and removes the fixed diagnostics from
+// results. Only the text edits are applied, except that a deletion
+// that leaves its lines blank removes them; the rest of each file is
+// left as is, even if it is not gofmt-formatted. A fix is skipped,
+// and its diagnostic kept, if it edits a file outside the package
+// directory, such as a file generated by cgo, or if it overlaps a fix
+// applied earlier.
+func applyFixes(fset *token.FileSet, dir string, results []result) error {
+	type edit struct {
+		start, end int
+		text       []byte
+	}
+	edits := make(map[string][]edit)
+
+	// addFix records the edits of fix unless they conflict.
+	addFix := func(fix analysis.SuggestedFix) bool {
+		if len(fix.TextEdits) == 0 {
+			return false
+		}
+		add := make(map[string][]edit)
+	nextEdit:
+		for _, te := range fix.TextEdits {
+			start := fset.Position(te.Pos)
+			end := start
+			if te.End.IsValid() {
+				end = fset.Position(te.End)
+			}
+			if filepath.Dir(start.Filename) != dir || end.Filename != start.Filename || end.Offset < start.Offset {
+				return false
+			}
+			e := edit{start.Offset, end.Offset, te.NewText}
+			for _, old := range append(edits[start.Filename], add[start.Filename]...) {
+				if old.start == e.start && old.end == e.end && bytes.Equal(old.text, e.text) {
+					continue nextEdit // same edit as another fix
+				}
+				if old.start < e.end && e.start < old.end || old.start == e.start {
+					return false
+				}
+			}
+			add[start.Filename] = append(add[start.Filename], e)
+		}
+		for file, list := range add {
+			edits[file] = append(edits[file], list...)
+		}
+		return true
+	}
+
+	for i := range results {
+		res := &results[i]
+		var kept []analysis.Diagnostic
+		for _, d := range res.diagnostics {
+			if len(d.SuggestedFixes) == 0 || !addFix(d.SuggestedFixes[0]) {
+				kept = append(kept, d)
+			}
+		}
+		res.diagnostics = kept
+	}
+
+	for file, list := range edits {
+		info, err := os.Stat(file)
+		if err != nil {
+			return err
+		}
+		src, err := ioutil.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		sort.Slice(list, func(i, j int) bool { return list[i].start < list[j].start })
+		var out []byte
+		last := 0
+		for _, e := range list {
+			if len(e.text) == 0 {
+				e.start, e.end = deleteLine(src, e.start, e.end)
+			}
+			if e.start < last {
+				e.start = last
+			}
+			if e.end < e.start {
+				e.end = e.start
+			}
+			out = append(out, src[last:e.start]...)
+			out = append(out, e.text...)
+			last = e.end
+		}
+		out = append(out, src[last:]...)
+		if err := ioutil.WriteFile(file, out, info.Mode()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteLine returns the range to delete instead of src[start:end]
+// so that a deletion that leaves only white space on its lines
+// removes those lines entirely.
+func deleteLine(src []byte, start, end int) (int, int) {
+	i := bytes.LastIndexByte(src[:start], '\n') + 1
+	j := bytes.IndexByte(src[end:], '\n')
+	if j < 0 {
+		return start, end
+	}
+	j += end + 1
+	if len(bytes.TrimSpace(src[i:start])) > 0 || len(bytes.TrimSpace(src[end:j])) > 0 {
+		return start, end
+	}
+	return i, j
+}
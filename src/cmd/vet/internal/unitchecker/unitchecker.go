@@ -18,6 +18,10 @@
 // If you need a standalone tool, use multichecker,
 // which supports this mode but can also load packages
 // from source using go/packages.
+//
+// This is a copy of golang.org/x/tools/go/analysis/unitchecker,
+// extended for cmd/vet with optional analyzers, per-module
+// configuration in go.vet files, //vet:ignore comments and -fix.
 package unitchecker
 
 // TODO(adonovan):
@@ -48,9 +52,10 @@ import (
 	"sync"
 	"time"
 
+	"cmd/vet/internal/analysisflags"
+	"cmd/vet/internal/facts"
+
 	"golang.org/x/tools/go/analysis"
-	"golang.org/x/tools/go/analysis/internal/analysisflags"
-	"golang.org/x/tools/go/analysis/internal/facts"
 )
 
 // A Config describes a compilation unit to be analyzed.
@@ -69,6 +74,7 @@ type Config struct {
 	PackageVetx               map[string]string
 	VetxOnly                  bool
 	VetxOutput                string
+	VetConfigFile             string // module's go.vet file, if any
 	SucceedOnTypecheckFailure bool
 }
 
@@ -83,6 +89,23 @@ type Config struct {
 //                      unit described by a JSON config file foo.cfg.
 //
 func Main(analyzers ...*analysis.Analyzer) {
+	MainWithOptional(analyzers, nil)
+}
+
+// allAnalyzers holds all the analyzers passed to MainWithOptional,
+// including those not selected by the command line.
+var allAnalyzers []*analysis.Analyzer
+
+// MainWithOptional is like Main, but the optional analyzers are run
+// only when enabled explicitly, by a -NAME flag or by an enable
+// directive in the module's go.vet file.
+func MainWithOptional(analyzers, optional []*analysis.Analyzer) {
+	for _, a := range optional {
+		analysisflags.Optional[a] = true
+	}
+	analyzers = append(analyzers[:len(analyzers):len(analyzers)], optional...)
+	allAnalyzers = analyzers
+
 	progname := filepath.Base(os.Args[0])
 	log.SetFlags(0)
 	log.SetPrefix(progname + ": ")
@@ -109,7 +132,7 @@ Usage of %[1]s:
 		flag.Usage()
 	}
 	if args[0] == "help" {
-		analysisflags.Help(progname, analyzers, args[1:])
+		analysisflags.Help(progname, allAnalyzers, args[1:])
 		os.Exit(0)
 	}
 	if len(args) != 1 || !strings.HasSuffix(args[0], ".cfg") {
@@ -127,12 +150,27 @@ func Run(configFile string, analyzers []*analysis.Analyzer) {
 		log.Fatal(err)
 	}
 
+	// The module's go.vet file applies to the packages being
+	// checked, not to those analyzed only for their facts.
+	if cfg.VetConfigFile != "" && !cfg.VetxOnly {
+		analyzers, err = applyModuleConfig(cfg.VetConfigFile, analyzers)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
 	fset := token.NewFileSet()
 	results, err := run(fset, cfg, analyzers)
 	if err != nil {
 		log.Fatal(err)
 	}
 
+	if analysisflags.Fix && !cfg.VetxOnly {
+		if err := applyFixes(fset, cfg.Dir, results); err != nil {
+			log.Fatal(err)
+		}
+	}
+
 	// In VetxOnly mode, the analysis is run only for facts.
 	if !cfg.VetxOnly {
 		if analysisflags.JSON {
@@ -368,13 +406,25 @@ func run(fset *token.FileSet, cfg *Config, analyzers []*analysis.Analyzer) ([]re
 
 	execAll(analyzers)
 
+	// Files may suppress diagnostics with //vet:ignore comments.
+	ignored := make(map[string]*ignoreSet)
+	for _, f := range files {
+		if s := ignoredAnalyzers(fset, f); s != nil {
+			ignored[fset.File(f.Pos()).Name()] = s
+		}
+	}
+
 	// Return diagnostics and errors from root analyzers.
 	results := make([]result, len(analyzers))
 	for i, a := range analyzers {
 		act := actions[a]
 		results[i].a = a
 		results[i].err = act.err
-		results[i].diagnostics = act.diagnostics
+		for _, d := range act.diagnostics {
+			if pos := fset.Position(d.Pos); !ignored[pos.Filename].ignores(a.Name, pos.Line) {
+				results[i].diagnostics = append(results[i].diagnostics, d)
+			}
+		}
 	}
 
 	data := facts.Encode()
@@ -2,8 +2,15 @@ package main
 
 import (
 	"cmd/internal/objabi"
+	"cmd/vet/internal/passes/deferclose"
+	"cmd/vet/internal/passes/lostcancel"
+	"cmd/vet/internal/passes/printf"
+	"cmd/vet/internal/passes/tests"
+	"cmd/vet/internal/passes/tickerstop"
+	"cmd/vet/internal/passes/waitgroup"
+	"cmd/vet/internal/unitchecker"
 
-	"golang.org/x/tools/go/analysis/unitchecker"
+	"golang.org/x/tools/go/analysis"
 
 	"golang.org/x/tools/go/analysis/passes/asmdecl"
 	"golang.org/x/tools/go/analysis/passes/assign"
@@ -17,14 +24,12 @@ import (
 	"golang.org/x/tools/go/analysis/passes/httpresponse"
 	"golang.org/x/tools/go/analysis/passes/ifaceassert"
 	"golang.org/x/tools/go/analysis/passes/loopclosure"
-	"golang.org/x/tools/go/analysis/passes/lostcancel"
 	"golang.org/x/tools/go/analysis/passes/nilfunc"
-	"golang.org/x/tools/go/analysis/passes/printf"
 	"golang.org/x/tools/go/analysis/passes/shift"
 	"golang.org/x/tools/go/analysis/passes/stdmethods"
 	"golang.org/x/tools/go/analysis/passes/stringintconv"
 	"golang.org/x/tools/go/analysis/passes/structtag"
-	"golang.org/x/tools/go/analysis/passes/tests"
+	"golang.org/x/tools/go/analysis/passes/testinggoroutine"
 	"golang.org/x/tools/go/analysis/passes/unmarshal"
 	"golang.org/x/tools/go/analysis/passes/unreachable"
 	"golang.org/x/tools/go/analysis/passes/unsafeptr"
@@ -34,7 +39,7 @@ import (
 func main() {
 	objabi.AddVersionFlag()
 
-	unitchecker.Main(
+	unitchecker.MainWithOptional([]*analysis.Analyzer{
 		asmdecl.Analyzer,
 		assign.Analyzer,
 		atomic.Analyzer,
@@ -54,10 +59,17 @@ func main() {
 		stdmethods.Analyzer,
 		stringintconv.Analyzer,
 		structtag.Analyzer,
+		testinggoroutine.Analyzer,
 		tests.Analyzer,
+		tickerstop.Analyzer,
 		unmarshal.Analyzer,
 		unreachable.Analyzer,
 		unsafeptr.Analyzer,
 		unusedresult.Analyzer,
-	)
+		waitgroup.Analyzer,
+	}, []*analysis.Analyzer{
+		// Optional analyzers, which go vet runs only when they
+		// are enabled by a flag or by the module's go.vet file.
+		deferclose.Analyzer,
+	})
 }
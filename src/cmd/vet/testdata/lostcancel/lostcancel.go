@@ -12,3 +12,10 @@ func _() {
 		_ = cancel
 	}
 } // ERROR "this return statement may be reached without using the cancel var defined on line 10"
+
+func _() {
+	var _, cancel = context.WithCancelCause(context.Background()) // ERROR "the cancel function is not used on all paths \(possible context leak\)"
+	if false {
+		_ = cancel
+	}
+} // ERROR "this return statement may be reached without using the cancel var defined on line 17"
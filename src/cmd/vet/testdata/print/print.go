@@ -246,6 +246,12 @@ func PrintfTests() {
 	Errorf(1, "%d", 3)    // OK
 	Errorf(1, "%d", "hi") // ERROR "Errorf format %d has arg \x22hi\x22 of wrong type string"
 
+	// fmt.Errorf accepts several %w verbs.
+	_ = fmt.Errorf("%w: %w", os.ErrNotExist, os.ErrPermission)        // OK
+	_ = fmt.Errorf("%w: %w", os.ErrNotExist, 3)                       // ERROR "Errorf format %w has arg 3 of wrong type int"
+	_ = fmt.Errorf("%w %w %d", os.ErrNotExist, os.ErrPermission, "x") // ERROR "Errorf format %d has arg \x22x\x22 of wrong type string"
+	_ = fmt.Errorf("%w %w", os.ErrNotExist, os.ErrPermission, 3)      // ERROR "Errorf call needs 2 args but has 3 args"
+
 	// Multiple string arguments before variadic args
 	errorf("WARNING", "foobar")            // OK
 	errorf("INFO", "s=%s, n=%d", "foo", 1) // OK
@@ -0,0 +1,48 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file contains tests for the testinggoroutine checker.
+
+package testinggoroutine
+
+import "testing"
+
+func TestBadFatal(t *testing.T) {
+	done := make(chan bool)
+	go func() {
+		defer close(done)
+		t.Fatal("oops") // ERROR "call to \(\*T\).Fatal from a non-test goroutine"
+	}()
+	<-done
+}
+
+func TestBadSkip(t *testing.T) {
+	go func(t *testing.T) {
+		if true {
+			t.Skipf("skipping %d", 1) // ERROR "call to \(\*T\).Skipf from a non-test goroutine"
+		}
+	}(t)
+}
+
+func BenchmarkBadFailNow(b *testing.B) {
+	go func() {
+		b.FailNow() // ERROR "call to \(\*B\).FailNow from a non-test goroutine"
+	}()
+}
+
+func TestOK(t *testing.T) {
+	errc := make(chan error)
+	go func() {
+		t.Error("Error may be called from any goroutine")
+		t.Log("so may Log")
+		errc <- nil
+	}()
+	if err := <-errc; err != nil {
+		t.Fatal(err)
+	}
+
+	// A function literal called by the test runs in its goroutine.
+	f := func() { t.Fatal("ok") }
+	f()
+}
@@ -1,3 +1,24 @@
 package testdata
 
+import "testing"
+
 func Example_BadSuffix() {} // ERROR "Example_BadSuffix has malformed example suffix: BadSuffix"
+
+func TestSetenvParallel(t *testing.T) {
+	t.Parallel()
+	t.Setenv("KEY", "value") // ERROR "t.Setenv called in a parallel test; it panics because it changes the state of the whole process"
+}
+
+func TestChdirParallelParent(t *testing.T) {
+	t.Parallel()
+	t.Run("sub", func(t2 *testing.T) {
+		t2.Chdir("dir") // ERROR "t2.Chdir called in a subtest of a parallel test; it panics because it changes the state of the whole process"
+	})
+}
+
+func TestSetenvSequential(t *testing.T) {
+	t.Setenv("KEY", "value")
+	t.Run("sub", func(t *testing.T) {
+		t.Chdir("dir")
+	})
+}
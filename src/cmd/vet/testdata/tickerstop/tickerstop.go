@@ -0,0 +1,67 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file contains tests for the tickerstop checker.
+
+package tickerstop
+
+import "time"
+
+func bad(done chan bool) {
+	t := time.NewTicker(time.Second) // ERROR "the ticker t is never stopped \(possible resource leak\)"
+	for {
+		select {
+		case <-t.C:
+		case <-done:
+			return
+		}
+	}
+}
+
+func badReset() {
+	var t = time.NewTicker(time.Second) // ERROR "the ticker t is never stopped \(possible resource leak\)"
+	<-t.C
+	t.Reset(2 * time.Second)
+	<-t.C
+}
+
+func badDirect() {
+	for range time.NewTicker(time.Second).C { // ERROR "the ticker returned by time.NewTicker is never stopped \(possible resource leak\)"
+		break
+	}
+}
+
+func good() {
+	t := time.NewTicker(time.Second)
+	defer t.Stop()
+	<-t.C
+
+	t2 := time.NewTicker(time.Second)
+	go func() {
+		<-t2.C
+		t2.Stop()
+	}()
+}
+
+func escapes() *time.Ticker {
+	t := time.NewTicker(time.Second)
+	return t
+}
+
+func passed() {
+	t := time.NewTicker(time.Second)
+	stopLater(t)
+}
+
+func stopLater(t *time.Ticker) {
+	t.Stop()
+}
+
+type poller struct {
+	ticker *time.Ticker
+}
+
+func (p *poller) start() {
+	p.ticker = time.NewTicker(time.Second)
+}
@@ -0,0 +1,64 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file contains tests for the waitgroup checker.
+
+package waitgroup
+
+import "sync"
+
+func bad() {
+	var wg sync.WaitGroup
+	go func() {
+		wg.Add(1) // ERROR "WaitGroup.Add called from inside new goroutine"
+		defer wg.Done()
+	}()
+	wg.Wait()
+}
+
+func badPointer(wg *sync.WaitGroup) {
+	for i := 0; i < 10; i++ {
+		go func() {
+			wg.Add(1) // ERROR "WaitGroup.Add called from inside new goroutine"
+			work()
+			wg.Done()
+		}()
+	}
+}
+
+type server struct {
+	wg sync.WaitGroup
+}
+
+func (s *server) badField() {
+	go func() {
+		s.wg.Add(1) // ERROR "WaitGroup.Add called from inside new goroutine"
+		defer s.wg.Done()
+	}()
+}
+
+func good() {
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		// The goroutine is already counted, so it may add
+		// the goroutines it starts.
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+		}()
+	}()
+	wg.Wait()
+
+	// Add on a different WaitGroup is fine.
+	var other sync.WaitGroup
+	go func() {
+		other.Add(1)
+		defer wg.Done()
+	}()
+}
+
+func work() {}
@@ -88,6 +88,7 @@ func TestVet(t *testing.T) {
 		"composite",
 		"copylock",
 		"deadcode",
+		"deferclose",
 		"httpresponse",
 		"lostcancel",
 		"method",
@@ -96,11 +97,14 @@ func TestVet(t *testing.T) {
 		"rangeloop",
 		"shift",
 		"structtag",
+		"testinggoroutine",
 		"testingpkg",
 		// "testtag" has its own test
+		"tickerstop",
 		"unmarshal",
 		"unsafeptr",
 		"unused",
+		"waitgroup",
 	} {
 		pkg := pkg
 		t.Run(pkg, func(t *testing.T) {
@@ -113,6 +117,11 @@ func TestVet(t *testing.T) {
 
 			cmd := vetCmd(t, "-printfuncs=Warn,Warnf", pkg)
 
+			// The deferclose check is optional.
+			if pkg == "deferclose" {
+				cmd = vetCmd(t, "-deferclose", pkg)
+			}
+
 			// The asm test assumes amd64.
 			if pkg == "asm" {
 				cmd.Env = append(cmd.Env, "GOOS=linux", "GOARCH=amd64")
@@ -99,6 +99,7 @@ const (
 	extensionCertificateAuthorities  uint16 = 47
 	extensionSignatureAlgorithmsCert uint16 = 50
 	extensionKeyShare                uint16 = 51
+	extensionQUICTransportParameters uint16 = 57
 	extensionRenegotiationInfo       uint16 = 0xff01
 )
 
@@ -326,9 +327,11 @@ type ClientSessionState struct {
 	scts               [][]byte              // SCTs presented by the server
 
 	// TLS 1.3 fields.
-	nonce  []byte    // Ticket nonce sent by the server, to derive PSK
-	useBy  time.Time // Expiration of the ticket lifetime as set by the server
-	ageAdd uint32    // Random obfuscation factor for sending the ticket age
+	nonce        []byte    // Ticket nonce sent by the server, to derive PSK
+	useBy        time.Time // Expiration of the ticket lifetime as set by the server
+	ageAdd       uint32    // Random obfuscation factor for sending the ticket age
+	earlyData    bool      // Whether the ticket allows 0-RTT, only used by QUIC
+	alpnProtocol string    // Protocol negotiated in the session, to check 0-RTT
 }
 
 // ClientSessionCache is a cache of ClientSessionState objects that can be used
@@ -1255,6 +1258,7 @@ func (c *Config) BuildNameToCertificate() {
 
 const (
 	keyLogLabelTLS12           = "CLIENT_RANDOM"
+	keyLogLabelEarlyTraffic    = "CLIENT_EARLY_TRAFFIC_SECRET"
 	keyLogLabelClientHandshake = "CLIENT_HANDSHAKE_TRAFFIC_SECRET"
 	keyLogLabelServerHandshake = "SERVER_HANDSHAKE_TRAFFIC_SECRET"
 	keyLogLabelClientTraffic   = "CLIENT_TRAFFIC_SECRET_0"
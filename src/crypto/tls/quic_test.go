@@ -0,0 +1,462 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package tls
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+)
+
+type testQUICConn struct {
+	t           *testing.T
+	conn        *QUICConn
+	readSecret  map[QUICEncryptionLevel]suiteSecret
+	writeSecret map[QUICEncryptionLevel]suiteSecret
+	gotParams   []byte
+	rejected    bool
+	complete    bool
+
+	// ticketOpts, if set, makes the server send a session ticket
+	// when the handshake completes.
+	ticketOpts *QUICSessionTicketOptions
+}
+
+func newTestQUICClient(t *testing.T, config *Config) *testQUICConn {
+	q := &testQUICConn{t: t}
+	q.conn = QUICClient(&QUICConfig{
+		TLSConfig: config,
+	})
+	t.Cleanup(func() {
+		q.conn.Close()
+	})
+	return q
+}
+
+func newTestQUICServer(t *testing.T, config *Config) *testQUICConn {
+	q := &testQUICConn{t: t}
+	q.conn = QUICServer(&QUICConfig{
+		TLSConfig: config,
+	})
+	t.Cleanup(func() {
+		q.conn.Close()
+	})
+	return q
+}
+
+type suiteSecret struct {
+	suite  uint16
+	secret []byte
+}
+
+func (q *testQUICConn) setReadSecret(level QUICEncryptionLevel, suite uint16, secret []byte) {
+	if _, ok := q.writeSecret[level]; !ok && level != QUICEncryptionLevelEarly {
+		q.t.Errorf("SetReadSecret for level %v called before SetWriteSecret", level)
+	}
+	if level == QUICEncryptionLevelApplication && !q.complete {
+		q.t.Errorf("SetReadSecret for level %v called before HandshakeComplete", level)
+	}
+	if q.readSecret == nil {
+		q.readSecret = map[QUICEncryptionLevel]suiteSecret{}
+	}
+	if _, ok := q.readSecret[level]; ok {
+		q.t.Errorf("SetReadSecret for level %v called twice", level)
+	}
+	q.readSecret[level] = suiteSecret{suite, append([]byte(nil), secret...)}
+}
+
+func (q *testQUICConn) setWriteSecret(level QUICEncryptionLevel, suite uint16, secret []byte) {
+	if q.writeSecret == nil {
+		q.writeSecret = map[QUICEncryptionLevel]suiteSecret{}
+	}
+	if _, ok := q.writeSecret[level]; ok {
+		q.t.Errorf("SetWriteSecret for level %v called twice", level)
+	}
+	q.writeSecret[level] = suiteSecret{suite, append([]byte(nil), secret...)}
+}
+
+var errTransportParametersRequired = errors.New("transport parameters required")
+
+// runTestQUICConnection runs the handshake between cli and srv, delivering the
+// data each side writes to the other at the matching encryption level.
+// onEvent, if not nil, is called for each event and may consume it.
+func runTestQUICConnection(ctx context.Context, cli, srv *testQUICConn, onEvent func(e QUICEvent, src, dst *testQUICConn) bool) error {
+	a, b := cli, srv
+	for _, c := range []*testQUICConn{a, b} {
+		if !c.conn.conn.quic.started {
+			if err := c.conn.Start(ctx); err != nil {
+				return err
+			}
+		}
+	}
+	idleCount := 0
+	for {
+		e := a.conn.NextEvent()
+		if onEvent != nil && onEvent(e, a, b) {
+			continue
+		}
+		switch e.Kind {
+		case QUICNoEvent:
+			idleCount++
+			if idleCount == 2 {
+				if !a.complete || !b.complete {
+					return errors.New("handshake incomplete")
+				}
+				return nil
+			}
+			a, b = b, a
+		case QUICSetReadSecret:
+			a.setReadSecret(e.Level, e.Suite, e.Data)
+		case QUICSetWriteSecret:
+			a.setWriteSecret(e.Level, e.Suite, e.Data)
+		case QUICWriteData:
+			if err := b.conn.HandleData(e.Level, e.Data); err != nil {
+				return err
+			}
+		case QUICTransportParameters:
+			a.gotParams = append([]byte{}, e.Data...)
+		case QUICTransportParametersRequired:
+			return errTransportParametersRequired
+		case QUICRejectedEarlyData:
+			a.rejected = true
+		case QUICHandshakeDone:
+			a.complete = true
+			if a.ticketOpts != nil {
+				if err := a.conn.SendSessionTicket(*a.ticketOpts); err != nil {
+					return err
+				}
+			}
+		}
+		if e.Kind != QUICNoEvent {
+			idleCount = 0
+		}
+	}
+}
+
+func testQUICConfig() *Config {
+	config := testConfig.Clone()
+	config.MinVersion = VersionTLS13
+	return config
+}
+
+// checkQUICSecrets checks that the secrets cli and srv provided to the QUIC
+// layer for each level match.
+func checkQUICSecrets(t *testing.T, cli, srv *testQUICConn) {
+	t.Helper()
+	for _, level := range []QUICEncryptionLevel{
+		QUICEncryptionLevelEarly,
+		QUICEncryptionLevelHandshake,
+		QUICEncryptionLevelApplication,
+	} {
+		if cw, sr := cli.writeSecret[level], srv.readSecret[level]; cw.suite != sr.suite || !bytes.Equal(cw.secret, sr.secret) {
+			t.Errorf("client write secret does not match server read secret for level %v", level)
+		}
+		if sw, cr := srv.writeSecret[level], cli.readSecret[level]; sw.suite != cr.suite || !bytes.Equal(sw.secret, cr.secret) {
+			t.Errorf("server write secret does not match client read secret for level %v", level)
+		}
+	}
+}
+
+func TestQUICConnection(t *testing.T) {
+	config := testQUICConfig()
+	config.NextProtos = []string{"h3"}
+
+	cli := newTestQUICClient(t, config)
+	cli.conn.SetTransportParameters([]byte("client params"))
+
+	srv := newTestQUICServer(t, config)
+	srv.conn.SetTransportParameters([]byte("server params"))
+
+	if err := runTestQUICConnection(context.Background(), cli, srv, nil); err != nil {
+		t.Fatalf("error during connection handshake: %v", err)
+	}
+
+	checkQUICSecrets(t, cli, srv)
+	if _, ok := cli.writeSecret[QUICEncryptionLevelHandshake]; !ok {
+		t.Errorf("client has no Handshake secret")
+	}
+	if _, ok := cli.writeSecret[QUICEncryptionLevelApplication]; !ok {
+		t.Errorf("client has no Application secret")
+	}
+	if _, ok := cli.writeSecret[QUICEncryptionLevelEarly]; ok {
+		t.Errorf("client has an Early secret on a full handshake")
+	}
+
+	if !bytes.Equal(cli.gotParams, []byte("server params")) {
+		t.Errorf("client got transport params %q, want %q", cli.gotParams, "server params")
+	}
+	if !bytes.Equal(srv.gotParams, []byte("client params")) {
+		t.Errorf("server got transport params %q, want %q", srv.gotParams, "client params")
+	}
+
+	for _, q := range []*testQUICConn{cli, srv} {
+		state := q.conn.ConnectionState()
+		if !state.HandshakeComplete {
+			t.Errorf("ConnectionState().HandshakeComplete = false")
+		}
+		if state.Version != VersionTLS13 {
+			t.Errorf("ConnectionState().Version = %x, want %x", state.Version, VersionTLS13)
+		}
+		if state.NegotiatedProtocol != "h3" {
+			t.Errorf("ConnectionState().NegotiatedProtocol = %q, want %q", state.NegotiatedProtocol, "h3")
+		}
+	}
+}
+
+func TestQUICHelloRetryRequest(t *testing.T) {
+	clientConfig := testQUICConfig()
+	clientConfig.CurvePreferences = []CurveID{X25519, CurveP256}
+	serverConfig := testQUICConfig()
+	serverConfig.CurvePreferences = []CurveID{CurveP256}
+
+	cli := newTestQUICClient(t, clientConfig)
+	cli.conn.SetTransportParameters(nil)
+	srv := newTestQUICServer(t, serverConfig)
+	srv.conn.SetTransportParameters(nil)
+	if err := runTestQUICConnection(context.Background(), cli, srv, nil); err != nil {
+		t.Fatalf("error during connection handshake: %v", err)
+	}
+	checkQUICSecrets(t, cli, srv)
+}
+
+func TestQUICDelayedTransportParameters(t *testing.T) {
+	config := testQUICConfig()
+
+	cli := newTestQUICClient(t, config)
+	srv := newTestQUICServer(t, config)
+
+	cliParams := "client params"
+	srvParams := "server params"
+
+	if err := runTestQUICConnection(context.Background(), cli, srv, nil); err != errTransportParametersRequired {
+		t.Fatalf("handshake with no client parameters: %v; want errTransportParametersRequired", err)
+	}
+	cli.conn.SetTransportParameters([]byte(cliParams))
+	if err := runTestQUICConnection(context.Background(), cli, srv, nil); err != errTransportParametersRequired {
+		t.Fatalf("handshake with no server parameters: %v; want errTransportParametersRequired", err)
+	}
+	srv.conn.SetTransportParameters([]byte(srvParams))
+	if err := runTestQUICConnection(context.Background(), cli, srv, nil); err != nil {
+		t.Fatalf("error during connection handshake: %v", err)
+	}
+
+	if got, want := string(cli.gotParams), srvParams; got != want {
+		t.Errorf("client got transport params: %q, want %q", got, want)
+	}
+	if got, want := string(srv.gotParams), cliParams; got != want {
+		t.Errorf("server got transport params: %q, want %q", got, want)
+	}
+}
+
+func TestQUICEmptyTransportParameters(t *testing.T) {
+	config := testQUICConfig()
+
+	cli := newTestQUICClient(t, config)
+	cli.conn.SetTransportParameters(nil)
+	srv := newTestQUICServer(t, config)
+	srv.conn.SetTransportParameters(nil)
+	if err := runTestQUICConnection(context.Background(), cli, srv, nil); err != nil {
+		t.Fatalf("error during connection handshake: %v", err)
+	}
+
+	if cli.gotParams == nil {
+		t.Errorf("client did not get transport params")
+	}
+	if srv.gotParams == nil {
+		t.Errorf("server did not get transport params")
+	}
+	if len(cli.gotParams) != 0 {
+		t.Errorf("client got transport params: %v, want empty", cli.gotParams)
+	}
+	if len(srv.gotParams) != 0 {
+		t.Errorf("server got transport params: %v, want empty", srv.gotParams)
+	}
+}
+
+func TestQUICStartRequiresTLS13(t *testing.T) {
+	config := testConfig.Clone()
+	config.MinVersion = VersionTLS12
+
+	cli := newTestQUICClient(t, config)
+	cli.conn.SetTransportParameters(nil)
+	err := cli.conn.Start(context.Background())
+	if err == nil {
+		t.Fatalf("Start with MinVersion TLS 1.2 succeeded, want error")
+	}
+	var ae AlertError
+	if !errors.As(err, &ae) {
+		t.Errorf("Start error %v is not an AlertError", err)
+	}
+}
+
+func TestQUICStartContextCanceled(t *testing.T) {
+	config := testQUICConfig()
+
+	cli := newTestQUICClient(t, config)
+	cli.conn.SetTransportParameters(nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := cli.conn.Start(ctx); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	if e := cli.conn.NextEvent(); e.Kind != QUICWriteData || e.Level != QUICEncryptionLevelInitial {
+		t.Errorf("first event is %v at level %v, want QUICWriteData at level Initial", e.Kind, e.Level)
+	}
+	cancel()
+	err := cli.conn.Close()
+	if err == nil {
+		t.Fatalf("Close after canceling the handshake returned nil, want error")
+	}
+	var ae AlertError
+	if !errors.As(err, &ae) {
+		t.Errorf("Close error %v is not an AlertError", err)
+	}
+}
+
+func TestQUICHandshakeError(t *testing.T) {
+	clientConfig := testQUICConfig()
+	clientConfig.InsecureSkipVerify = false
+	clientConfig.ServerName = "name"
+
+	serverConfig := testQUICConfig()
+
+	cli := newTestQUICClient(t, clientConfig)
+	cli.conn.SetTransportParameters(nil)
+	srv := newTestQUICServer(t, serverConfig)
+	srv.conn.SetTransportParameters(nil)
+	err := runTestQUICConnection(context.Background(), cli, srv, nil)
+	if err == nil {
+		t.Fatalf("connection with untrusted certificate succeeded, want error")
+	}
+	var ae AlertError
+	if !errors.As(err, &ae) {
+		t.Fatalf("connection handshake error %v is not an AlertError", err)
+	}
+	if ae != AlertError(alertBadCertificate) {
+		t.Errorf("connection handshake failed with alert %v, want %v", ae, AlertError(alertBadCertificate))
+	}
+}
+
+func TestQUICSessionResumption(t *testing.T) {
+	clientConfig := testQUICConfig()
+	clientConfig.ClientSessionCache = NewLRUClientSessionCache(1)
+	clientConfig.ServerName = "example.go.dev"
+
+	serverConfig := testQUICConfig()
+
+	cli := newTestQUICClient(t, clientConfig)
+	cli.conn.SetTransportParameters(nil)
+	srv := newTestQUICServer(t, serverConfig)
+	srv.conn.SetTransportParameters(nil)
+	srv.ticketOpts = &QUICSessionTicketOptions{}
+	if err := runTestQUICConnection(context.Background(), cli, srv, nil); err != nil {
+		t.Fatalf("error during first connection handshake: %v", err)
+	}
+	if cli.conn.ConnectionState().DidResume {
+		t.Errorf("first connection unexpectedly used session resumption")
+	}
+
+	cli2 := newTestQUICClient(t, clientConfig)
+	cli2.conn.SetTransportParameters(nil)
+	srv2 := newTestQUICServer(t, serverConfig)
+	srv2.conn.SetTransportParameters(nil)
+	if err := runTestQUICConnection(context.Background(), cli2, srv2, nil); err != nil {
+		t.Fatalf("error during second connection handshake: %v", err)
+	}
+	if !cli2.conn.ConnectionState().DidResume {
+		t.Errorf("second connection did not use session resumption")
+	}
+	if !srv2.conn.ConnectionState().DidResume {
+		t.Errorf("second connection did not use session resumption on the server")
+	}
+	if _, ok := cli2.writeSecret[QUICEncryptionLevelEarly]; ok {
+		t.Errorf("client offered 0-RTT with a ticket that does not allow it")
+	}
+	checkQUICSecrets(t, cli2, srv2)
+}
+
+func TestQUICEarlyData(t *testing.T) {
+	clientConfig := testQUICConfig()
+	clientConfig.ClientSessionCache = NewLRUClientSessionCache(1)
+	clientConfig.ServerName = "example.go.dev"
+	clientConfig.NextProtos = []string{"h3"}
+
+	serverConfig := testQUICConfig()
+	serverConfig.NextProtos = []string{"h3"}
+
+	cli := newTestQUICClient(t, clientConfig)
+	cli.conn.SetTransportParameters(nil)
+	srv := newTestQUICServer(t, serverConfig)
+	srv.conn.SetTransportParameters(nil)
+	srv.ticketOpts = &QUICSessionTicketOptions{EarlyData: true}
+	if err := runTestQUICConnection(context.Background(), cli, srv, nil); err != nil {
+		t.Fatalf("error during first connection handshake: %v", err)
+	}
+
+	cli2 := newTestQUICClient(t, clientConfig)
+	cli2.conn.SetTransportParameters(nil)
+	srv2 := newTestQUICServer(t, serverConfig)
+	srv2.conn.SetTransportParameters(nil)
+	if err := runTestQUICConnection(context.Background(), cli2, srv2, nil); err != nil {
+		t.Fatalf("error during second connection handshake: %v", err)
+	}
+	if !cli2.conn.ConnectionState().DidResume {
+		t.Errorf("second connection did not use session resumption")
+	}
+	if _, ok := cli2.writeSecret[QUICEncryptionLevelEarly]; !ok {
+		t.Errorf("client did not set an Early write secret")
+	}
+	if _, ok := srv2.readSecret[QUICEncryptionLevelEarly]; !ok {
+		t.Errorf("server did not set an Early read secret")
+	}
+	if cli2.rejected {
+		t.Errorf("client reported that 0-RTT was rejected")
+	}
+	checkQUICSecrets(t, cli2, srv2)
+}
+
+func TestQUICEarlyDataDeclined(t *testing.T) {
+	clientConfig := testQUICConfig()
+	clientConfig.ClientSessionCache = NewLRUClientSessionCache(1)
+	clientConfig.ServerName = "example.go.dev"
+	clientConfig.NextProtos = []string{"h3", "h3-other"}
+
+	serverConfig := testQUICConfig()
+	serverConfig.NextProtos = []string{"h3"}
+
+	cli := newTestQUICClient(t, clientConfig)
+	cli.conn.SetTransportParameters(nil)
+	srv := newTestQUICServer(t, serverConfig)
+	srv.conn.SetTransportParameters(nil)
+	srv.ticketOpts = &QUICSessionTicketOptions{EarlyData: true}
+	if err := runTestQUICConnection(context.Background(), cli, srv, nil); err != nil {
+		t.Fatalf("error during first connection handshake: %v", err)
+	}
+
+	// The server negotiates a different protocol on the second connection,
+	// so it must not accept 0-RTT data sent for the first one.
+	serverConfig2 := serverConfig.Clone()
+	serverConfig2.NextProtos = []string{"h3-other"}
+
+	cli2 := newTestQUICClient(t, clientConfig)
+	cli2.conn.SetTransportParameters(nil)
+	srv2 := newTestQUICServer(t, serverConfig2)
+	srv2.conn.SetTransportParameters(nil)
+	if err := runTestQUICConnection(context.Background(), cli2, srv2, nil); err != nil {
+		t.Fatalf("error during second connection handshake: %v", err)
+	}
+	if !cli2.conn.ConnectionState().DidResume {
+		t.Errorf("second connection did not use session resumption")
+	}
+	if _, ok := cli2.writeSecret[QUICEncryptionLevelEarly]; !ok {
+		t.Errorf("client did not offer 0-RTT")
+	}
+	if _, ok := srv2.readSecret[QUICEncryptionLevelEarly]; ok {
+		t.Errorf("server accepted 0-RTT with a different ALPN protocol")
+	}
+	if !cli2.rejected {
+		t.Errorf("client did not report that 0-RTT was rejected")
+	}
+}
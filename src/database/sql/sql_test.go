@@ -2860,12 +2860,14 @@ func TestConnExpiresFreshOutOfPool(t *testing.T) {
 			waitingForConn := make(chan struct{})
 
 			go func() {
+				defer close(afterPutConn)
+
 				conn, err := db.conn(ctx, alwaysNewConn)
 				if err != nil {
-					t.Fatal(err)
+					t.Error(err)
+					return
 				}
 				db.putConn(conn, err, false)
-				close(afterPutConn)
 			}()
 			go func() {
 				for {
@@ -0,0 +1,42 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package embedtest_test
+
+import (
+	"embed"
+	"io/ioutil"
+	"testing"
+)
+
+var (
+	x = 1 // initialized before the embedded variables
+
+	//go:embed testdata/*.txt
+	all embed.FS
+
+	//go:embed testdata/ken.txt
+	ken string
+)
+
+func TestXGlobal(t *testing.T) {
+	if x != 1 {
+		t.Fatalf("x = %d, want 1", x)
+	}
+	f, err := all.Open("testdata/ken.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	data, err := ioutil.ReadAll(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != ken {
+		t.Errorf("testdata/ken.txt = %q, want %q", data, ken)
+	}
+	if _, err := all.ReadFile("testdata/i/i18n.txt"); err == nil {
+		t.Errorf("ReadFile(testdata/i/i18n.txt) succeeded; testdata/*.txt should not match subdirectories")
+	}
+}
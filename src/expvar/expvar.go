@@ -8,8 +8,9 @@
 //
 // expvar 包除了添加 HTTP handler 外，还注册了以下变量：
 //
-//	cmdline   os.Args
-//	memstats  runtime.Memstats
+//	cmdline          os.Args
+//	memstats         runtime.Memstats
+//	runtime/metrics  runtime/metrics.All 列出的所有运行时指标
 //
 // 有时仅出于注册 HTTP handler 和上述变量时的副作用而导入本包。为了以副作用方式使用本包，请将此包按如下方式导入到程序中：
 //	import _ "expvar"
@@ -24,6 +25,7 @@ import (
 	"net/http"
 	"os"
 	"runtime"
+	"runtime/metrics"
 	"sort"
 	"strconv"
 	"strings"
@@ -345,8 +347,62 @@ func memstats() interface{} {
 	return *stats
 }
 
+// runtimeMetrics 返回 runtime/metrics 支持的所有指标的当前值，以指标名为键。
+func runtimeMetrics() interface{} {
+	descs := metrics.All()
+	samples := make([]metrics.Sample, len(descs))
+	for i := range descs {
+		samples[i].Name = descs[i].Name
+	}
+	metrics.Read(samples)
+	m := make(map[string]interface{}, len(samples))
+	for _, s := range samples {
+		switch s.Value.Kind() {
+		case metrics.KindUint64:
+			m[s.Name] = s.Value.Uint64()
+		case metrics.KindFloat64:
+			m[s.Name] = s.Value.Float64()
+		case metrics.KindFloat64Histogram:
+			m[s.Name] = newHistogramJSON(s.Value.Float64Histogram())
+		}
+	}
+	return m
+}
+
+// histogramJSON 是 metrics.Float64Histogram 的 JSON 形式。
+type histogramJSON struct {
+	Counts  []uint64  `json:"counts"`
+	Buckets []float64 `json:"buckets"`
+}
+
+// newHistogramJSON 返回 h 的 JSON 形式。为了让输出更小，直方图两端的空桶会被丢弃；
+// 由于 JSON 无法表示无穷大，无穷大的桶边界会被替换为最大的有限 float64 值。
+func newHistogramJSON(h *metrics.Float64Histogram) histogramJSON {
+	lo, hi := 0, len(h.Counts)
+	for lo < hi && h.Counts[lo] == 0 {
+		lo++
+	}
+	for hi > lo && h.Counts[hi-1] == 0 {
+		hi--
+	}
+	if lo == hi {
+		return histogramJSON{Counts: []uint64{}, Buckets: []float64{}}
+	}
+	buckets := make([]float64, hi-lo+1)
+	copy(buckets, h.Buckets[lo:hi+1])
+	for i, b := range buckets {
+		if math.IsInf(b, -1) {
+			buckets[i] = -math.MaxFloat64
+		} else if math.IsInf(b, 1) {
+			buckets[i] = math.MaxFloat64
+		}
+	}
+	return histogramJSON{Counts: h.Counts[lo:hi], Buckets: buckets}
+}
+
 func init() {
 	http.HandleFunc("/debug/vars", expvarHandler)
 	Publish("cmdline", Func(cmdline))
 	Publish("memstats", Func(memstats))
+	Publish("runtime/metrics", Func(runtimeMetrics))
 }
@@ -438,6 +438,32 @@ func TestFunc(t *testing.T) {
 	}
 }
 
+func TestRuntimeMetrics(t *testing.T) {
+	runtime.GC()
+	s := Func(runtimeMetrics).String()
+	var got map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(s), &got); err != nil {
+		t.Fatalf("runtime metrics are not valid JSON: %v\n%s", err, s)
+	}
+	var n uint64
+	if err := json.Unmarshal(got["/gc/cycles/total:gc-cycles"], &n); err != nil || n == 0 {
+		t.Errorf("/gc/cycles/total:gc-cycles = %s, want a positive integer", got["/gc/cycles/total:gc-cycles"])
+	}
+	var h struct {
+		Counts  []uint64
+		Buckets []float64
+	}
+	if err := json.Unmarshal(got["/gc/pauses:seconds"], &h); err != nil {
+		t.Fatalf("/gc/pauses:seconds: %v", err)
+	}
+	if len(h.Counts) == 0 || len(h.Buckets) != len(h.Counts)+1 {
+		t.Errorf("/gc/pauses:seconds has %d counts and %d buckets, want at least one count and one more bucket", len(h.Counts), len(h.Buckets))
+	}
+	if len(h.Counts) > 0 && (h.Counts[0] == 0 || h.Counts[len(h.Counts)-1] == 0) {
+		t.Errorf("/gc/pauses:seconds has empty buckets at either end: %v", h.Counts)
+	}
+}
+
 func TestHandler(t *testing.T) {
 	RemoveAll()
 	m := NewMap("map1")
@@ -99,6 +99,11 @@ var depsRules = `
 	RUNTIME
 	< io;
 
+	RUNTIME, unicode/utf8
+	< path;
+
+	unicode !< path;
+
 	reflect !< sort;
 
 	# SYSCALL is RUNTIME plus the packages necessary for basic system calls.
@@ -129,6 +134,9 @@ var depsRules = `
 	MATH
 	< math/rand;
 
+	MATH
+	< runtime/metrics;
+
 	MATH, unicode/utf8
 	< strconv;
 
@@ -137,7 +145,7 @@ var depsRules = `
 	# STR is basic string and buffer manipulation.
 	RUNTIME, io, unicode/utf8, unicode/utf16, unicode
 	< bytes, strings
-	< bufio, path;
+	< bufio;
 
 	bufio, path, strconv
 	< STR;
@@ -145,13 +153,17 @@ var depsRules = `
 	# OS is basic OS access, including helpers (path/filepath, os/exec, etc).
 	# OS includes string routines, but those must be layered above package os.
 	# OS does not include reflection.
-	TIME, io, sort
+	TIME, io, path, sort
+	< io/fs
 	< internal/testlog
 	< internal/poll
 	< os
 	< os/signal;
 
-	unicode, fmt !< os, os/signal;
+	unicode, fmt !< io/fs, os, os/signal;
+
+	io/fs
+	< embed;
 
 	os/signal, STR
 	< path/filepath
@@ -341,14 +353,26 @@ var depsRules = `
 	< NET;
 
 	# logging - most packages should not import; http and up is allowed
-	FMT
+	NONE
+	< log/internal;
+
+	FMT, log/internal
 	< log;
 
-	log !< crypto/tls, database/sql, go/importer, testing;
+	log, log/slog !< crypto/tls, database/sql, go/importer, testing;
 
 	FMT, log, net
 	< log/syslog;
 
+	RUNTIME
+	< log/slog/internal/buffer;
+
+	FMT,
+	encoding, encoding/json,
+	log, log/internal,
+	log/slog/internal/buffer
+	< log/slog;
+
 	NET, log
 	< net/mail;
 
@@ -429,11 +453,14 @@ var depsRules = `
 
 	# HTTP-aware packages
 
-	encoding/json, net/http
+	encoding/json, net/http, runtime/metrics
 	< expvar;
 
 	net/http
-	< net/http/cookiejar, net/http/httputil;
+	< net/http/cookiejar;
+
+	net/http, hash/fnv
+	< net/http/httputil;
 
 	net/http, flag
 	< net/http/httptest;
@@ -452,6 +479,11 @@ var depsRules = `
 	html/template, internal/profile, net/http, runtime/pprof, runtime/trace
 	< net/http/pprof;
 
+	# Coverage
+	FMT, crypto/sha256, encoding/hex
+	< internal/coverage
+	< runtime/coverage;
+
 	# RPC
 	encoding/gob, encoding/json, go/token, html/template, net/http
 	< net/rpc
@@ -459,15 +491,20 @@ var depsRules = `
 
 	# Test-only
 	log
-	< testing/iotest;
+	< testing/iotest
+	< testing/fstest;
 
 	FMT, flag, math/rand
 	< testing/quick;
 
-	FMT, flag, runtime/debug, runtime/trace
+	FMT, flag, math/rand, runtime/debug, runtime/trace
 	< testing;
 
-	internal/testlog, runtime/pprof, regexp
+	FMT, context, crypto/sha256, encoding/json, go/parser,
+	internal/unsafeheader, math/rand
+	< internal/fuzz;
+
+	internal/fuzz, internal/testlog, runtime/pprof, regexp
 	< testing/internal/testdeps;
 
 	OS, flag, testing, internal/cfg
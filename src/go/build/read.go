@@ -7,7 +7,13 @@ package build
 import (
 	"bufio"
 	"errors"
+	"fmt"
+	"go/scanner"
+	"go/token"
 	"io"
+	"strconv"
+	"strings"
+	"unicode"
 	"unicode/utf8"
 )
 
@@ -245,3 +251,123 @@ func readImports(f io.Reader, reportSyntaxError bool, imports *[]string) ([]byte
 
 	return r.buf, r.err
 }
+
+// A fileEmbed is a single //go:embed pattern and its source position.
+type fileEmbed struct {
+	pattern string
+	pos     token.Position
+}
+
+// readGoEmbed scans the Go source in data, which is the complete
+// content of the named file, and returns the patterns listed in its
+// //go:embed directives. A directive must be a // comment at the start
+// of a line; comments appearing after other tokens on a line, and text
+// inside string literals or /* */ comments, are ignored.
+func readGoEmbed(fset *token.FileSet, filename string, data []byte) ([]fileEmbed, error) {
+	var s scanner.Scanner
+	file := fset.AddFile(filename, -1, len(data))
+	s.Init(file, data, nil, scanner.ScanComments)
+
+	var embeds []fileEmbed
+	lastLine := 0
+	for {
+		pos, tok, lit := s.Scan()
+		if tok == token.EOF {
+			break
+		}
+		line := file.Line(pos)
+		if tok == token.COMMENT && line > lastLine && strings.HasPrefix(lit, "//go:embed") {
+			args := lit[len("//go:embed"):]
+			if args == "" || args[0] == ' ' || args[0] == '\t' {
+				// Patterns start after the "//go:embed" prefix.
+				p := fset.Position(pos)
+				p.Column += len("//go:embed")
+				p.Offset += len("//go:embed")
+				list, err := parseGoEmbed(args, p)
+				if err != nil {
+					return nil, err
+				}
+				embeds = append(embeds, list...)
+			}
+		}
+		if tok == token.COMMENT && strings.HasPrefix(lit, "/*") {
+			// A block comment may span lines; only its last line
+			// counts as having seen a token.
+			line += strings.Count(lit, "\n")
+		}
+		lastLine = line
+	}
+	return embeds, nil
+}
+
+// parseGoEmbed parses the text following "//go:embed" to extract the glob patterns.
+// It accepts unquoted space-separated patterns as well as double-quoted and back-quoted Go strings.
+// pos gives the position of the start of args.
+func parseGoEmbed(args string, pos token.Position) ([]fileEmbed, error) {
+	trimBytes := func(n int) {
+		pos.Offset += n
+		pos.Column += utf8.RuneCountInString(args[:n])
+		args = args[n:]
+	}
+	trimSpace := func() {
+		trim := strings.TrimLeftFunc(args, unicode.IsSpace)
+		trimBytes(len(args) - len(trim))
+	}
+
+	var list []fileEmbed
+	for trimSpace(); args != ""; trimSpace() {
+		var path string
+		pathPos := pos
+	Switch:
+		switch args[0] {
+		default:
+			i := len(args)
+			for j, c := range args {
+				if unicode.IsSpace(c) {
+					i = j
+					break
+				}
+			}
+			path = args[:i]
+			trimBytes(i)
+
+		case '`':
+			i := strings.Index(args[1:], "`")
+			if i < 0 {
+				return nil, fmt.Errorf("%s: invalid quoted string in //go:embed: %s", pos, args)
+			}
+			path = args[1 : 1+i]
+			trimBytes(1 + i + 1)
+
+		case '"':
+			i := 1
+			for ; i < len(args); i++ {
+				if args[i] == '\\' {
+					i++
+					continue
+				}
+				if args[i] == '"' {
+					q, err := strconv.Unquote(args[:i+1])
+					if err != nil {
+						return nil, fmt.Errorf("%s: invalid quoted string in //go:embed: %s", pos, args[:i+1])
+					}
+					path = q
+					trimBytes(i + 1)
+					break Switch
+				}
+			}
+			if i >= len(args) {
+				return nil, fmt.Errorf("%s: invalid quoted string in //go:embed: %s", pos, args)
+			}
+		}
+
+		if args != "" {
+			r, _ := utf8.DecodeRuneInString(args)
+			if !unicode.IsSpace(r) {
+				return nil, fmt.Errorf("%s: invalid quoted string in //go:embed: %s", pos, args)
+			}
+		}
+		list = append(list, fileEmbed{path, pathPos})
+	}
+	return list, nil
+}
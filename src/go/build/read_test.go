@@ -5,6 +5,8 @@
 package build
 
 import (
+	"fmt"
+	"go/token"
 	"io"
 	"strings"
 	"testing"
@@ -221,3 +223,70 @@ func TestReadFailuresIgnored(t *testing.T) {
 	}
 	testRead(t, tests, func(r io.Reader) ([]byte, error) { return readImports(r, false, nil) })
 }
+
+var readEmbedTests = []struct {
+	in, out string
+}{
+	{
+		"package p\n",
+		"",
+	},
+	{
+		"package p\nimport \"embed\"\nvar i int\n//go:embed x y z\nvar files embed.FS",
+		`test:4:12:x
+		 test:4:14:y
+		 test:4:16:z`,
+	},
+	{
+		"package p\nimport \"embed\"\nvar i int\n//go:embed x \"\\x79\" `z`\nvar files embed.FS",
+		`test:4:12:x
+		 test:4:14:y
+		 test:4:21:z`,
+	},
+	{
+		"package p\nimport \"embed\"\nvar i int\n//go:embed x y\n//go:embed z\nvar files embed.FS",
+		`test:4:12:x
+		 test:4:14:y
+		 test:5:12:z`,
+	},
+	{
+		"package p\nimport \"embed\"\nvar s = \"\\n//go:embed x\"\nvar t = `\n//go:embed y\n`\n/*\n//go:embed z\n*/\nvar u int // //go:embed w\n",
+		"",
+	},
+	{
+		"package p\nimport \"embed\"\n//go:embedded x\nvar files embed.FS",
+		"",
+	},
+}
+
+func TestReadEmbed(t *testing.T) {
+	fset := token.NewFileSet()
+	for i, tt := range readEmbedTests {
+		embeds, err := readGoEmbed(fset, "test", []byte(tt.in))
+		if err != nil {
+			t.Errorf("#%d: %v", i, err)
+			continue
+		}
+		var got []string
+		for _, e := range embeds {
+			got = append(got, fmt.Sprintf("%s:%s", e.pos, e.pattern))
+		}
+		want := strings.Fields(tt.out)
+		if strings.Join(got, "\n") != strings.Join(want, "\n") {
+			t.Errorf("#%d: embeds:\n%s\nwant:\n%s", i, strings.Join(got, "\n"), strings.Join(want, "\n"))
+		}
+	}
+}
+
+func TestReadEmbedErrors(t *testing.T) {
+	fset := token.NewFileSet()
+	for _, in := range []string{
+		"package p\nimport \"embed\"\n//go:embed \"x\nvar files embed.FS",
+		"package p\nimport \"embed\"\n//go:embed `x\nvar files embed.FS",
+		"package p\nimport \"embed\"\n//go:embed \"x\"y\nvar files embed.FS",
+	} {
+		if _, err := readGoEmbed(fset, "test", []byte(in)); err == nil || !strings.Contains(err.Error(), "invalid quoted string") {
+			t.Errorf("readGoEmbed(%q) = %v, want invalid quoted string error", in, err)
+		}
+	}
+}
@@ -59,6 +59,7 @@ const KnownEnv = `
 	GOTMPDIR
 	GOTOOLDIR
 	GOWASM
+	GOWORK
 	GO_EXTLINK_ENABLED
 	PKG_CONFIG
 `
@@ -0,0 +1,273 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package coverage defines the files written by programs built with
+// 'go build -cover' and read by 'go tool covdata'.
+//
+// A covered program writes two kinds of files into the directory named
+// by $GOCOVERDIR when it exits. A meta-data file, named
+//
+//	covmeta.<hash>
+//
+// describes the instrumented source files and the position and
+// statement count of each basic block in them. It depends only on the
+// binary, so every run of the same binary shares a single meta-data
+// file. A counter data file, named
+//
+//	covcounters.<hash>.<pid>.<nanotime>
+//
+// holds the counter values for one run of the program. <hash> is the
+// hash of the meta-data file that the counters correspond to.
+//
+// Both files are line-oriented text. A meta-data file looks like
+//
+//	go coverage meta v1
+//	mode: set
+//	file example.com/hello/hello.go
+//	block 5.14,7.2 1
+//	block 9.13,11.2 2
+//
+// and the matching counter data file like
+//
+//	go coverage counters v1
+//	meta: 1d5d1c9a3cbb2c4f07b6a5f0b1e87c34
+//	counts 1 0
+//
+// with one "counts" line for each "file" line in the meta-data.
+package coverage
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	// MetaFilePref is the prefix of meta-data file names.
+	MetaFilePref = "covmeta"
+
+	// CounterFilePref is the prefix of counter data file names.
+	CounterFilePref = "covcounters"
+
+	metaHeader    = "go coverage meta v1"
+	counterHeader = "go coverage counters v1"
+)
+
+// Block describes one basic block of an instrumented source file.
+type Block struct {
+	StartLine, StartCol uint32
+	EndLine, EndCol     uint32
+	NumStmt             uint16
+}
+
+// FileMeta describes the blocks of one instrumented source file.
+type FileMeta struct {
+	Name   string // import path followed by the file name, or a full path
+	Blocks []Block
+}
+
+// Meta is the content of a meta-data file.
+type Meta struct {
+	Mode  string // "set", "count" or "atomic"
+	Files []FileMeta
+}
+
+// Encode returns the meta-data file form of m.
+func (m *Meta) Encode() []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%s\nmode: %s\n", metaHeader, m.Mode)
+	for _, f := range m.Files {
+		fmt.Fprintf(&buf, "file %s\n", f.Name)
+		for _, b := range f.Blocks {
+			fmt.Fprintf(&buf, "block %d.%d,%d.%d %d\n", b.StartLine, b.StartCol, b.EndLine, b.EndCol, b.NumStmt)
+		}
+	}
+	return buf.Bytes()
+}
+
+// Hash returns the hash identifying m, as used in file names.
+func (m *Meta) Hash() string {
+	sum := sha256.Sum256(m.Encode())
+	return hex.EncodeToString(sum[:16])
+}
+
+// ParseMeta parses the content of a meta-data file.
+func ParseMeta(data []byte) (*Meta, error) {
+	s := newScanner(data)
+	if !s.next() || s.line != metaHeader {
+		return nil, fmt.Errorf("not a coverage meta-data file")
+	}
+	if !s.next() || !strings.HasPrefix(s.line, "mode: ") {
+		return nil, s.errorf("missing mode")
+	}
+	m := &Meta{Mode: strings.TrimPrefix(s.line, "mode: ")}
+	switch m.Mode {
+	case "set", "count", "atomic":
+	default:
+		return nil, s.errorf("unknown mode %q", m.Mode)
+	}
+	for s.next() {
+		switch {
+		case strings.HasPrefix(s.line, "file "):
+			m.Files = append(m.Files, FileMeta{Name: strings.TrimPrefix(s.line, "file ")})
+		case strings.HasPrefix(s.line, "block "):
+			if len(m.Files) == 0 {
+				return nil, s.errorf("block before first file")
+			}
+			b, err := parseBlock(strings.TrimPrefix(s.line, "block "))
+			if err != nil {
+				return nil, s.errorf("%v", err)
+			}
+			f := &m.Files[len(m.Files)-1]
+			f.Blocks = append(f.Blocks, b)
+		default:
+			return nil, s.errorf("unexpected line %q", s.line)
+		}
+	}
+	return m, s.err()
+}
+
+// parseBlock parses a block in the form "startLine.startCol,endLine.endCol numStmt".
+func parseBlock(s string) (Block, error) {
+	var b Block
+	i := strings.IndexByte(s, ' ')
+	if i < 0 {
+		return b, fmt.Errorf("malformed block %q", s)
+	}
+	n, err := strconv.ParseUint(s[i+1:], 10, 16)
+	if err != nil {
+		return b, fmt.Errorf("malformed block %q", s)
+	}
+	b.NumStmt = uint16(n)
+	start, end, ok := cut(s[:i], ",")
+	if !ok {
+		return b, fmt.Errorf("malformed block %q", s)
+	}
+	if b.StartLine, b.StartCol, err = parsePos(start); err != nil {
+		return b, fmt.Errorf("malformed block %q", s)
+	}
+	if b.EndLine, b.EndCol, err = parsePos(end); err != nil {
+		return b, fmt.Errorf("malformed block %q", s)
+	}
+	return b, nil
+}
+
+func parsePos(s string) (line, col uint32, err error) {
+	l, c, ok := cut(s, ".")
+	if !ok {
+		return 0, 0, fmt.Errorf("malformed position %q", s)
+	}
+	n, err := strconv.ParseUint(l, 10, 32)
+	if err != nil {
+		return 0, 0, err
+	}
+	m, err := strconv.ParseUint(c, 10, 32)
+	if err != nil {
+		return 0, 0, err
+	}
+	return uint32(n), uint32(m), nil
+}
+
+// Counters is the content of a counter data file.
+type Counters struct {
+	MetaHash string     // hash of the corresponding meta-data
+	Counts   [][]uint32 // Counts[i] holds the counters of Meta.Files[i]
+}
+
+// Encode returns the counter data file form of c.
+func (c *Counters) Encode() []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%s\nmeta: %s\n", counterHeader, c.MetaHash)
+	for _, counts := range c.Counts {
+		buf.WriteString("counts")
+		for _, n := range counts {
+			buf.WriteByte(' ')
+			buf.WriteString(strconv.FormatUint(uint64(n), 10))
+		}
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes()
+}
+
+// ParseCounters parses the content of a counter data file.
+func ParseCounters(data []byte) (*Counters, error) {
+	s := newScanner(data)
+	if !s.next() || s.line != counterHeader {
+		return nil, fmt.Errorf("not a coverage counter data file")
+	}
+	if !s.next() || !strings.HasPrefix(s.line, "meta: ") {
+		return nil, s.errorf("missing meta-data hash")
+	}
+	c := &Counters{MetaHash: strings.TrimPrefix(s.line, "meta: ")}
+	for s.next() {
+		fields := strings.Fields(s.line)
+		if len(fields) == 0 || fields[0] != "counts" {
+			return nil, s.errorf("unexpected line %q", s.line)
+		}
+		counts := make([]uint32, len(fields)-1)
+		for i, f := range fields[1:] {
+			n, err := strconv.ParseUint(f, 10, 32)
+			if err != nil {
+				return nil, s.errorf("malformed counter %q", f)
+			}
+			counts[i] = uint32(n)
+		}
+		c.Counts = append(c.Counts, counts)
+	}
+	return c, s.err()
+}
+
+// Check reports whether c has the shape described by m.
+func (c *Counters) Check(m *Meta) error {
+	if len(c.Counts) != len(m.Files) {
+		return fmt.Errorf("counter data has %d files, meta-data has %d", len(c.Counts), len(m.Files))
+	}
+	for i, f := range m.Files {
+		if len(c.Counts[i]) != len(f.Blocks) {
+			return fmt.Errorf("counter data for %s has %d blocks, meta-data has %d", f.Name, len(c.Counts[i]), len(f.Blocks))
+		}
+	}
+	return nil
+}
+
+// A scanner reads the lines of a coverage data file.
+type scanner struct {
+	s    *bufio.Scanner
+	n    int // line number
+	line string
+}
+
+func newScanner(data []byte) *scanner {
+	s := bufio.NewScanner(bytes.NewReader(data))
+	s.Buffer(nil, len(data)+1)
+	return &scanner{s: s}
+}
+
+func (s *scanner) next() bool {
+	if !s.s.Scan() {
+		return false
+	}
+	s.n++
+	s.line = s.s.Text()
+	return true
+}
+
+func (s *scanner) err() error {
+	return s.s.Err()
+}
+
+func (s *scanner) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("line %d: %s", s.n, fmt.Sprintf(format, args...))
+}
+
+func cut(s, sep string) (before, after string, found bool) {
+	if i := strings.Index(s, sep); i >= 0 {
+		return s[:i], s[i+len(sep):], true
+	}
+	return s, "", false
+}
@@ -0,0 +1,98 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package coverage
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var testMeta = &Meta{
+	Mode: "count",
+	Files: []FileMeta{
+		{
+			Name: "example.com/hello/hello.go",
+			Blocks: []Block{
+				{StartLine: 5, StartCol: 14, EndLine: 7, EndCol: 2, NumStmt: 1},
+				{StartLine: 9, StartCol: 13, EndLine: 11, EndCol: 2, NumStmt: 2},
+			},
+		},
+		{
+			Name: "example.com/hello/empty.go",
+		},
+	},
+}
+
+func TestMetaRoundTrip(t *testing.T) {
+	data := testMeta.Encode()
+	m, err := ParseMeta(data)
+	if err != nil {
+		t.Fatalf("ParseMeta: %v\n%s", err, data)
+	}
+	if !reflect.DeepEqual(m, testMeta) {
+		t.Errorf("ParseMeta(Encode(m)) = %+v, want %+v", m, testMeta)
+	}
+	if m.Hash() != testMeta.Hash() {
+		t.Errorf("hash changed after round trip")
+	}
+	if len(m.Hash()) != 32 {
+		t.Errorf("len(Hash()) = %d, want 32", len(m.Hash()))
+	}
+}
+
+func TestCountersRoundTrip(t *testing.T) {
+	c := &Counters{
+		MetaHash: testMeta.Hash(),
+		Counts:   [][]uint32{{3, 0}, {}},
+	}
+	data := c.Encode()
+	c2, err := ParseCounters(data)
+	if err != nil {
+		t.Fatalf("ParseCounters: %v\n%s", err, data)
+	}
+	if !reflect.DeepEqual(c2, c) {
+		t.Errorf("ParseCounters(Encode(c)) = %+v, want %+v", c2, c)
+	}
+	if err := c2.Check(testMeta); err != nil {
+		t.Errorf("Check: %v", err)
+	}
+	c2.Counts[0] = c2.Counts[0][:1]
+	if err := c2.Check(testMeta); err == nil {
+		t.Errorf("Check succeeded for counters of the wrong shape")
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, tt := range []struct {
+		data string
+		err  string
+	}{
+		{"", "not a coverage meta-data file"},
+		{"go coverage meta v1\n", "missing mode"},
+		{"go coverage meta v1\nmode: maybe\n", "unknown mode"},
+		{"go coverage meta v1\nmode: set\nblock 1.1,2.2 1\n", "block before first file"},
+		{"go coverage meta v1\nmode: set\nfile f.go\nblock 1.1 1\n", "malformed block"},
+		{"go coverage meta v1\nmode: set\nfile f.go\nstray\n", "unexpected line"},
+	} {
+		_, err := ParseMeta([]byte(tt.data))
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("ParseMeta(%q) = %v, want error containing %q", tt.data, err, tt.err)
+		}
+	}
+	for _, tt := range []struct {
+		data string
+		err  string
+	}{
+		{"go coverage meta v1\n", "not a coverage counter data file"},
+		{"go coverage counters v1\n", "missing meta-data hash"},
+		{"go coverage counters v1\nmeta: x\ncounts 1 x\n", "malformed counter"},
+	} {
+		_, err := ParseCounters([]byte(tt.data))
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("ParseCounters(%q) = %v, want error containing %q", tt.data, err, tt.err)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fuzz
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestMinimizeBytes(t *testing.T) {
+	tests := []struct {
+		name  string
+		input []byte
+		fails func([]byte) bool
+		want  []byte
+	}{
+		{
+			name:  "ones",
+			input: []byte{0, 1, 1, 1, 0, 1, 0, 1, 1},
+			fails: func(b []byte) bool {
+				return bytes.Count(b, []byte{1}) >= 3
+			},
+			want: []byte{1, 1, 1},
+		},
+		{
+			name:  "prefix",
+			input: []byte("xxxxfuzzxxxx"),
+			fails: func(b []byte) bool {
+				return bytes.Contains(b, []byte("fuzz"))
+			},
+			want: []byte("fuzz"),
+		},
+		{
+			name:  "length",
+			input: bytes.Repeat([]byte{'a'}, 5000),
+			fails: func(b []byte) bool {
+				return len(b) >= 100
+			},
+			// Bytes are also simplified to printable characters.
+			want: bytes.Repeat([]byte{'0'}, 100),
+		},
+		{
+			name:  "already minimal",
+			input: []byte{'x'},
+			fails: func(b []byte) bool {
+				return bytes.Equal(b, []byte{'x'})
+			},
+			want: []byte{'x'},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var got []byte
+			try := func(b []byte) bool {
+				if tc.fails(b) {
+					got = append(got[:0], b...)
+					return true
+				}
+				return false
+			}
+			shouldStop := func() bool { return false }
+			minimizeBytes(append([]byte(nil), tc.input...), try, shouldStop)
+			if got == nil {
+				got = tc.input
+			}
+			if !bytes.Equal(got, tc.want) {
+				t.Errorf("minimizeBytes(%q) = %q; want %q", tc.input, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestMinimizeBytesStop(t *testing.T) {
+	calls := 0
+	try := func(b []byte) bool {
+		calls++
+		return true
+	}
+	shouldStop := func() bool { return calls >= 3 }
+	minimizeBytes(bytes.Repeat([]byte{'a'}, 1000), try, shouldStop)
+	if calls != 3 {
+		t.Errorf("minimizeBytes made %d calls after being asked to stop; want 3", calls)
+	}
+}
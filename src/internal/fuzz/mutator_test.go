@@ -0,0 +1,84 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fuzz
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestMutateKeepsTypes(t *testing.T) {
+	m := newMutator()
+	vals := []interface{}{
+		[]byte("hello"),
+		"world",
+		int(1), int8(2), int16(3), int32(4), int64(5),
+		uint(1), uint8(2), uint16(3), uint32(4), uint64(5),
+		float32(1.5), float64(2.5),
+		true,
+	}
+	types := make([]reflect.Type, len(vals))
+	for i, v := range vals {
+		types[i] = reflect.TypeOf(v)
+	}
+	for i := 0; i < 1000; i++ {
+		m.mutate(vals, workerSharedMemSize)
+		for j, v := range vals {
+			if got := reflect.TypeOf(v); got != types[j] {
+				t.Fatalf("mutation changed type of value %d from %v to %v", j, types[j], got)
+			}
+		}
+	}
+}
+
+func TestMutateBytesMaxLen(t *testing.T) {
+	// mutate reserves 100 bytes of each value's share for the encoding.
+	const maxBytes, maxLen = 200, 100
+	m := newMutator()
+	vals := []interface{}{[]byte("abc")}
+	for i := 0; i < 10000; i++ {
+		m.mutate(vals, maxBytes)
+		if b := vals[0].([]byte); len(b) > maxLen {
+			t.Fatalf("mutated value has length %d; want at most %d", len(b), maxLen)
+		}
+	}
+}
+
+func TestByteSliceMutators(t *testing.T) {
+	m := newMutator()
+	for i, mutate := range byteSliceMutators {
+		b := make([]byte, 0, 64)
+		b = append(b, "abcdefghijklmnop"...)
+		for j := 0; j < 100; j++ {
+			mutated := mutate(m, b)
+			if mutated == nil {
+				continue
+			}
+			if len(mutated) > cap(b) || &mutated[:1][0] != &b[:1][0] {
+				t.Fatalf("byteSliceMutators[%d] reallocated the slice", i)
+			}
+			b = mutated
+			if len(b) == 0 {
+				b = append(b, 'a')
+			}
+		}
+	}
+}
+
+func BenchmarkMutatorBytes(b *testing.B) {
+	for _, size := range []int{1, 10, 100, 1000, 10000, 100000} {
+		b.Run(strconv.Itoa(size), func(b *testing.B) {
+			buf := make([]byte, size)
+			m := newMutator()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				// Resize the buffer to the original shape before each mutation.
+				buf = buf[0:size]
+				m.mutate([]interface{}{buf}, workerSharedMemSize)
+			}
+		})
+	}
+}
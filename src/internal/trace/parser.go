@@ -100,11 +100,11 @@ func Parse(r io.Reader, bin string) (ParseResult, error) {
 // parse parses, post-processes and verifies the trace. It returns the
 // trace version and the list of events.
 func parse(r io.Reader, bin string) (int, ParseResult, error) {
-	ver, rawEvents, strings, err := readTrace(r)
+	ver, parts, err := readTrace(r)
 	if err != nil {
 		return 0, ParseResult{}, err
 	}
-	events, stacks, err := parseEvents(ver, rawEvents, strings)
+	events, stacks, err := parsePartitions(ver, parts)
 	if err != nil {
 		return 0, ParseResult{}, err
 	}
@@ -135,9 +135,21 @@ type rawEvent struct {
 	sargs []string
 }
 
+// rawPartition is a helper type used during parsing.
+// It holds the raw events of a single trace partition.
+//
+// Starting with Go 1.16, a trace is a sequence of partitions. Each
+// partition starts with the trace header and has its own string and
+// stack tables, and every partition but the last one ends with
+// EvPartitionEnd. Earlier traces consist of a single partition.
+type rawPartition struct {
+	events  []rawEvent
+	strings map[uint64]string
+}
+
 // readTrace does wire-format parsing and verification.
 // It does not care about specific event types and argument meaning.
-func readTrace(r io.Reader) (ver int, events []rawEvent, strings map[uint64]string, err error) {
+func readTrace(r io.Reader) (ver int, parts []rawPartition, err error) {
 	// Read and validate trace header.
 	var buf [16]byte
 	off, err := io.ReadFull(r, buf[:])
@@ -150,7 +162,7 @@ func readTrace(r io.Reader) (ver int, events []rawEvent, strings map[uint64]stri
 		return
 	}
 	switch ver {
-	case 1005, 1007, 1008, 1009, 1010, 1011:
+	case 1005, 1007, 1008, 1009, 1010, 1011, 1016:
 		// Note: When adding a new version, add canned traces
 		// from the old version to the test suite using mkcanned.bash.
 		break
@@ -160,7 +172,8 @@ func readTrace(r io.Reader) (ver int, events []rawEvent, strings map[uint64]stri
 	}
 
 	// Read events.
-	strings = make(map[uint64]string)
+	var events []rawEvent
+	strings := make(map[uint64]string)
 	for {
 		// Read event type and number of arguments (1 byte).
 		off0 := off
@@ -266,7 +279,35 @@ func readTrace(r io.Reader) (ver int, events []rawEvent, strings map[uint64]stri
 			ev.sargs = append(ev.sargs, s)
 		}
 		events = append(events, ev)
+		if ev.typ == EvPartitionEnd {
+			// The partition is followed either by the end of
+			// the trace or by the header of the next partition.
+			parts = append(parts, rawPartition{events, strings})
+			events, strings = nil, make(map[uint64]string)
+			var n int
+			n, err = io.ReadFull(r, buf[:])
+			if err == io.EOF {
+				err = nil
+				return
+			}
+			if err != nil {
+				err = fmt.Errorf("failed to read partition header at offset 0x%x: read %v, err %v", off, n, err)
+				return
+			}
+			var ver1 int
+			ver1, err = parseHeader(buf[:])
+			if err != nil {
+				err = fmt.Errorf("bad partition header at offset 0x%x: %v", off, err)
+				return
+			}
+			if ver1 != ver {
+				err = fmt.Errorf("partition at offset 0x%x has version %v, want %v", off, ver1, ver)
+				return
+			}
+			off += n
+		}
 	}
+	parts = append(parts, rawPartition{events, strings})
 	return
 }
 
@@ -311,10 +352,141 @@ func parseHeader(buf []byte) (int, error) {
 	return ver, nil
 }
 
-// Parse events transforms raw events into events.
+// parsePartitions transforms the raw events of all partitions into events
+// and joins them into a single stream, as if the trace had been recorded
+// as one partition. Stack IDs are renumbered so that they are unique
+// across partitions.
+func parsePartitions(ver int, parts []rawPartition) (events []*Event, stacks map[uint64][]*Frame, err error) {
+	stacks = make(map[uint64][]*Frame)
+	var (
+		j         partitionJoiner
+		stkBase   uint64  // offset of the stack IDs of the current partition
+		lastSeq   uint64  // sequence number of the previous partition
+		lastTicks int64   // timestamp of the last event of the previous partition
+		baseTicks int64   // timestamp of the first event of the previous partition
+		baseTs    int64   // baseTicks in nanoseconds since the start of the trace
+		freq      float64 // nanoseconds per tick in the previous partition
+	)
+	for i, part := range parts {
+		if n := len(part.events); n > 0 && part.events[n-1].typ == EvPartitionEnd {
+			seq := part.events[n-1].args[0]
+			if i > 0 && lastSeq != 0 && seq != lastSeq+1 {
+				return nil, nil, fmt.Errorf("trace partition %v follows partition %v", seq, lastSeq)
+			}
+			lastSeq = seq
+		}
+		evs, stks, ticksPerSec, err := parseEvents(ver, part.events, part.strings)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		// Translate cpu ticks to real time. Each partition has its own
+		// tick frequency.
+		minTs := evs[0].Ts
+		if i > 0 {
+			if minTs < lastTicks {
+				return nil, nil, ErrTimeOrder
+			}
+			baseTs += int64(float64(minTs-baseTicks) * freq)
+		}
+		baseTicks = minTs
+		lastTicks = evs[len(evs)-1].Ts
+		// Use floating point to avoid integer overflows.
+		freq = 1e9 / float64(ticksPerSec)
+		for _, ev := range evs {
+			ev.Ts = baseTs + int64(float64(ev.Ts-minTs)*freq)
+		}
+
+		var maxID uint64
+		for id, stk := range stks {
+			stacks[stkBase+id] = stk
+			if id > maxID {
+				maxID = id
+			}
+		}
+		for _, ev := range evs {
+			if ev.StkID != 0 {
+				ev.StkID += stkBase
+				if ev.StkID > stkBase+maxID {
+					maxID = ev.StkID - stkBase
+				}
+			}
+			if ev.Type == EvGoCreate && ver >= 1007 && ev.Args[1] != 0 {
+				// The new goroutine's stack ID.
+				ev.Args[1] += stkBase
+				if ev.Args[1] > stkBase+maxID {
+					maxID = ev.Args[1] - stkBase
+				}
+			}
+		}
+		stkBase += maxID
+
+		events = j.join(events, evs, i == 0)
+	}
+	return events, stacks, nil
+}
+
+// partitionJoiner joins the events of consecutive trace partitions.
+//
+// Every partition starts with a snapshot of the state of all goroutines
+// and of the P that started the partition, which then starts running the
+// goroutine that ended the previous partition with EvGoSched. Except in the first partition
+// of a trace, the snapshot repeats what the previous partitions have
+// already established. partitionJoiner tracks enough state to recognize
+// such events and drops them.
+type partitionJoiner struct {
+	alive   map[uint64]bool // goroutines that have been created and have not ended
+	running map[int]bool    // Ps that have started and have not stopped
+}
+
+// join appends the events of a partition to events. first is whether
+// this is the first partition of the trace.
+func (j *partitionJoiner) join(events, part []*Event, first bool) []*Event {
+	if j.alive == nil {
+		j.alive = make(map[uint64]bool)
+		j.running = make(map[int]bool)
+	}
+	recreated := make(map[uint64]bool) // goroutines whose snapshot EvGoCreate was dropped
+	for _, ev := range part {
+		if !first {
+			// In a consistent trace, these events can only be
+			// part of the snapshot.
+			switch ev.Type {
+			case EvGoCreate:
+				if j.alive[ev.Args[0]] {
+					recreated[ev.Args[0]] = true
+					continue
+				}
+			case EvGoWaiting, EvGoInSyscall:
+				if recreated[ev.G] {
+					continue
+				}
+			case EvProcStart:
+				if j.running[ev.P] {
+					continue
+				}
+			}
+		}
+		switch ev.Type {
+		case EvGoCreate:
+			j.alive[ev.Args[0]] = true
+		case EvGoEnd:
+			delete(j.alive, ev.G)
+		case EvProcStart:
+			j.running[ev.P] = true
+		case EvProcStop:
+			j.running[ev.P] = false
+		}
+		events = append(events, ev)
+	}
+	return events
+}
+
+// Parse events transforms raw events of a single partition into events.
 // It does analyze and verify per-event-type arguments.
-func parseEvents(ver int, rawEvents []rawEvent, strings map[uint64]string) (events []*Event, stacks map[uint64][]*Frame, err error) {
-	var ticksPerSec, lastSeq, lastTs int64
+// Event timestamps are left in ticks, at the returned tick frequency.
+func parseEvents(ver int, rawEvents []rawEvent, strings map[uint64]string) (events []*Event, stacks map[uint64][]*Frame, ticksPerSec int64, err error) {
+	var lastSeq, lastTs int64
 	var lastG uint64
 	var lastP int
 	timerGoids := make(map[uint64]bool)
@@ -355,6 +527,8 @@ func parseEvents(ver int, rawEvents []rawEvent, strings map[uint64]string) (even
 			}
 		case EvTimerGoroutine:
 			timerGoids[raw.args[0]] = true
+		case EvPartitionEnd:
+			// Handled by parsePartitions.
 		case EvStack:
 			if len(raw.args) < 2 {
 				err = fmt.Errorf("EvStack has wrong number of arguments at offset 0x%x: want at least 2, got %v",
@@ -479,12 +653,7 @@ func parseEvents(ver int, rawEvents []rawEvent, strings map[uint64]string) (even
 		return
 	}
 
-	// Translate cpu ticks to real time.
-	minTs := events[0].Ts
-	// Use floating point to avoid integer overflows.
-	freq := 1e9 / float64(ticksPerSec)
 	for _, ev := range events {
-		ev.Ts = int64(float64(ev.Ts-minTs) * freq)
 		// Move timers and syscalls to separate fake Ps.
 		if timerGoids[ev.G] && ev.Type == EvGoUnblock {
 			ev.P = TimerP
@@ -976,7 +1145,7 @@ func argNum(raw rawEvent, ver int) int {
 		narg++
 	}
 	switch raw.typ {
-	case EvBatch, EvFrequency, EvTimerGoroutine:
+	case EvBatch, EvFrequency, EvTimerGoroutine, EvPartitionEnd:
 		if ver < 1007 {
 			narg++ // there was an unused arg before 1.7
 		}
@@ -1058,7 +1227,8 @@ const (
 	EvUserTaskEnd       = 46 // end of task [timestamp, internal task id, stack]
 	EvUserRegion        = 47 // trace.WithRegion [timestamp, internal task id, mode(0:start, 1:end), stack, name string]
 	EvUserLog           = 48 // trace.Log [timestamp, internal id, key string id, stack, value string]
-	EvCount             = 49
+	EvPartitionEnd      = 49 // end of a trace partition [partition sequence number]
+	EvCount             = 50
 )
 
 var EventDescriptions = [EvCount]struct {
@@ -1117,4 +1287,5 @@ var EventDescriptions = [EvCount]struct {
 	EvUserTaskEnd:       {"UserTaskEnd", 1011, true, []string{"taskid"}, nil},
 	EvUserRegion:        {"UserRegion", 1011, true, []string{"taskid", "mode", "typeid"}, []string{"name"}},
 	EvUserLog:           {"UserLog", 1011, true, []string{"id", "keyid"}, []string{"category", "message"}},
+	EvPartitionEnd:      {"PartitionEnd", 1016, false, []string{"seq"}, nil},
 }
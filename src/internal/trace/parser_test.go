@@ -109,3 +109,63 @@ func TestTimestampOverflow(t *testing.T) {
 		t.Fatalf("failed to parse: %v", err)
 	}
 }
+
+func TestParsePartitions(t *testing.T) {
+	const header = "go 1.16 trace\x00\x00\x00"
+
+	// Goroutine 1 runs on P 0 in both partitions, while goroutine 2 is
+	// unblocked in the first partition and runs in the second one.
+	// Both partitions start with a snapshot of the two goroutines.
+	w := new(Writer)
+	w.WriteString(header)
+	w.Emit(EvBatch, 0, 0)
+	w.Emit(EvGoCreate, 1, 1, 0, 0)
+	w.Emit(EvGoCreate, 1, 2, 0, 0)
+	w.Emit(EvGoWaiting, 1, 2)
+	w.Emit(EvProcStart, 1, 0)
+	w.Emit(EvGoStartLocal, 1, 1)
+	w.Emit(EvGoUnblock, 1, 2, 2, 0)
+	w.Emit(EvGoSched, 1, 0)
+	w.Emit(EvFrequency, 1e9)
+	w.Emit(EvPartitionEnd, 1)
+	first := w.Len()
+	w.WriteString(header)
+	w.Emit(EvBatch, 0, 100)
+	w.Emit(EvGoCreate, 1, 1, 0, 0)
+	w.Emit(EvGoCreate, 1, 2, 0, 0)
+	w.Emit(EvProcStart, 1, 0)
+	w.Emit(EvGoStartLocal, 1, 1)
+	w.Emit(EvGoEnd, 1)
+	w.Emit(EvGoStart, 1, 2, 1)
+	w.Emit(EvGoEnd, 1)
+	w.Emit(EvFrequency, 1e9)
+	data := w.Bytes()
+
+	res, err := Parse(bytes.NewReader(data), "")
+	if err != nil {
+		t.Fatalf("failed to parse trace: %v", err)
+	}
+	want := []byte{
+		EvGoCreate, EvGoCreate, EvGoWaiting, EvProcStart, EvGoStart, EvGoUnblock, EvGoSched,
+		EvGoStart, EvGoEnd, EvGoStart, EvGoEnd,
+	}
+	var got []byte
+	for _, ev := range res.Events {
+		got = append(got, ev.Type)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("got events %v, want %v", got, want)
+	}
+	if ts := res.Events[len(res.Events)-1].Ts; ts != 106 {
+		t.Errorf("last event at %vns, want 106ns", ts)
+	}
+
+	// The second partition is also a complete trace on its own.
+	res, err = Parse(bytes.NewReader(data[first:]), "")
+	if err != nil {
+		t.Fatalf("failed to parse second partition: %v", err)
+	}
+	if n := len(res.Events); n != 7 {
+		t.Errorf("got %d events in second partition, want 7", n)
+	}
+}
@@ -0,0 +1,70 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fs_test
+
+import (
+	. "io/fs"
+	"testing"
+)
+
+var isValidPathTests = []struct {
+	name string
+	ok   bool
+}{
+	{".", true},
+	{"x", true},
+	{"x/y", true},
+
+	{"", false},
+	{"..", false},
+	{"/", false},
+	{"x/", false},
+	{"/x", false},
+	{"x/y/", false},
+	{"/x/y", false},
+	{"./", false},
+	{"./x", false},
+	{"x/.", false},
+	{"x/./y", false},
+	{"../", false},
+	{"../x", false},
+	{"x/..", false},
+	{"x/../y", false},
+	{"x//y", false},
+	{`x\`, true},
+	{`x\y`, true},
+	{`x:y`, true},
+	{`\x`, true},
+	{"x\xff", false},
+}
+
+func TestValidPath(t *testing.T) {
+	for _, tt := range isValidPathTests {
+		ok := ValidPath(tt.name)
+		if ok != tt.ok {
+			t.Errorf("ValidPath(%q) = %v, want %v", tt.name, ok, tt.ok)
+		}
+	}
+}
+
+func TestFileModeString(t *testing.T) {
+	tests := []struct {
+		m    FileMode
+		want string
+	}{
+		{0, "----------"},
+		{0644, "-rw-r--r--"},
+		{ModeDir | 0755, "drwxr-xr-x"},
+		{ModeSymlink | ModeSetuid | 0700, "Lurwx------"},
+	}
+	for _, tt := range tests {
+		if got := tt.m.String(); got != tt.want {
+			t.Errorf("FileMode(%#o).String() = %q, want %q", uint32(tt.m), got, tt.want)
+		}
+	}
+	if got := (ModeDir | ModeSymlink | 0755).Type(); got != ModeDir|ModeSymlink {
+		t.Errorf("Type() = %v, want %v", got, ModeDir|ModeSymlink)
+	}
+}
@@ -0,0 +1,110 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fs_test
+
+import (
+	"errors"
+	. "io/fs"
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+var testFsys = fstest.MapFS{
+	"hello.txt": {
+		Data: []byte("hello, world"),
+		Mode: 0456,
+	},
+	"sub/a.txt": {Data: []byte("a")},
+	"sub/b.txt": {Data: []byte("b")},
+	"sub/c.go":  {Data: []byte("package c")},
+}
+
+// openOnly hides every method of the wrapped FS except Open,
+// forcing the helpers in package fs onto their generic paths.
+type openOnly struct{ FS }
+
+func TestReadFile(t *testing.T) {
+	for _, fsys := range []FS{testFsys, openOnly{testFsys}} {
+		data, err := ReadFile(fsys, "hello.txt")
+		if string(data) != "hello, world" || err != nil {
+			t.Errorf("ReadFile(%T, hello.txt) = %q, %v, want %q, nil", fsys, data, err, "hello, world")
+		}
+		if _, err := ReadFile(fsys, "missing.txt"); !errors.Is(err, ErrNotExist) {
+			t.Errorf("ReadFile(%T, missing.txt) error = %v, want ErrNotExist", fsys, err)
+		}
+	}
+}
+
+func TestStat(t *testing.T) {
+	for _, fsys := range []FS{testFsys, openOnly{testFsys}} {
+		info, err := Stat(fsys, "hello.txt")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Name() != "hello.txt" || info.Mode() != 0456 || info.Size() != 12 {
+			t.Errorf("Stat(%T, hello.txt) = %s %v %d, want hello.txt %v 12", fsys, info.Name(), info.Mode(), info.Size(), FileMode(0456))
+		}
+	}
+}
+
+func TestReadDir(t *testing.T) {
+	for _, fsys := range []FS{testFsys, openOnly{testFsys}} {
+		list, err := ReadDir(fsys, "sub")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var names []string
+		for _, d := range list {
+			names = append(names, d.Name())
+		}
+		if want := []string{"a.txt", "b.txt", "c.go"}; !reflect.DeepEqual(names, want) {
+			t.Errorf("ReadDir(%T, sub) = %q, want %q", fsys, names, want)
+		}
+	}
+}
+
+func TestGlob(t *testing.T) {
+	for _, fsys := range []FS{testFsys, openOnly{testFsys}} {
+		for _, tt := range []struct {
+			pattern string
+			want    []string
+		}{
+			{"hello.txt", []string{"hello.txt"}},
+			{"*.txt", []string{"hello.txt"}},
+			{"sub/*.txt", []string{"sub/a.txt", "sub/b.txt"}},
+			{"*/c.go", []string{"sub/c.go"}},
+			{"missing", nil},
+		} {
+			matches, err := Glob(fsys, tt.pattern)
+			if err != nil || !reflect.DeepEqual(matches, tt.want) {
+				t.Errorf("Glob(%T, %#q) = %q, %v, want %q, nil", fsys, tt.pattern, matches, err, tt.want)
+			}
+		}
+		if _, err := Glob(fsys, "["); err == nil {
+			t.Errorf("Glob(%T, %#q) succeeded, want error", fsys, "[")
+		}
+	}
+}
+
+func TestSub(t *testing.T) {
+	for _, fsys := range []FS{testFsys, openOnly{testFsys}} {
+		sub, err := Sub(fsys, "sub")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := fstest.TestFS(sub, "a.txt", "b.txt", "c.go"); err != nil {
+			t.Errorf("Sub(%T, sub): %v", fsys, err)
+		}
+		_, err = sub.Open("missing.txt")
+		var pe *PathError
+		if !errors.As(err, &pe) || pe.Path != "missing.txt" {
+			t.Errorf("Open(missing.txt) error = %v, want PathError for missing.txt", err)
+		}
+	}
+	if _, err := Sub(testFsys, "../x"); err == nil {
+		t.Errorf("Sub(../x) succeeded, want error")
+	}
+}
@@ -0,0 +1,97 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fs_test
+
+import (
+	"errors"
+	. "io/fs"
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+var walkTree = fstest.MapFS{
+	"a/x":         {Data: []byte("x")},
+	"a/y/z":       {Data: []byte("z")},
+	"b/empty":     {Mode: ModeDir},
+	"b/skip/s1":   {Data: []byte("s1")},
+	"b/skip/s2":   {Data: []byte("s2")},
+	"c":           {Data: []byte("c")},
+	"d/e/f/g/h/i": {Data: []byte("i")},
+}
+
+func TestWalkDir(t *testing.T) {
+	var got []string
+	err := WalkDir(walkTree, ".", func(path string, d DirEntry, err error) error {
+		if err != nil {
+			t.Fatalf("walk %s: %v", path, err)
+		}
+		got = append(got, path)
+		if path == "b/skip" {
+			return SkipDir
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		".", "a", "a/x", "a/y", "a/y/z",
+		"b", "b/empty", "b/skip",
+		"c",
+		"d", "d/e", "d/e/f", "d/e/f/g", "d/e/f/g/h", "d/e/f/g/h/i",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("WalkDir visited\n\t%q\nwant\n\t%q", got, want)
+	}
+}
+
+func TestWalkDirSkipFile(t *testing.T) {
+	// Returning SkipDir for a file skips the rest of its directory.
+	var got []string
+	err := WalkDir(walkTree, "b", func(path string, d DirEntry, err error) error {
+		got = append(got, path)
+		if path == "b/skip/s1" {
+			return SkipDir
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"b", "b/empty", "b/skip", "b/skip/s1"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("WalkDir visited %q, want %q", got, want)
+	}
+}
+
+func TestWalkDirErrors(t *testing.T) {
+	errStop := errors.New("stop")
+	n := 0
+	err := WalkDir(walkTree, ".", func(path string, d DirEntry, err error) error {
+		n++
+		if path == "a/y" {
+			return errStop
+		}
+		return nil
+	})
+	if err != errStop {
+		t.Errorf("WalkDir = %v, want %v", err, errStop)
+	}
+	if n != 4 {
+		t.Errorf("WalkDir called fn %d times before stopping, want 4", n)
+	}
+
+	// A missing root is reported to fn with a nil DirEntry.
+	err = WalkDir(walkTree, "missing", func(path string, d DirEntry, err error) error {
+		if d != nil {
+			t.Errorf("fn called with DirEntry %v for missing root", d)
+		}
+		return err
+	})
+	if !errors.Is(err, ErrNotExist) {
+		t.Errorf("WalkDir(missing) = %v, want ErrNotExist", err)
+	}
+}
@@ -13,6 +13,7 @@ package log
 import (
 	"fmt"
 	"io"
+	"log/internal"
 	"os"
 	"runtime"
 	"sync"
@@ -144,6 +145,15 @@ func (l *Logger) formatHeader(buf *[]byte, t time.Time, file string, line int) {
 // 如果 s 的末尾没有换行符，会在末尾添加换行符。
 // 参数 Calldepth 用于恢复 PC，出于一般性提供，然后目前在所有预定义的路径上它的值都为2。
 func (l *Logger) Output(calldepth int, s string) error {
+	calldepth++ // +1 for this frame.
+	return l.output(0, calldepth, func(b []byte) []byte { return append(b, s...) })
+}
+
+// output 可以使用 calldepth 或 pc 获取源代码的文件和行号。
+// pc 非零时使用 pc。
+//
+// appendOutput 负责将日志内容追加到 Logger 的缓冲区中。
+func (l *Logger) output(pc uintptr, calldepth int, appendOutput func([]byte) []byte) error {
 	now := time.Now() // get this early.
 	var file string
 	var line int
@@ -152,24 +162,40 @@ func (l *Logger) Output(calldepth int, s string) error {
 	if l.flag&(Lshortfile|Llongfile) != 0 {
 		// Release lock while getting caller info - it's expensive.
 		l.mu.Unlock()
-		var ok bool
-		_, file, line, ok = runtime.Caller(calldepth)
-		if !ok {
-			file = "???"
-			line = 0
+		if pc == 0 {
+			var ok bool
+			_, file, line, ok = runtime.Caller(calldepth)
+			if !ok {
+				file = "???"
+				line = 0
+			}
+		} else {
+			fs := runtime.CallersFrames([]uintptr{pc})
+			f, _ := fs.Next()
+			file = f.File
+			if file == "" {
+				file = "???"
+			}
+			line = f.Line
 		}
 		l.mu.Lock()
 	}
 	l.buf = l.buf[:0]
 	l.formatHeader(&l.buf, now, file, line)
-	l.buf = append(l.buf, s...)
-	if len(s) == 0 || s[len(s)-1] != '\n' {
+	l.buf = appendOutput(l.buf)
+	if len(l.buf) == 0 || l.buf[len(l.buf)-1] != '\n' {
 		l.buf = append(l.buf, '\n')
 	}
 	_, err := l.out.Write(l.buf)
 	return err
 }
 
+func init() {
+	internal.DefaultOutput = func(pc uintptr, data []byte) error {
+		return std.output(pc, 0, func(buf []byte) []byte { return append(buf, data...) })
+	}
+}
+
 // Printf 调用 l.Output 输出到 logger。
 // 以 fmt.Printf 的方式处理参数。
 func (l *Logger) Printf(format string, v ...interface{}) {
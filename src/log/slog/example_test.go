@@ -0,0 +1,105 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package slog_test
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"os"
+	"time"
+)
+
+func ExampleGroup() {
+	r, _ := http.NewRequest("GET", "localhost", nil)
+	// ...
+
+	logger := slog.New(
+		slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
+			ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+				if a.Key == slog.TimeKey && len(groups) == 0 {
+					return slog.Attr{}
+				}
+				return a
+			},
+		}),
+	)
+	logger.Info("finished",
+		slog.Group("req",
+			slog.String("method", r.Method),
+			slog.String("url", r.URL.String())),
+		slog.Int("status", http.StatusOK),
+		slog.Duration("duration", time.Second))
+
+	// Output:
+	// level=INFO msg=finished req.method=GET req.url=localhost status=200 duration=1s
+}
+
+func ExampleHandlerOptions_customLevels() {
+	// This example demonstrates using custom log levels and custom log level names.
+	// In addition to the default log levels, it introduces Trace (-8) and
+	// Fatal (12) levels.
+	const (
+		LevelTrace = slog.Level(-8)
+		LevelFatal = slog.Level(12)
+	)
+
+	th := slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
+		// Set a custom level to show all log output. The default value is
+		// LevelInfo, which would drop Debug and Trace logs.
+		Level: LevelTrace,
+
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			// Remove time from the output for predictable test output.
+			if a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+
+			// Customize the name of the level key and the output string,
+			// including custom level values.
+			if a.Key == slog.LevelKey {
+				// Rename the level key from "level" to "sev".
+				a.Key = "sev"
+
+				// Handle custom level values.
+				level := a.Value.Any().(slog.Level)
+
+				switch {
+				case level < slog.LevelDebug:
+					a.Value = slog.StringValue("TRACE")
+				case level < slog.LevelInfo:
+					a.Value = slog.StringValue("DEBUG")
+				case level < slog.LevelWarn:
+					a.Value = slog.StringValue("INFO")
+				case level < slog.LevelError:
+					a.Value = slog.StringValue("WARNING")
+				case level < LevelFatal:
+					a.Value = slog.StringValue("ERROR")
+				default:
+					a.Value = slog.StringValue("FATAL")
+				}
+			}
+
+			return a
+		},
+	})
+
+	logger := slog.New(th)
+	ctx := context.Background()
+	logger.Log(ctx, LevelTrace, "Trace message")
+	logger.Log(ctx, slog.LevelDebug, "Debug message")
+	logger.Log(ctx, slog.LevelInfo, "Info message")
+	logger.Log(ctx, slog.LevelWarn, "Warning message")
+	logger.Log(ctx, slog.LevelError, "Error message")
+	logger.Log(ctx, LevelFatal, "Fatal level")
+
+	// Output:
+	// {"sev":"TRACE","msg":"Trace message"}
+	// {"sev":"DEBUG","msg":"Debug message"}
+	// {"sev":"INFO","msg":"Info message"}
+	// {"sev":"WARNING","msg":"Warning message"}
+	// {"sev":"ERROR","msg":"Error message"}
+	// {"sev":"FATAL","msg":"Fatal level"}
+}
@@ -0,0 +1,32 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package buffer
+
+import "testing"
+
+func Test(t *testing.T) {
+	b := New()
+	defer b.Free()
+	b.WriteString("hello")
+	b.WriteByte(',')
+	b.Write([]byte(" world"))
+
+	got := b.String()
+	want := "hello, world"
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if b.Len() != len(want) {
+		t.Errorf("Len() = %d, want %d", b.Len(), len(want))
+	}
+	b.SetLen(5)
+	if got := b.String(); got != "hello" {
+		t.Errorf("after SetLen(5): got %q, want %q", got, "hello")
+	}
+	b.Reset()
+	if b.Len() != 0 {
+		t.Errorf("after Reset: Len() = %d, want 0", b.Len())
+	}
+}
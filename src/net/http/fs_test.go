@@ -26,6 +26,7 @@ import (
 	"runtime"
 	"strings"
 	"testing"
+	"testing/fstest"
 	"time"
 )
 
@@ -408,6 +409,45 @@ func TestFileServerImplicitLeadingSlash(t *testing.T) {
 	}
 }
 
+func TestFileServerFS(t *testing.T) {
+	defer afterTest(t)
+	fsys := fstest.MapFS{
+		"index.txt":     {Data: []byte("root")},
+		"sub/hello.txt": {Data: []byte("Hello world"), ModTime: time.Unix(1e9, 0)},
+	}
+	ts := httptest.NewServer(FileServer(FS(fsys)))
+	defer ts.Close()
+
+	get := func(path string) (*Response, string) {
+		res, err := Get(ts.URL + path)
+		if err != nil {
+			t.Fatalf("Get %s: %v", path, err)
+		}
+		b, err := ioutil.ReadAll(res.Body)
+		if err != nil {
+			t.Fatalf("ReadAll %s: %v", path, err)
+		}
+		res.Body.Close()
+		return res, string(b)
+	}
+	if _, s := get("/"); !strings.Contains(s, ">index.txt<") || !strings.Contains(s, ">sub/<") {
+		t.Errorf("root directory listing = %q, want index.txt and sub/", s)
+	}
+	res, s := get("/sub/hello.txt")
+	if s != "Hello world" {
+		t.Errorf("body = %q, want %q", s, "Hello world")
+	}
+	if got, want := res.Header.Get("Last-Modified"), time.Unix(1e9, 0).UTC().Format(TimeFormat); got != want {
+		t.Errorf("Last-Modified = %q, want %q", got, want)
+	}
+	if res, _ := get("/missing.txt"); res.StatusCode != StatusNotFound {
+		t.Errorf("missing file status = %d, want %d", res.StatusCode, StatusNotFound)
+	}
+	if res, _ := get("/sub"); res.Request.URL.Path != "/sub/" {
+		t.Errorf("GET /sub ended at %q, want redirect to /sub/", res.Request.URL.Path)
+	}
+}
+
 func TestDirJoin(t *testing.T) {
 	if runtime.GOOS == "windows" {
 		t.Skip("skipping test on windows")
@@ -0,0 +1,503 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Load-balanced pool of upstream servers for ReverseProxy.
+
+package httputil
+
+import (
+	"context"
+	"errors"
+	"hash/fnv"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrNoHealthyUpstream is returned by Pool.RoundTrip when every
+// upstream in the pool is unhealthy or has already been tried.
+var ErrNoHealthyUpstream = errors.New("httputil: no healthy upstream")
+
+// ErrNoUpstreams is returned by Pool.RoundTrip when the pool has no
+// upstreams at all, such as a Pool that was not created by NewPool.
+var ErrNoUpstreams = errors.New("httputil: pool has no upstreams")
+
+const (
+	defaultMaxRetries  = 2
+	defaultMaxFails    = 3
+	defaultFailTimeout = 10 * time.Second
+)
+
+// A Pool is an http.RoundTripper that distributes requests among a
+// set of upstream servers. It is intended to be used as the Transport
+// of a ReverseProxy:
+//
+//	pool := httputil.NewPool(backend1, backend2, backend3)
+//	proxy := &httputil.ReverseProxy{
+//		Rewrite: func(r *httputil.ProxyRequest) {
+//			r.SetXForwarded()
+//		},
+//		Transport: pool,
+//	}
+//
+// For each request, the Pool's Balancer picks one of the healthy
+// upstreams and the request URL is routed to it as by ProxyRequest.SetURL,
+// except that the Host header is left unchanged.
+//
+// An upstream is considered unhealthy if it has failed MaxFails
+// consecutive requests (passive health checking), in which case it is
+// not used again until FailTimeout has elapsed, or if it has failed its
+// most recent active health check (see RunHealthChecks).
+//
+// If sending a request to an upstream fails before any byte of the
+// response has been received, and the request is idempotent and its
+// body can be replayed, the Pool retries the request on a different
+// upstream.
+//
+// A Pool must be created with NewPool, which sets its upstreams; the
+// zero Pool has none and fails every request with ErrNoUpstreams.
+// A Pool's fields should not be changed after its first use.
+// A Pool is safe for concurrent use by multiple goroutines.
+type Pool struct {
+	// Balancer chooses the upstream for each request.
+	// If nil, RoundRobin is used.
+	Balancer Balancer
+
+	// Transport is used to send requests to the upstreams,
+	// including active health checks.
+	// If nil, http.DefaultTransport is used.
+	Transport http.RoundTripper
+
+	// MaxRetries is the maximum number of times a failed request
+	// is retried on another upstream.
+	// If zero, a default of 2 is used.
+	// If negative, requests are never retried.
+	MaxRetries int
+
+	// MaxFails is the number of consecutive failed requests
+	// after which an upstream is taken out of rotation.
+	// If zero, a default of 3 is used.
+	// If negative, upstreams are never taken out of rotation
+	// because of failed requests.
+	MaxFails int
+
+	// FailTimeout is how long an upstream that reached MaxFails
+	// stays out of rotation before it is tried again.
+	// If zero, a default of 10 seconds is used.
+	FailTimeout time.Duration
+
+	// HealthCheck optionally configures active health checking
+	// performed by RunHealthChecks.
+	HealthCheck *HealthCheck
+
+	upstreams []*Upstream
+	rr        roundRobin // used if Balancer is nil
+}
+
+// NewPool returns a new Pool that distributes requests among the
+// given targets. The target URLs are interpreted as by ProxyRequest.SetURL.
+func NewPool(targets ...*url.URL) *Pool {
+	p := &Pool{}
+	for _, t := range targets {
+		p.upstreams = append(p.upstreams, &Upstream{URL: t})
+	}
+	return p
+}
+
+// Upstreams returns the upstreams of the pool, in the order
+// they were passed to NewPool.
+func (p *Pool) Upstreams() []*Upstream {
+	return append([]*Upstream(nil), p.upstreams...)
+}
+
+// An Upstream is a server in a Pool.
+type Upstream struct {
+	// URL is the target URL of the upstream.
+	URL *url.URL
+
+	outstanding int64 // accessed atomically
+
+	mu           sync.Mutex
+	fails        int       // consecutive failed requests
+	ejectedUntil time.Time // out of rotation until this time
+	checkFailed  bool      // whether the last active health check failed
+}
+
+// Outstanding returns the number of requests currently being handled
+// by the upstream, counting a request as outstanding until its
+// response body is closed.
+func (u *Upstream) Outstanding() int {
+	return int(atomic.LoadInt64(&u.outstanding))
+}
+
+// Healthy reports whether the upstream is currently in rotation.
+func (u *Upstream) Healthy() bool {
+	return u.healthy(time.Now())
+}
+
+func (u *Upstream) healthy(now time.Time) bool {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return !u.checkFailed && !now.Before(u.ejectedUntil)
+}
+
+func (u *Upstream) recordSuccess() {
+	u.mu.Lock()
+	u.fails = 0
+	u.mu.Unlock()
+}
+
+func (u *Upstream) recordFailure(maxFails int, failTimeout time.Duration) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.fails++
+	if maxFails > 0 && u.fails >= maxFails {
+		u.ejectedUntil = time.Now().Add(failTimeout)
+		u.fails = 0
+	}
+}
+
+func (u *Upstream) setCheckResult(healthy bool) {
+	u.mu.Lock()
+	u.checkFailed = !healthy
+	u.mu.Unlock()
+}
+
+// A Balancer chooses an upstream for a request.
+type Balancer interface {
+	// Pick returns one of the given upstreams, which are all
+	// healthy and non-empty, to serve req.
+	// Pick must be safe for concurrent use.
+	Pick(req *http.Request, upstreams []*Upstream) *Upstream
+}
+
+// RoundRobin returns a Balancer that picks upstreams in turn.
+func RoundRobin() Balancer {
+	return new(roundRobin)
+}
+
+type roundRobin struct {
+	next uint32 // accessed atomically
+}
+
+func (b *roundRobin) Pick(req *http.Request, upstreams []*Upstream) *Upstream {
+	n := atomic.AddUint32(&b.next, 1) - 1
+	return upstreams[n%uint32(len(upstreams))]
+}
+
+// LeastOutstanding returns a Balancer that picks the upstream with
+// the fewest outstanding requests, breaking ties in turn.
+func LeastOutstanding() Balancer {
+	return new(leastOutstanding)
+}
+
+type leastOutstanding struct {
+	next uint32 // accessed atomically
+}
+
+func (b *leastOutstanding) Pick(req *http.Request, upstreams []*Upstream) *Upstream {
+	start := int(atomic.AddUint32(&b.next, 1) - 1)
+	var best *Upstream
+	for i := range upstreams {
+		u := upstreams[(start+i)%len(upstreams)]
+		if best == nil || u.Outstanding() < best.Outstanding() {
+			best = u
+		}
+	}
+	return best
+}
+
+// ConsistentHash returns a Balancer that sends all requests with the
+// same key to the same upstream for as long as it is healthy. When an
+// upstream becomes unhealthy, only the keys mapped to it move to other
+// upstreams, and they move back once it is healthy again.
+//
+// The key function must be safe for concurrent use.
+func ConsistentHash(key func(*http.Request) string) Balancer {
+	return consistentHash{key}
+}
+
+type consistentHash struct {
+	key func(*http.Request) string
+}
+
+// Pick uses rendezvous (highest random weight) hashing: each upstream
+// is scored by hashing it together with the key, and the highest
+// score wins.
+func (b consistentHash) Pick(req *http.Request, upstreams []*Upstream) *Upstream {
+	key := b.key(req)
+	var best *Upstream
+	var bestScore uint64
+	for _, u := range upstreams {
+		h := fnv.New64a()
+		io.WriteString(h, u.URL.String())
+		h.Write([]byte{0})
+		io.WriteString(h, key)
+		if score := mix64(h.Sum64()); best == nil || score > bestScore {
+			best, bestScore = u, score
+		}
+	}
+	return best
+}
+
+// mix64 scrambles the bits of x so that inputs differing only in their
+// last bytes, such as upstream URLs with nearby ports, get unrelated scores.
+// It is the finalizer of the SplitMix64 generator.
+func mix64(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
+
+func (p *Pool) transport() http.RoundTripper {
+	if p.Transport != nil {
+		return p.Transport
+	}
+	return http.DefaultTransport
+}
+
+func (p *Pool) balancer() Balancer {
+	if p.Balancer != nil {
+		return p.Balancer
+	}
+	return &p.rr
+}
+
+func (p *Pool) maxRetries() int {
+	switch {
+	case p.MaxRetries < 0:
+		return 0
+	case p.MaxRetries == 0:
+		return defaultMaxRetries
+	}
+	return p.MaxRetries
+}
+
+func (p *Pool) maxFails() int {
+	if p.MaxFails == 0 {
+		return defaultMaxFails
+	}
+	return p.MaxFails
+}
+
+func (p *Pool) failTimeout() time.Duration {
+	if p.FailTimeout == 0 {
+		return defaultFailTimeout
+	}
+	return p.FailTimeout
+}
+
+// pick returns a healthy upstream for req that is not in tried,
+// or nil if there is none.
+func (p *Pool) pick(req *http.Request, tried []*Upstream) *Upstream {
+	now := time.Now()
+	avail := make([]*Upstream, 0, len(p.upstreams))
+	for _, u := range p.upstreams {
+		if u.healthy(now) && !containsUpstream(tried, u) {
+			avail = append(avail, u)
+		}
+	}
+	if len(avail) == 0 {
+		return nil
+	}
+	return p.balancer().Pick(req, avail)
+}
+
+func containsUpstream(us []*Upstream, u *Upstream) bool {
+	for _, v := range us {
+		if v == u {
+			return true
+		}
+	}
+	return false
+}
+
+// RoundTrip implements the http.RoundTripper interface.
+func (p *Pool) RoundTrip(req *http.Request) (*http.Response, error) {
+	if len(p.upstreams) == 0 {
+		return nil, ErrNoUpstreams
+	}
+	var tried []*Upstream
+	var lastErr error
+	for attempt := 0; ; attempt++ {
+		u := p.pick(req, tried)
+		if u == nil {
+			if lastErr != nil {
+				return nil, lastErr
+			}
+			return nil, ErrNoHealthyUpstream
+		}
+		res, gotByte, err := p.roundTrip(u, req, attempt)
+		if err == nil {
+			u.recordSuccess()
+			return res, nil
+		}
+		if req.Context().Err() != nil {
+			// The client went away; that says nothing about the upstream.
+			return nil, err
+		}
+		u.recordFailure(p.maxFails(), p.failTimeout())
+		if gotByte || attempt >= p.maxRetries() || !canRetryRequest(req) {
+			return nil, err
+		}
+		tried = append(tried, u)
+		lastErr = err
+	}
+}
+
+// roundTrip sends req to u. It reports whether any byte of the
+// response was received, in which case the request must not be retried.
+func (p *Pool) roundTrip(u *Upstream, req *http.Request, attempt int) (res *http.Response, gotByte bool, err error) {
+	var gotFirstByte int32
+	trace := &httptrace.ClientTrace{
+		GotFirstResponseByte: func() { atomic.StoreInt32(&gotFirstByte, 1) },
+	}
+	outreq := req.Clone(httptrace.WithClientTrace(req.Context(), trace))
+	rewriteRequestURL(outreq, u.URL)
+	if attempt > 0 && req.Body != nil && req.Body != http.NoBody {
+		if outreq.Body, err = req.GetBody(); err != nil {
+			return nil, false, err
+		}
+	}
+
+	atomic.AddInt64(&u.outstanding, 1)
+	res, err = p.transport().RoundTrip(outreq)
+	if err != nil {
+		atomic.AddInt64(&u.outstanding, -1)
+		return nil, atomic.LoadInt32(&gotFirstByte) != 0, err
+	}
+	res.Body = &upstreamBody{ReadCloser: res.Body, u: u}
+	return res, true, nil
+}
+
+// canRetryRequest reports whether req may be sent again after a
+// failure: it must be idempotent and its body must be replayable.
+func canRetryRequest(req *http.Request) bool {
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return false
+	}
+	switch req.Method {
+	case "GET", "HEAD", "OPTIONS", "TRACE", "PUT", "DELETE":
+		return true
+	}
+	// The Idempotency-Key, while non-standard, is widely used to
+	// mean a POST or other request is idempotent. See
+	// https://golang.org/issue/19943#issuecomment-421092421
+	if _, ok := req.Header["Idempotency-Key"]; ok {
+		return true
+	}
+	if _, ok := req.Header["X-Idempotency-Key"]; ok {
+		return true
+	}
+	return false
+}
+
+// upstreamBody is a response body that counts its request as
+// outstanding on an upstream until it is closed.
+type upstreamBody struct {
+	io.ReadCloser
+	u    *Upstream
+	once sync.Once
+}
+
+func (b *upstreamBody) Close() error {
+	b.once.Do(func() { atomic.AddInt64(&b.u.outstanding, -1) })
+	return b.ReadCloser.Close()
+}
+
+// HealthCheck configures active health checking for a Pool.
+type HealthCheck struct {
+	// Path is the path requested from each upstream, resolved
+	// against the upstream URL as by ProxyRequest.SetURL.
+	Path string
+
+	// Interval is the time between health checks.
+	// If zero, a default of 10 seconds is used.
+	Interval time.Duration
+
+	// Timeout bounds each health check request.
+	// If zero, a default of 5 seconds is used.
+	Timeout time.Duration
+
+	// Healthy optionally reports whether a health check response
+	// indicates a healthy upstream. If nil, an upstream is healthy
+	// if it responds with a 2xx status code.
+	Healthy func(*http.Response) bool
+}
+
+// RunHealthChecks checks the health of every upstream in the pool
+// according to p.HealthCheck, and then again every HealthCheck.Interval,
+// until ctx is done. An upstream that fails a check is taken out of
+// rotation until it passes one.
+//
+// RunHealthChecks returns immediately if p.HealthCheck is nil.
+// It is typically run in its own goroutine.
+func (p *Pool) RunHealthChecks(ctx context.Context) {
+	hc := p.HealthCheck
+	if hc == nil {
+		return
+	}
+	interval := hc.Interval
+	if interval == 0 {
+		interval = 10 * time.Second
+	}
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		p.checkHealth(ctx, hc)
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// checkHealth runs one round of health checks on all upstreams concurrently.
+func (p *Pool) checkHealth(ctx context.Context, hc *HealthCheck) {
+	var wg sync.WaitGroup
+	for _, u := range p.upstreams {
+		wg.Add(1)
+		go func(u *Upstream) {
+			defer wg.Done()
+			healthy := p.checkUpstream(ctx, hc, u)
+			if ctx.Err() == nil {
+				u.setCheckResult(healthy)
+			}
+		}(u)
+	}
+	wg.Wait()
+}
+
+func (p *Pool) checkUpstream(ctx context.Context, hc *HealthCheck, u *Upstream) bool {
+	timeout := hc.Timeout
+	if timeout == 0 {
+		timeout = 5 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, "GET", "/", nil)
+	if err != nil {
+		return false
+	}
+	req.URL.Path = hc.Path
+	rewriteRequestURL(req, u.URL)
+	req.Host = ""
+	res, err := p.transport().RoundTrip(req)
+	if err != nil {
+		return false
+	}
+	defer res.Body.Close()
+	if hc.Healthy != nil {
+		return hc.Healthy(res)
+	}
+	return res.StatusCode >= 200 && res.StatusCode < 300
+}
@@ -0,0 +1,319 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Upstream pool tests.
+
+package httputil
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newPoolBackends starts n backends that respond with their index
+// and returns them along with their URLs.
+func newPoolBackends(t *testing.T, n int) ([]*httptest.Server, []*url.URL) {
+	var servers []*httptest.Server
+	var urls []*url.URL
+	for i := 0; i < n; i++ {
+		i := i
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/health" {
+				w.WriteHeader(http.StatusOK)
+				return
+			}
+			fmt.Fprint(w, i)
+		}))
+		u, err := url.Parse(ts.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		servers = append(servers, ts)
+		urls = append(urls, u)
+	}
+	return servers, urls
+}
+
+func closeServers(servers []*httptest.Server) {
+	for _, ts := range servers {
+		ts.Close()
+	}
+}
+
+func poolGet(t *testing.T, p *Pool, method, path string) (string, error) {
+	t.Helper()
+	req, err := http.NewRequest(method, "http://frontend.tld"+path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := p.RoundTrip(req)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+	b, err := ioutil.ReadAll(res.Body)
+	return string(b), err
+}
+
+func TestPoolRoundRobin(t *testing.T) {
+	servers, urls := newPoolBackends(t, 3)
+	defer closeServers(servers)
+	p := NewPool(urls...)
+
+	var got []string
+	for i := 0; i < 6; i++ {
+		body, err := poolGet(t, p, "GET", "/")
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, body)
+	}
+	if got, want := strings.Join(got, ""), "012012"; got != want {
+		t.Errorf("round robin order = %q, want %q", got, want)
+	}
+}
+
+func TestPoolLeastOutstanding(t *testing.T) {
+	us := []*Upstream{{}, {}, {}}
+	us[0].outstanding = 3
+	us[1].outstanding = 1
+	us[2].outstanding = 2
+	b := LeastOutstanding()
+	for i := 0; i < 3; i++ {
+		if got := b.Pick(nil, us); got != us[1] {
+			t.Fatalf("Pick chose upstream with %d outstanding, want 1", got.Outstanding())
+		}
+	}
+
+	// Ties are broken in turn.
+	us[0].outstanding, us[1].outstanding, us[2].outstanding = 0, 0, 0
+	seen := map[*Upstream]bool{}
+	for i := 0; i < 3; i++ {
+		seen[b.Pick(nil, us)] = true
+	}
+	if len(seen) != 3 {
+		t.Errorf("Pick with equal load chose %d distinct upstreams, want 3", len(seen))
+	}
+}
+
+func TestPoolOutstandingUntilBodyClosed(t *testing.T) {
+	servers, urls := newPoolBackends(t, 1)
+	defer closeServers(servers)
+	p := NewPool(urls...)
+	u := p.Upstreams()[0]
+
+	req, _ := http.NewRequest("GET", "http://frontend.tld/", nil)
+	res, err := p.RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := u.Outstanding(); got != 1 {
+		t.Errorf("Outstanding() before Close = %d, want 1", got)
+	}
+	res.Body.Close()
+	res.Body.Close()
+	if got := u.Outstanding(); got != 0 {
+		t.Errorf("Outstanding() after Close = %d, want 0", got)
+	}
+}
+
+func TestPoolConsistentHash(t *testing.T) {
+	var us []*Upstream
+	for i := 0; i < 5; i++ {
+		us = append(us, &Upstream{URL: &url.URL{Scheme: "http", Host: fmt.Sprintf("10.0.0.%d:80", i)}})
+	}
+	b := ConsistentHash(func(r *http.Request) string { return r.Header.Get("User") })
+	req := func(user string) *http.Request {
+		return &http.Request{Header: http.Header{"User": {user}}}
+	}
+
+	assigned := map[string]*Upstream{}
+	used := map[*Upstream]bool{}
+	for i := 0; i < 100; i++ {
+		user := fmt.Sprint("user", i)
+		u := b.Pick(req(user), us)
+		assigned[user] = u
+		used[u] = true
+		if again := b.Pick(req(user), us); again != u {
+			t.Fatalf("key %q mapped to two different upstreams", user)
+		}
+	}
+	if len(used) != len(us) {
+		t.Errorf("100 keys used %d of %d upstreams", len(used), len(us))
+	}
+
+	// Removing an upstream only moves the keys that were on it.
+	removed := us[2]
+	rest := append(append([]*Upstream(nil), us[:2]...), us[3:]...)
+	for user, u := range assigned {
+		got := b.Pick(req(user), rest)
+		if u != removed && got != u {
+			t.Errorf("key %q moved from %v to %v after removing %v", user, u.URL, got.URL, removed.URL)
+		}
+	}
+}
+
+func TestPoolRetryConnectionFailure(t *testing.T) {
+	servers, urls := newPoolBackends(t, 2)
+	defer closeServers(servers)
+	servers[0].Close() // connections to upstream 0 are refused
+
+	p := NewPool(urls...)
+	p.MaxFails = 2
+	p.FailTimeout = time.Hour
+	for i := 0; i < 4; i++ {
+		body, err := poolGet(t, p, "GET", "/")
+		if err != nil {
+			t.Fatalf("GET #%d: %v", i, err)
+		}
+		if body != "1" {
+			t.Errorf("GET #%d served by upstream %q, want 1", i, body)
+		}
+	}
+	if p.Upstreams()[0].Healthy() {
+		t.Errorf("upstream 0 still healthy after repeated failures")
+	}
+	if !p.Upstreams()[1].Healthy() {
+		t.Errorf("upstream 1 not healthy")
+	}
+}
+
+func TestPoolNoRetryNonIdempotent(t *testing.T) {
+	servers, urls := newPoolBackends(t, 2)
+	defer closeServers(servers)
+	servers[0].Close()
+
+	p := NewPool(urls...)
+	req, _ := http.NewRequest("POST", "http://frontend.tld/", io.MultiReader(strings.NewReader("body")))
+	if _, err := p.RoundTrip(req); err == nil {
+		t.Errorf("POST to failing upstream succeeded; want error without retry")
+	}
+
+	// With an idempotency key and a replayable body, POST is retried.
+	req, _ = http.NewRequest("POST", "http://frontend.tld/", strings.NewReader("body"))
+	req.Header.Set("Idempotency-Key", "1")
+	res, err := p.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("POST with Idempotency-Key: %v", err)
+	}
+	res.Body.Close()
+}
+
+func TestPoolNoRetryAfterResponseByte(t *testing.T) {
+	var hits int32
+	broken := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		conn, bufrw, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer conn.Close()
+		bufrw.WriteString("HTTP/1.1 200 OK\r\nContent-Le")
+		bufrw.Flush()
+	}))
+	defer broken.Close()
+	u, _ := url.Parse(broken.URL)
+
+	p := NewPool(u, u, u)
+	if _, err := poolGet(t, p, "GET", "/"); err == nil {
+		t.Fatalf("GET succeeded; want error")
+	}
+	if got := atomic.LoadInt32(&hits); got != 1 {
+		t.Errorf("backend hit %d times, want 1 (no retry after a response byte)", got)
+	}
+}
+
+func TestPoolNoHealthyUpstream(t *testing.T) {
+	p := NewPool(&url.URL{Scheme: "http", Host: "127.0.0.1:1"})
+	p.Upstreams()[0].setCheckResult(false)
+	if _, err := poolGet(t, p, "GET", "/"); err != ErrNoHealthyUpstream {
+		t.Errorf("got error %v, want ErrNoHealthyUpstream", err)
+	}
+}
+
+func TestPoolNoUpstreams(t *testing.T) {
+	for _, p := range []*Pool{NewPool(), {Balancer: LeastOutstanding()}} {
+		if _, err := poolGet(t, p, "GET", "/"); err != ErrNoUpstreams {
+			t.Errorf("got error %v, want ErrNoUpstreams", err)
+		}
+	}
+}
+
+func TestPoolActiveHealthCheck(t *testing.T) {
+	var sick int32
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/base/healthz" {
+			t.Errorf("health check requested %q, want /base/healthz", r.URL.Path)
+		}
+		if atomic.LoadInt32(&sick) != 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer backend.Close()
+	u, _ := url.Parse(backend.URL + "/base")
+
+	p := NewPool(u)
+	hc := &HealthCheck{Path: "/healthz"}
+	p.HealthCheck = hc
+	up := p.Upstreams()[0]
+
+	atomic.StoreInt32(&sick, 1)
+	p.checkHealth(context.Background(), hc)
+	if up.Healthy() {
+		t.Errorf("upstream healthy after failed check")
+	}
+	atomic.StoreInt32(&sick, 0)
+	p.checkHealth(context.Background(), hc)
+	if !up.Healthy() {
+		t.Errorf("upstream unhealthy after passed check")
+	}
+
+	// RunHealthChecks returns once its context is done.
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		p.RunHealthChecks(ctx)
+		close(done)
+	}()
+	cancel()
+	<-done
+}
+
+func TestPoolReverseProxy(t *testing.T) {
+	servers, urls := newPoolBackends(t, 2)
+	defer closeServers(servers)
+	pool := NewPool(urls...)
+	proxy := &ReverseProxy{
+		Rewrite: func(r *ProxyRequest) {
+			r.SetXForwarded()
+		},
+		Transport: pool,
+	}
+	frontend := httptest.NewServer(proxy)
+	defer frontend.Close()
+
+	var got []string
+	for i := 0; i < 4; i++ {
+		res, err := frontend.Client().Get(frontend.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, _ := ioutil.ReadAll(res.Body)
+		res.Body.Close()
+		got = append(got, string(b))
+	}
+	if got, want := strings.Join(got, ""), "0101"; got != want {
+		t.Errorf("responses = %q, want %q", got, want)
+	}
+}
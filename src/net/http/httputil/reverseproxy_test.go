@@ -10,6 +10,7 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"crypto/tls"
 	"errors"
 	"fmt"
 	"io"
@@ -310,6 +311,184 @@ func TestXForwardedFor_Omit(t *testing.T) {
 	res.Body.Close()
 }
 
+func TestReverseProxyRewriteStripsForwarded(t *testing.T) {
+	headers := []string{
+		"Forwarded",
+		"X-Forwarded-For",
+		"X-Forwarded-Host",
+		"X-Forwarded-Proto",
+	}
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for _, h := range headers {
+			if v := r.Header.Get(h); v != "" {
+				t.Errorf("got %v header: %q", h, v)
+			}
+		}
+	}))
+	defer backend.Close()
+	backendURL, err := url.Parse(backend.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	proxyHandler := &ReverseProxy{
+		Rewrite: func(r *ProxyRequest) {
+			r.SetURL(backendURL)
+		},
+	}
+	frontend := httptest.NewServer(proxyHandler)
+	defer frontend.Close()
+
+	getReq, _ := http.NewRequest("GET", frontend.URL, nil)
+	getReq.Host = "some-name"
+	getReq.Close = true
+	for _, h := range headers {
+		getReq.Header.Set(h, "x")
+	}
+	res, err := frontend.Client().Do(getReq)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	res.Body.Close()
+}
+
+// The Rewrite hook runs after hop-by-hop headers are removed, so a
+// client cannot strip headers it sets by naming them in Connection.
+func TestReverseProxyRewriteKeepsHeadersNamedInConnection(t *testing.T) {
+	const header = "X-Proxy-Secret"
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got, want := r.Header.Get(header), "set by proxy"; got != want {
+			t.Errorf("backend got %v header %q, want %q", header, got, want)
+		}
+	}))
+	defer backend.Close()
+	backendURL, err := url.Parse(backend.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	proxyHandler := &ReverseProxy{
+		Rewrite: func(r *ProxyRequest) {
+			r.SetURL(backendURL)
+			r.Out.Header.Set(header, "set by proxy")
+		},
+	}
+	frontend := httptest.NewServer(proxyHandler)
+	defer frontend.Close()
+
+	getReq, _ := http.NewRequest("GET", frontend.URL, nil)
+	getReq.Header.Set("Connection", header)
+	res, err := frontend.Client().Do(getReq)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	res.Body.Close()
+}
+
+func TestReverseProxyDirectorAndRewrite(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		p    *ReverseProxy
+	}{
+		{"neither", &ReverseProxy{}},
+		{"both", &ReverseProxy{
+			Director: func(*http.Request) {},
+			Rewrite:  func(*ProxyRequest) {},
+		}},
+	} {
+		var gotErr error
+		test.p.ErrorHandler = func(w http.ResponseWriter, r *http.Request, err error) {
+			gotErr = err
+			w.WriteHeader(http.StatusBadGateway)
+		}
+		req := httptest.NewRequest("GET", "http://example.tld/", nil)
+		rec := httptest.NewRecorder()
+		test.p.ServeHTTP(rec, req)
+		if gotErr == nil || rec.Code != http.StatusBadGateway {
+			t.Errorf("%v: got error %v, status %d; want an error and status 502", test.name, gotErr, rec.Code)
+		}
+	}
+}
+
+func TestSetURL(t *testing.T) {
+	for _, test := range []struct {
+		target, in, want string
+	}{
+		{"http://backend.tld/", "/", "http://backend.tld/"},
+		{"http://backend.tld/base", "/dir", "http://backend.tld/base/dir"},
+		{"http://backend.tld/base/", "/dir?q=1", "http://backend.tld/base/dir?q=1"},
+		{"https://backend.tld/?a=b", "/x?c=d", "https://backend.tld/x?a=b&c=d"},
+		{"http://backend.tld/a%2fb/", "/c", "http://backend.tld/a%2fb/c"},
+	} {
+		target, err := url.Parse(test.target)
+		if err != nil {
+			t.Fatal(err)
+		}
+		in := httptest.NewRequest("GET", "http://example.tld"+test.in, nil)
+		pr := &ProxyRequest{In: in, Out: in.Clone(in.Context())}
+		pr.SetURL(target)
+		if got := pr.Out.URL.String(); got != test.want {
+			t.Errorf("SetURL(%q) on %q: URL = %q, want %q", test.target, test.in, got, test.want)
+		}
+		if pr.Out.Host != "" {
+			t.Errorf("SetURL(%q): Out.Host = %q, want empty", test.target, pr.Out.Host)
+		}
+	}
+}
+
+func TestSetXForwarded(t *testing.T) {
+	for _, test := range []struct {
+		name      string
+		remote    string
+		tls       bool
+		prior     []string
+		wantFor   string
+		wantProto string
+	}{{
+		name:      "plain",
+		remote:    "1.2.3.4:1234",
+		wantFor:   "1.2.3.4",
+		wantProto: "http",
+	}, {
+		name:      "tls",
+		remote:    "1.2.3.4:1234",
+		tls:       true,
+		wantFor:   "1.2.3.4",
+		wantProto: "https",
+	}, {
+		name:      "append",
+		remote:    "1.2.3.4:1234",
+		prior:     []string{"5.6.7.8", "9.9.9.9"},
+		wantFor:   "5.6.7.8, 9.9.9.9, 1.2.3.4",
+		wantProto: "http",
+	}, {
+		name:      "bad remote address",
+		remote:    "unparsable",
+		prior:     []string{"5.6.7.8"},
+		wantFor:   "",
+		wantProto: "http",
+	}} {
+		in := httptest.NewRequest("GET", "http://example.tld/", nil)
+		in.RemoteAddr = test.remote
+		if test.tls {
+			in.TLS = &tls.ConnectionState{}
+		}
+		out := in.Clone(in.Context())
+		if test.prior != nil {
+			out.Header["X-Forwarded-For"] = test.prior
+		}
+		pr := &ProxyRequest{In: in, Out: out}
+		pr.SetXForwarded()
+		if got := out.Header.Get("X-Forwarded-For"); got != test.wantFor {
+			t.Errorf("%v: X-Forwarded-For = %q, want %q", test.name, got, test.wantFor)
+		}
+		if got, want := out.Header.Get("X-Forwarded-Host"), "example.tld"; got != want {
+			t.Errorf("%v: X-Forwarded-Host = %q, want %q", test.name, got, want)
+		}
+		if got := out.Header.Get("X-Forwarded-Proto"); got != test.wantProto {
+			t.Errorf("%v: X-Forwarded-Proto = %q, want %q", test.name, got, test.wantProto)
+		}
+	}
+}
+
 var proxyQueryTests = []struct {
 	baseSuffix string // suffix to add to backend URL
 	reqSuffix  string // suffix to add to frontend's request URL
@@ -0,0 +1,484 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package http
+
+import (
+	"context"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParsePattern(t *testing.T) {
+	lit := func(name string) segment {
+		return segment{s: name}
+	}
+
+	wild := func(name string) segment {
+		return segment{s: name, wild: true}
+	}
+
+	multi := func(name string) segment {
+		s := wild(name)
+		s.multi = true
+		return s
+	}
+
+	for _, test := range []struct {
+		in   string
+		want pattern
+	}{
+		{"/", pattern{segments: []segment{multi("")}}},
+		{"/a", pattern{segments: []segment{lit("a")}}},
+		{
+			"/a/",
+			pattern{segments: []segment{lit("a"), multi("")}},
+		},
+		{"/path/to/something", pattern{segments: []segment{
+			lit("path"), lit("to"), lit("something"),
+		}}},
+		{
+			"/{w1}/lit/{w2}",
+			pattern{
+				segments: []segment{wild("w1"), lit("lit"), wild("w2")},
+			},
+		},
+		{
+			"/{w1}/lit/{w2}/",
+			pattern{
+				segments: []segment{wild("w1"), lit("lit"), wild("w2"), multi("")},
+			},
+		},
+		{
+			"example.com/",
+			pattern{host: "example.com", segments: []segment{multi("")}},
+		},
+		{
+			"GET /",
+			pattern{method: "GET", segments: []segment{multi("")}},
+		},
+		{
+			"POST example.com/foo/{w}",
+			pattern{
+				method:   "POST",
+				host:     "example.com",
+				segments: []segment{lit("foo"), wild("w")},
+			},
+		},
+		{
+			"/{$}",
+			pattern{segments: []segment{lit("/")}},
+		},
+		{
+			"DELETE example.com/a/{foo12}/{$}",
+			pattern{method: "DELETE", host: "example.com", segments: []segment{lit("a"), wild("foo12"), lit("/")}},
+		},
+		{
+			"/foo/{$}",
+			pattern{segments: []segment{lit("foo"), lit("/")}},
+		},
+		{
+			"/{a}/foo/{rest...}",
+			pattern{segments: []segment{wild("a"), lit("foo"), multi("rest")}},
+		},
+		{
+			"GET \t  /",
+			pattern{method: "GET", segments: []segment{multi("")}},
+		},
+	} {
+		got := mustParsePattern(t, test.in)
+		test.want.str = test.in
+		if !reflect.DeepEqual(*got, test.want) {
+			t.Errorf("%q:\ngot  %#v\nwant %#v", test.in, *got, test.want)
+		}
+	}
+}
+
+func TestParsePatternError(t *testing.T) {
+	for _, test := range []struct {
+		in       string
+		contains string
+	}{
+		{"", "empty pattern"},
+		{"A=B /", "invalid method"},
+		{" ", "invalid method"},
+		{"/{w}x", "bad wildcard segment"},
+		{"/x{w}", "bad wildcard segment"},
+		{"/{wx", "bad wildcard segment"},
+		{"/{a$}", "bad wildcard name"},
+		{"/{}", "empty wildcard"},
+		{"/{...}", "empty wildcard"},
+		{"/{$...}", "bad wildcard"},
+		{"/{$}/", "{$} not at end"},
+		{"/{$}/x", "{$} not at end"},
+		{"/{a...}/", "not at end"},
+		{"/{a...}/x", "not at end"},
+		{"{a}/b", "missing initial '/'"},
+		{"/a/{x}/b/{x...}", "duplicate wildcard name"},
+		{"/{1x}", "bad wildcard name"},
+		{"host", "missing /"},
+	} {
+		_, err := parsePattern(test.in)
+		if err == nil || !strings.Contains(err.Error(), test.contains) {
+			t.Errorf("%q:\ngot %v, want error containing %q", test.in, err, test.contains)
+		}
+	}
+}
+
+func TestIsValidWildcardName(t *testing.T) {
+	for _, test := range []struct {
+		in   string
+		want bool
+	}{
+		{"", false},
+		{"a", true},
+		{"abc", true},
+		{"a1", true},
+		{"a_b_c", true},
+		{"_", true},
+		{"1", false},
+		{"a-b", false},
+		{"\u00e9", true},
+	} {
+		if got := isValidWildcardName(test.in); got != test.want {
+			t.Errorf("%q: got %t, want %t", test.in, got, test.want)
+		}
+	}
+}
+
+func TestPatternMatchPath(t *testing.T) {
+	for _, test := range []struct {
+		pat, path string
+		want      []string // nil means no match; empty means match with no values
+	}{
+		{"/", "/", []string{}},
+		{"/", "/a/b", []string{}},
+		{"/{$}", "/", []string{}},
+		{"/{$}", "/a", nil},
+		{"/a", "/a", []string{}},
+		{"/a", "/a/", nil},
+		{"/a", "/b", nil},
+		{"/a/", "/a", nil},
+		{"/a/", "/a/", []string{}},
+		{"/a/", "/a/b/c", []string{}},
+		{"/a/{$}", "/a/", []string{}},
+		{"/a/{$}", "/a/b", nil},
+		{"/a/{x}", "/a/b", []string{"b"}},
+		{"/a/{x}", "/a/", nil},
+		{"/a/{x}", "/a/b/", nil},
+		{"/a/{x}/", "/a/b/", []string{"b"}},
+		{"/{x}/c/{y}", "/a/c/d", []string{"a", "d"}},
+		{"/{x}/c/{y}", "/a/b/d", nil},
+		{"/a/{rest...}", "/a/", []string{""}},
+		{"/a/{rest...}", "/a/b/c", []string{"b/c"}},
+		{"/a/{rest...}", "/a", nil},
+		{"/{x}/{rest...}", "/a/b/c/", []string{"a", "b/c/"}},
+		{"/a", "", nil},
+		{"/a/{x}", "/a/b%2Fc", []string{"b/c"}},
+		{"/a/{x}", "/a%2Fb", nil},
+		{"/a%2Fb", "/a%2Fb", []string{}},
+		{"/a/{rest...}", "/a/b%2Fc/d", []string{"b/c/d"}},
+	} {
+		p := mustParsePattern(t, test.pat)
+		got, ok := p.matchPath(test.path)
+		if !ok {
+			if test.want != nil {
+				t.Errorf("%q.matchPath(%q): no match, want %q", test.pat, test.path, test.want)
+			}
+			continue
+		}
+		if test.want == nil {
+			t.Errorf("%q.matchPath(%q): matched with %q, want no match", test.pat, test.path, got)
+			continue
+		}
+		if len(got) != len(test.want) || (len(got) > 0 && !reflect.DeepEqual(got, test.want)) {
+			t.Errorf("%q.matchPath(%q) = %q, want %q", test.pat, test.path, got, test.want)
+		}
+	}
+}
+
+func TestCompareMethods(t *testing.T) {
+	for _, test := range []struct {
+		p1, p2 string
+		want   relationship
+	}{
+		{"/", "/", equivalent},
+		{"GET /", "GET /", equivalent},
+		{"HEAD /", "HEAD /", equivalent},
+		{"POST /", "POST /", equivalent},
+		{"GET /", "POST /", disjoint},
+		{"GET /", "/", moreSpecific},
+		{"HEAD /", "/", moreSpecific},
+		{"GET /", "HEAD /", moreGeneral},
+	} {
+		pat1 := mustParsePattern(t, test.p1)
+		pat2 := mustParsePattern(t, test.p2)
+		got := pat1.compareMethods(pat2)
+		if got != test.want {
+			t.Errorf("%s vs %s: got %s, want %s", test.p1, test.p2, got, test.want)
+		}
+		got2 := pat2.compareMethods(pat1)
+		want2 := inverseRelationship(test.want)
+		if got2 != want2 {
+			t.Errorf("%s vs %s: got %s, want %s", test.p2, test.p1, got2, want2)
+		}
+	}
+}
+
+func TestComparePaths(t *testing.T) {
+	for _, test := range []struct {
+		p1, p2 string
+		want   relationship
+	}{
+		// A non-final pattern segment can have one of two values: literal or
+		// single wildcard. A final pattern segment can have one of 5: empty
+		// (trailing slash), literal, dollar, single wildcard, or multi
+		// wildcard. Trailing slash and multi wildcard are the same.
+
+		// A literal should be more specific than anything it overlaps, except itself.
+		{"/a", "/a", equivalent},
+		{"/a", "/b", disjoint},
+		{"/a", "/", moreSpecific},
+		{"/a", "/{$}", disjoint},
+		{"/a", "/{x}", moreSpecific},
+		{"/a", "/{x...}", moreSpecific},
+
+		// Adding a segment doesn't change that.
+		{"/b/a", "/b/a", equivalent},
+		{"/b/a", "/b/b", disjoint},
+		{"/b/a", "/b/", moreSpecific},
+		{"/b/a", "/b/{$}", disjoint},
+		{"/b/a", "/b/{x}", moreSpecific},
+		{"/b/a", "/b/{x...}", moreSpecific},
+		{"/{z}/a", "/{z}/a", equivalent},
+		{"/{z}/a", "/{z}/b", disjoint},
+		{"/{z}/a", "/{z}/", moreSpecific},
+		{"/{z}/a", "/{z}/{$}", disjoint},
+		{"/{z}/a", "/{z}/{x}", moreSpecific},
+		{"/{z}/a", "/{z}/{x...}", moreSpecific},
+
+		// Single wildcard on left.
+		{"/{z}", "/a", moreGeneral},
+		{"/{z}", "/a/b", disjoint},
+		{"/{z}", "/{$}", disjoint},
+		{"/{z}", "/{x}", equivalent},
+		{"/", "/{z}", moreGeneral},
+		{"/{z}", "/{x...}", moreSpecific},
+		{"/b/{z}", "/b/a", moreGeneral},
+		{"/b/{z}", "/b/a/b", disjoint},
+		{"/b/{z}", "/b/{$}", disjoint},
+		{"/b/{z}", "/b/{x}", equivalent},
+		{"/b/", "/b/{z}", moreGeneral},
+		{"/b/{z}", "/b/{x...}", moreSpecific},
+
+		// Trailing slash on left.
+		{"/", "/a", moreGeneral},
+		{"/", "/a/b", moreGeneral},
+		{"/", "/{$}", moreGeneral},
+		{"/", "/{x}", moreGeneral},
+		{"/", "/", equivalent},
+		{"/", "/{x...}", equivalent},
+
+		// Overlapping patterns.
+		{"/a/{x}", "/{x}/a", overlaps},
+		{"/a/{x}/", "/{y}/b/", overlaps},
+		{"/a/", "/{x}/b", overlaps},
+		{"/a/{$}", "/{x}/{$}", moreSpecific},
+		{"/{x}/", "/a/{$}", moreGeneral},
+	} {
+		pat1 := mustParsePattern(t, test.p1)
+		pat2 := mustParsePattern(t, test.p2)
+		if g := pat1.comparePaths(pat1); g != equivalent {
+			t.Errorf("%s does not match itself; got %s", pat1, g)
+		}
+		if g := pat2.comparePaths(pat2); g != equivalent {
+			t.Errorf("%s does not match itself; got %s", pat2, g)
+		}
+		got := pat1.comparePaths(pat2)
+		if got != test.want {
+			t.Errorf("%s vs %s: got %s, want %s", test.p1, test.p2, got, test.want)
+		}
+		want2 := inverseRelationship(test.want)
+		got2 := pat2.comparePaths(pat1)
+		if got2 != want2 {
+			t.Errorf("%s vs %s: got %s, want %s", test.p2, test.p1, got2, want2)
+		}
+	}
+}
+
+func TestConflictsWith(t *testing.T) {
+	for _, test := range []struct {
+		p1, p2 string
+		want   bool
+	}{
+		{"/a", "/a", true},
+		{"/a", "/ab", false},
+		{"/a/b/cd", "/a/b/cd", true},
+		{"/a/b/cd", "/a/b/c", false},
+		{"/a/b/c", "/a/c/c", false},
+		{"/{x}", "/{y}", true},
+		{"/{x}", "/a", false}, // more specific
+		{"/{x}/{y}", "/{x}/a", false},
+		{"/{x}/{y}", "/{x}/a/b", false},
+		{"/{x}", "/a/{y}", false},
+		{"/{x}/{y}", "/{x}/a/", false},
+		{"/{x}", "/a/{y...}", false},           // more specific
+		{"/{x}/a/{y}", "/{x}/a/{y...}", false}, // more specific
+		{"/{x}/{y}", "/{x}/a/{$}", false},      // more specific
+		{"/{x}/{y}/{$}", "/{x}/a/{$}", false},
+		{"/a/{x}", "/{x}/b", true},
+		{"/", "GET /", false},
+		{"/", "GET /{$}", false},
+		{"/", "GET /a", false},
+		{"GET /", "GET /", true},
+		{"GET /", "/a", true},
+		{"GET /", "POST /", false},
+		{"GET /", "HEAD /", false},
+		{"HEAD /", "GET /a", true},
+		{"/a", "example.com/a", false},
+		{"example.com/a", "example.com/a", true},
+		{"example.com/a", "example.org/a", false},
+	} {
+		pat1 := mustParsePattern(t, test.p1)
+		pat2 := mustParsePattern(t, test.p2)
+		got := pat1.conflictsWith(pat2)
+		if got != test.want {
+			t.Errorf("%q.ConflictsWith(%q) = %t, want %t",
+				test.p1, test.p2, got, test.want)
+		}
+		// conflictsWith should be commutative.
+		got = pat2.conflictsWith(pat1)
+		if got != test.want {
+			t.Errorf("%q.ConflictsWith(%q) = %t, want %t",
+				test.p2, test.p1, got, test.want)
+		}
+	}
+}
+
+func TestServeMuxPathValues(t *testing.T) {
+	mux := NewServeMux()
+	var got map[string]string
+	record := func(names ...string) HandlerFunc {
+		return func(w ResponseWriter, r *Request) {
+			got = map[string]string{}
+			for _, n := range names {
+				got[n] = r.PathValue(n)
+			}
+		}
+	}
+	mux.Handle("GET /items/{id}", record("id"))
+	mux.Handle("/items/{id}/parts/{rest...}", record("id", "rest"))
+	mux.Handle("/files/", record("x"))
+
+	for _, test := range []struct {
+		method, path string
+		want         map[string]string
+	}{
+		{"GET", "/items/12", map[string]string{"id": "12"}},
+		{"HEAD", "/items/12", map[string]string{"id": "12"}},
+		{"GET", "/items/12/parts/a/b", map[string]string{"id": "12", "rest": "a/b"}},
+		{"GET", "/files/a", map[string]string{"x": ""}},
+	} {
+		got = nil
+		r := &Request{Method: test.method, URL: &url.URL{Path: test.path}}
+		mux.ServeHTTP(&recordingResponseWriter{header: Header{}}, r)
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s %s: got %v, want %v", test.method, test.path, got, test.want)
+		}
+	}
+}
+
+func TestSetPathValue(t *testing.T) {
+	r := &Request{pat: mustParsePattern(t, "/{a}/{b}/{c...}"), matches: []string{"x", "y", "z"}}
+	r.SetPathValue("a", "X")
+	r.SetPathValue("d", "W")
+	for name, want := range map[string]string{"a": "X", "b": "y", "c": "z", "d": "W", "e": ""} {
+		if got := r.PathValue(name); got != want {
+			t.Errorf("PathValue(%q) = %q, want %q", name, got, want)
+		}
+	}
+	r2 := r.Clone(context.Background())
+	r2.SetPathValue("b", "Y")
+	if got := r.PathValue("b"); got != "y" {
+		t.Errorf("SetPathValue on clone changed original: got %q", got)
+	}
+}
+
+func TestServeMuxMethodNotAllowed(t *testing.T) {
+	mux := NewServeMux()
+	mux.HandleFunc("GET /thing", func(ResponseWriter, *Request) {})
+	mux.HandleFunc("PUT /thing", func(ResponseWriter, *Request) {})
+
+	r := &Request{Method: "POST", URL: &url.URL{Path: "/thing"}}
+	w := &recordingResponseWriter{header: Header{}}
+	mux.ServeHTTP(w, r)
+	if w.code != StatusMethodNotAllowed {
+		t.Errorf("got status %d, want %d", w.code, StatusMethodNotAllowed)
+	}
+	if got, want := w.header.Get("Allow"), "GET, HEAD, PUT"; got != want {
+		t.Errorf("Allow = %q, want %q", got, want)
+	}
+}
+
+func TestServeMuxHandleConflict(t *testing.T) {
+	for _, test := range []struct {
+		p1, p2 string
+		want   string
+	}{
+		{"/a", "/a", "multiple registrations"},
+		{"/a/{x}", "/{y}/b", "conflicts with"},
+		{"GET /", "/index.html", "conflicts with"},
+		{"/a/{x}", "/a/{x", "parsing"},
+	} {
+		func() {
+			defer func() {
+				err, _ := recover().(string)
+				if !strings.Contains(err, test.want) {
+					t.Errorf("registering %q after %q: panic %q, want %q", test.p2, test.p1, err, test.want)
+				}
+			}()
+			mux := NewServeMux()
+			mux.Handle(test.p1, NotFoundHandler())
+			mux.Handle(test.p2, NotFoundHandler())
+		}()
+	}
+}
+
+type recordingResponseWriter struct {
+	header Header
+	code   int
+}
+
+func (w *recordingResponseWriter) Header() Header              { return w.header }
+func (w *recordingResponseWriter) Write(p []byte) (int, error) { return len(p), nil }
+func (w *recordingResponseWriter) WriteHeader(code int)        { w.code = code }
+
+func mustParsePattern(t *testing.T, s string) *pattern {
+	t.Helper()
+	p, err := parsePattern(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestServeMuxEscapedSlash(t *testing.T) {
+	// A wildcard ends at a literal slash, not at %2F, and its value
+	// is unescaped.
+	mux := NewServeMux()
+	var got string
+	mux.HandleFunc("/{x}", func(w ResponseWriter, r *Request) { got = "/{x} " + r.PathValue("x") })
+	mux.HandleFunc("/a/{x}", func(w ResponseWriter, r *Request) { got = "/a/{x} " + r.PathValue("x") })
+
+	u, err := url.Parse("/a%2Fb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	mux.ServeHTTP(&recordingResponseWriter{header: Header{}}, &Request{Method: "GET", URL: u})
+	if want := "/{x} a/b"; got != want {
+		t.Errorf("request for %s matched %q, want %q", u, got, want)
+	}
+}
@@ -0,0 +1,330 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package http_test
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	. "net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// wrapWriter wraps a ResponseWriter the way typical middleware does,
+// hiding all of its optional methods except through Unwrap.
+type wrapWriter struct {
+	ResponseWriter
+}
+
+func (w wrapWriter) Unwrap() ResponseWriter { return w.ResponseWriter }
+
+// opaqueWriter wraps a ResponseWriter without providing Unwrap.
+type opaqueWriter struct {
+	ResponseWriter
+}
+
+func TestResponseControllerFlush(t *testing.T) {
+	defer afterTest(t)
+	continuec := make(chan struct{})
+	cst := newClientServerTest(t, h1Mode, HandlerFunc(func(w ResponseWriter, r *Request) {
+		ctl := NewResponseController(wrapWriter{w})
+		w.Write([]byte("one"))
+		if err := ctl.Flush(); err != nil {
+			t.Errorf("ctl.Flush() = %v, want nil", err)
+			return
+		}
+		<-continuec
+		w.Write([]byte("two"))
+	}))
+	defer cst.close()
+
+	res, err := cst.c.Get(cst.ts.URL)
+	if err != nil {
+		t.Fatalf("unexpected connection error: %v", err)
+	}
+	defer res.Body.Close()
+
+	buf := make([]byte, 16)
+	n, err := res.Body.Read(buf)
+	close(continuec)
+	if err != nil || string(buf[:n]) != "one" {
+		t.Fatalf("Body.Read = %q, %v, want %q, nil", string(buf[:n]), err, "one")
+	}
+
+	got, err := ioutil.ReadAll(res.Body)
+	if err != nil || string(got) != "two" {
+		t.Fatalf("Body.Read = %q, %v, want %q, nil", string(got), err, "two")
+	}
+}
+
+func TestResponseControllerHijack(t *testing.T) {
+	defer afterTest(t)
+	const header = "X-Header"
+	const value = "set"
+	cst := newClientServerTest(t, h1Mode, HandlerFunc(func(w ResponseWriter, r *Request) {
+		ctl := NewResponseController(wrapWriter{w})
+		c, _, err := ctl.Hijack()
+		if err != nil {
+			t.Errorf("ctl.Hijack() = %v, want nil", err)
+			return
+		}
+		defer c.Close()
+		fmt.Fprintf(c, "HTTP/1.0 200 OK\r\n%v: %v\r\nContent-Length: 0\r\n\r\n", header, value)
+	}))
+	defer cst.close()
+	res, err := cst.c.Get(cst.ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := res.Header.Get(header), value; got != want {
+		t.Errorf("response header %q = %q, want %q", header, got, want)
+	}
+}
+
+func TestResponseControllerSetPastWriteDeadline(t *testing.T) {
+	defer afterTest(t)
+	cst := newClientServerTest(t, h1Mode, HandlerFunc(func(w ResponseWriter, r *Request) {
+		ctl := NewResponseController(w)
+		w.Write([]byte("one"))
+		if err := ctl.Flush(); err != nil {
+			t.Errorf("before setting deadline: ctl.Flush() = %v, want nil", err)
+		}
+		if err := ctl.SetWriteDeadline(time.Now().Add(-10 * time.Second)); err != nil {
+			t.Errorf("ctl.SetWriteDeadline() = %v, want nil", err)
+		}
+
+		w.Write([]byte("two"))
+		if err := ctl.Flush(); err == nil {
+			t.Errorf("after setting deadline: ctl.Flush() = nil, want non-nil")
+		}
+		// Connection errors are sticky, so resetting the deadline does not permit
+		// making more progress. We might want to change this in the future, but verify
+		// the current behavior for now. If we do change this, we'll want to make sure
+		// to do so only for writing the response body, not headers.
+		if err := ctl.SetWriteDeadline(time.Now().Add(1 * time.Hour)); err != nil {
+			t.Errorf("ctl.SetWriteDeadline() = %v, want nil", err)
+		}
+		w.Write([]byte("three"))
+		if err := ctl.Flush(); err == nil {
+			t.Errorf("after resetting deadline: ctl.Flush() = nil, want non-nil")
+		}
+	}))
+	defer cst.close()
+
+	res, err := cst.c.Get(cst.ts.URL)
+	if err != nil {
+		t.Fatalf("unexpected connection error: %v", err)
+	}
+	defer res.Body.Close()
+	b, _ := ioutil.ReadAll(res.Body)
+	if string(b) != "one" {
+		t.Errorf("unexpected body: %q", string(b))
+	}
+}
+
+func TestResponseControllerSetFutureWriteDeadline(t *testing.T) {
+	defer afterTest(t)
+	errc := make(chan error, 1)
+	startwrites := make(chan struct{})
+	cst := newClientServerTest(t, h1Mode, HandlerFunc(func(w ResponseWriter, r *Request) {
+		ctl := NewResponseController(w)
+		w.WriteHeader(200)
+		if err := ctl.Flush(); err != nil {
+			t.Errorf("ctl.Flush() = %v, want nil", err)
+		}
+		<-startwrites
+		// Writes after the deadline must fail rather than block.
+		if err := ctl.SetWriteDeadline(time.Now().Add(1 * time.Millisecond)); err != nil {
+			t.Errorf("ctl.SetWriteDeadline() = %v, want nil", err)
+		}
+		var err error
+		for err == nil {
+			_, err = w.Write(make([]byte, 1<<16))
+			if err == nil {
+				err = ctl.Flush()
+			}
+		}
+		errc <- err
+	}))
+	defer cst.close()
+
+	res, err := cst.c.Get(cst.ts.URL)
+	if err != nil {
+		t.Fatalf("unexpected connection error: %v", err)
+	}
+	defer res.Body.Close()
+	close(startwrites)
+	// The client never reads the body, so the writes in the handler
+	// eventually block and the deadline must unblock them.
+	if err := <-errc; err == nil {
+		t.Errorf("server wrote past write deadline without error")
+	}
+}
+
+func TestResponseControllerExtendWriteTimeout(t *testing.T) {
+	defer afterTest(t)
+	const timeout = 50 * time.Millisecond
+	cst := newClientServerTest(t, h1Mode, HandlerFunc(func(w ResponseWriter, r *Request) {
+		ctl := NewResponseController(w)
+		if err := ctl.SetWriteDeadline(time.Now().Add(10 * timeout)); err != nil {
+			t.Errorf("ctl.SetWriteDeadline() = %v, want nil", err)
+		}
+		for i := 0; i < 3; i++ {
+			time.Sleep(timeout)
+			fmt.Fprintf(w, "%d", i)
+			if err := ctl.Flush(); err != nil {
+				t.Errorf("ctl.Flush() after Server.WriteTimeout = %v, want nil", err)
+				return
+			}
+		}
+	}), func(ts *httptest.Server) {
+		ts.Config.WriteTimeout = timeout
+	})
+	defer cst.close()
+
+	res, err := cst.c.Get(cst.ts.URL)
+	if err != nil {
+		t.Fatalf("unexpected connection error: %v", err)
+	}
+	defer res.Body.Close()
+	b, err := ioutil.ReadAll(res.Body)
+	if err != nil || string(b) != "012" {
+		t.Errorf("body = %q, %v, want %q, nil", string(b), err, "012")
+	}
+}
+
+func TestResponseControllerSetPastReadDeadline(t *testing.T) {
+	defer afterTest(t)
+	readc := make(chan struct{})
+	donec := make(chan struct{})
+	cst := newClientServerTest(t, h1Mode, HandlerFunc(func(w ResponseWriter, r *Request) {
+		defer close(donec)
+		ctl := NewResponseController(w)
+		b := make([]byte, 3)
+		n, err := io.ReadFull(r.Body, b)
+		b = b[:n]
+		if err != nil || string(b) != "one" {
+			t.Errorf("before setting read deadline: Read = %v, %q, want nil, %q", err, string(b), "one")
+			return
+		}
+		if err := ctl.SetReadDeadline(time.Now()); err != nil {
+			t.Errorf("ctl.SetReadDeadline() = %v, want nil", err)
+			return
+		}
+		b, err = ioutil.ReadAll(r.Body)
+		if err == nil || string(b) != "" {
+			t.Errorf("after setting read deadline: Read = %q, nil, want error", string(b))
+		}
+		close(readc)
+	}))
+	defer cst.close()
+
+	pr, pw := io.Pipe()
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer pw.Close()
+		pw.Write([]byte("one"))
+		select {
+		case <-readc:
+		case <-donec:
+			return
+		}
+		pw.Write([]byte("two"))
+	}()
+	defer wg.Wait()
+	res, err := cst.c.Post(cst.ts.URL, "text/foo", pr)
+	if err == nil {
+		defer res.Body.Close()
+	}
+}
+
+func TestResponseControllerDeadlinesResetAfterRequest(t *testing.T) {
+	// Deadlines set by a handler apply only to its own request,
+	// not to later requests on the same keep-alive connection.
+	defer afterTest(t)
+	const timeout = 50 * time.Millisecond
+	var mu sync.Mutex
+	var addrs []string
+	cst := newClientServerTest(t, h1Mode, HandlerFunc(func(w ResponseWriter, r *Request) {
+		mu.Lock()
+		addrs = append(addrs, r.RemoteAddr)
+		mu.Unlock()
+		if r.URL.Path == "/set" {
+			ctl := NewResponseController(w)
+			if err := ctl.SetWriteDeadline(time.Now().Add(timeout)); err != nil {
+				t.Errorf("ctl.SetWriteDeadline() = %v, want nil", err)
+			}
+			if err := ctl.SetReadDeadline(time.Now().Add(timeout)); err != nil {
+				t.Errorf("ctl.SetReadDeadline() = %v, want nil", err)
+			}
+		}
+		io.Copy(ioutil.Discard, r.Body)
+		w.Write([]byte("ok"))
+	}))
+	defer cst.close()
+
+	get := func(path string) {
+		t.Helper()
+		res, err := cst.c.Get(cst.ts.URL + path)
+		if err != nil {
+			t.Fatalf("GET %s: %v", path, err)
+		}
+		b, err := ioutil.ReadAll(res.Body)
+		res.Body.Close()
+		if err != nil || string(b) != "ok" {
+			t.Fatalf("GET %s: body = %q, %v, want %q, nil", path, string(b), err, "ok")
+		}
+	}
+	get("/set")
+	time.Sleep(2 * timeout)
+	get("/")
+	res, err := cst.c.Post(cst.ts.URL, "text/plain", strings.NewReader("body"))
+	if err != nil {
+		t.Fatalf("POST: %v", err)
+	}
+	res.Body.Close()
+
+	mu.Lock()
+	defer mu.Unlock()
+	for _, addr := range addrs[1:] {
+		if addr != addrs[0] {
+			t.Errorf("requests came from %v, want a single keep-alive connection", addrs)
+			break
+		}
+	}
+}
+
+func TestResponseControllerNotSupported(t *testing.T) {
+	var rw ResponseWriter = opaqueWriter{httptest.NewRecorder()}
+	ctl := NewResponseController(rw)
+	if err := ctl.Flush(); !errors.Is(err, ErrNotSupported) {
+		t.Errorf("ctl.Flush() = %v, want ErrNotSupported", err)
+	}
+	if _, _, err := ctl.Hijack(); !errors.Is(err, ErrNotSupported) {
+		t.Errorf("ctl.Hijack() = %v, want ErrNotSupported", err)
+	}
+	if err := ctl.SetReadDeadline(time.Time{}); !errors.Is(err, ErrNotSupported) {
+		t.Errorf("ctl.SetReadDeadline() = %v, want ErrNotSupported", err)
+	}
+	if err := ctl.SetWriteDeadline(time.Time{}); !errors.Is(err, ErrNotSupported) {
+		t.Errorf("ctl.SetWriteDeadline() = %v, want ErrNotSupported", err)
+	}
+
+	// The recorder itself supports Flush, and is reachable through Unwrap.
+	rec := httptest.NewRecorder()
+	if err := NewResponseController(wrapWriter{rec}).Flush(); err != nil {
+		t.Errorf("Flush through Unwrap = %v, want nil", err)
+	}
+	if !rec.Flushed {
+		t.Errorf("Flush through Unwrap did not flush the recorder")
+	}
+}
@@ -0,0 +1,80 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// An index of ServeMux patterns for fast lookup.
+
+package http
+
+// A routingIndex narrows the set of registered patterns that may match
+// a request, so that ServeMux need not consider every pattern.
+//
+// A pattern whose path segments are all literals can only match one
+// path, so it is indexed by that path. Any other pattern whose first
+// segment is a literal can only match paths with that first segment,
+// so it is indexed by the segment. The remaining patterns, which begin
+// with a wildcard, such as "/" or "/{x}/a", are kept in a list.
+// All three are also keyed by the pattern's host.
+type routingIndex struct {
+	exact map[routingIndexKey][]*muxEntry // by host and full path
+	first map[routingIndexKey][]*muxEntry // by host and first path segment
+	wild  map[string][]*muxEntry          // by host
+}
+
+type routingIndexKey struct {
+	host string
+	s    string // path or first path segment
+}
+
+// add adds the entry e to the index.
+func (idx *routingIndex) add(e *muxEntry) {
+	p := e.pat
+	if path, ok := p.literalPath(); ok {
+		if idx.exact == nil {
+			idx.exact = make(map[routingIndexKey][]*muxEntry)
+		}
+		k := routingIndexKey{p.host, path}
+		idx.exact[k] = append(idx.exact[k], e)
+		return
+	}
+	if seg := p.segments[0]; !seg.wild {
+		if idx.first == nil {
+			idx.first = make(map[routingIndexKey][]*muxEntry)
+		}
+		k := routingIndexKey{p.host, seg.s}
+		idx.first[k] = append(idx.first[k], e)
+		return
+	}
+	if idx.wild == nil {
+		idx.wild = make(map[string][]*muxEntry)
+	}
+	idx.wild[p.host] = append(idx.wild[p.host], e)
+}
+
+// lookup calls f for each entry with the given host that may match path,
+// an escaped path as passed to pattern.matchPath.
+// Entries for which f is not called do not match path.
+//
+// The index is keyed by unescaped literals. A path that matches a pattern
+// segment by segment also matches it when unescaped as a whole, so the
+// lookup may find more entries than match, but never fewer.
+func (idx *routingIndex) lookup(host, path string, f func(*muxEntry)) {
+	for _, e := range idx.exact[routingIndexKey{host, pathUnescape(path)}] {
+		f(e)
+	}
+	if len(path) > 0 && path[0] == '/' {
+		seg := path[1:]
+		for i := 0; i < len(seg); i++ {
+			if seg[i] == '/' {
+				seg = seg[:i]
+				break
+			}
+		}
+		for _, e := range idx.first[routingIndexKey{host, pathUnescape(seg)}] {
+			f(e)
+		}
+	}
+	for _, e := range idx.wild[host] {
+		f(e)
+	}
+}
@@ -0,0 +1,81 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package http
+
+import "testing"
+
+func TestRoutingIndex(t *testing.T) {
+	// Check that lookup returns every pattern that matches a path,
+	// by comparing it to matching against all the patterns.
+	var idx routingIndex
+	var es []*muxEntry
+	for _, s := range []string{
+		"/",
+		"/{$}",
+		"/a",
+		"/a/",
+		"/a/{$}",
+		"/a/b",
+		"/a/{x}",
+		"/a/{x}/c",
+		"/a/{rest...}",
+		"/{x}",
+		"/{x}/b",
+		"/{x}/{y...}",
+		"//a",
+		"GET /b/c",
+		"example.com/",
+		"example.com/a/b",
+		"example.com/{x}",
+	} {
+		e := &muxEntry{pat: mustParsePattern(t, s)}
+		es = append(es, e)
+		idx.add(e)
+	}
+	for _, host := range []string{"", "example.com", "other.com"} {
+		for _, path := range []string{
+			"/", "/a", "/a/", "/a/b", "/a/b/", "/a/b/c", "/a/x/c",
+			"/b", "/b/c", "/c/b", "//a", "/x/y/z", "",
+		} {
+			got := map[*muxEntry]bool{}
+			idx.lookup(host, path, func(e *muxEntry) {
+				if got[e] {
+					t.Errorf("lookup(%q, %q) returned %q twice", host, path, e.pat.str)
+				}
+				got[e] = true
+				if e.pat.host != host {
+					t.Errorf("lookup(%q, %q) returned %q, with the wrong host", host, path, e.pat.str)
+				}
+			})
+			for _, e := range es {
+				if _, ok := e.pat.matchPath(path); ok && e.pat.host == host && !got[e] {
+					t.Errorf("lookup(%q, %q) did not return matching pattern %q", host, path, e.pat.str)
+				}
+			}
+		}
+	}
+}
+
+func TestPatternLiteralPath(t *testing.T) {
+	for _, test := range []struct {
+		pat  string
+		path string
+		ok   bool
+	}{
+		{"/", "", false},
+		{"/{$}", "/", true},
+		{"/a", "/a", true},
+		{"/a/b/{$}", "/a/b/", true},
+		{"GET example.com/a/b", "/a/b", true},
+		{"/a/", "", false},
+		{"/a/{x}", "", false},
+		{"/{x}/b", "", false},
+	} {
+		path, ok := mustParsePattern(t, test.pat).literalPath()
+		if path != test.path || ok != test.ok {
+			t.Errorf("%q.literalPath() = %q, %t, want %q, %t", test.pat, path, ok, test.path, test.ok)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package http
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestServeMux115(t *testing.T) {
+	defer func(old bool) { use115 = old }(use115)
+	use115 = true
+
+	// Patterns are literal paths, so these are not methods or wildcards.
+	mux := NewServeMux()
+	var got string
+	for _, pat := range []string{"/a b", "/{x}", "/dir/"} {
+		pat := pat
+		mux.HandleFunc(pat, func(w ResponseWriter, r *Request) { got = pat })
+	}
+	for _, test := range []struct {
+		path string
+		want string
+	}{
+		{"/a b", "/a b"},
+		{"/{x}", "/{x}"},
+		{"/y", ""},
+		{"/dir/z", "/dir/"},
+	} {
+		got = ""
+		r := &Request{Method: "GET", URL: &url.URL{Path: test.path}}
+		mux.ServeHTTP(&recordingResponseWriter{header: Header{}}, r)
+		if got != test.want {
+			t.Errorf("%s: matched %q, want %q", test.path, got, test.want)
+		}
+		if _, pat := mux.Handler(r); pat != test.want {
+			t.Errorf("%s: Handler returned pattern %q, want %q", test.path, pat, test.want)
+		}
+	}
+}
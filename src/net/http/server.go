@@ -390,11 +390,11 @@ func (cw *chunkWriter) Write(p []byte) (n int, err error) {
 	return
 }
 
-func (cw *chunkWriter) flush() {
+func (cw *chunkWriter) flush() error {
 	if !cw.wroteHeader {
 		cw.writeHeader(nil)
 	}
-	cw.res.conn.bufw.Flush()
+	return cw.res.conn.bufw.Flush()
 }
 
 func (cw *chunkWriter) close() {
@@ -1667,11 +1667,33 @@ func (w *response) closedRequestBodyEarly() bool {
 }
 
 func (w *response) Flush() {
+	w.FlushError()
+}
+
+// FlushError is like Flush, but reports any error encountered
+// while writing the buffered data to the connection.
+func (w *response) FlushError() error {
 	if !w.wroteHeader {
 		w.WriteHeader(StatusOK)
 	}
-	w.w.Flush()
-	w.cw.flush()
+	err := w.w.Flush()
+	e2 := w.cw.flush()
+	if err == nil {
+		err = e2
+	}
+	return err
+}
+
+// SetReadDeadline sets the deadline for reading the rest of the
+// request body from the underlying connection.
+func (w *response) SetReadDeadline(deadline time.Time) error {
+	return w.conn.rwc.SetReadDeadline(deadline)
+}
+
+// SetWriteDeadline sets the deadline for writing the response to the
+// underlying connection.
+func (w *response) SetWriteDeadline(deadline time.Time) error {
+	return w.conn.rwc.SetWriteDeadline(deadline)
 }
 
 func (c *conn) finalFlush() {
@@ -1928,6 +1950,11 @@ func (c *conn) serve(ctx context.Context) {
 			return
 		}
 		w.finishRequest()
+		// The handler may have changed the connection's deadlines
+		// through a ResponseController. Clear them so that they do not
+		// apply to the next request; readRequest sets new ones.
+		c.rwc.SetWriteDeadline(time.Time{})
+		c.rwc.SetReadDeadline(time.Time{})
 		if !w.shouldReuseConnection() {
 			if w.requestBodyLimitHit || w.closedRequestBodyEarly() {
 				c.closeWriteAndWait()
@@ -2189,46 +2216,122 @@ func RedirectHandler(url string, code int) Handler {
 // patterns and calls the handler for the pattern that
 // most closely matches the URL.
 //
-// Patterns name fixed, rooted paths, like "/favicon.ico",
-// or rooted subtrees, like "/images/" (note the trailing slash).
-// Longer patterns take precedence over shorter ones, so that
-// if there are handlers registered for both "/images/"
-// and "/images/thumbnails/", the latter handler will be
-// called for paths beginning "/images/thumbnails/" and the
-// former will receive requests for any other paths in the
-// "/images/" subtree.
+// Patterns
+//
+// Patterns can match the method, host and path of a request.
+// Some examples:
+//
+//	"/index.html" matches the path "/index.html" for any host and method.
+//	"GET /static/" matches a GET request whose path begins with "/static/".
+//	"example.com/" matches any request to the host "example.com".
+//	"example.com/{$}" matches requests with host "example.com" and path "/".
+//	"/b/{bucket}/o/{objectname...}" matches paths whose first segment is "b"
+//	and whose third segment is "o". The name "bucket" denotes the second
+//	segment and "objectname" denotes the remainder of the path.
+//
+// In general, a pattern looks like
+//
+//	[METHOD ][HOST]/[PATH]
+//
+// All three parts are optional; "/" is a valid pattern.
+// If METHOD is present, it must be followed by at least one space or tab.
+//
+// Literal (that is, non-wildcard) parts of a pattern match
+// the corresponding parts of a request case-sensitively.
+//
+// A pattern with no method matches every method. A pattern
+// with the method GET matches both GET and HEAD requests.
+// Otherwise, the method must match exactly.
+//
+// A pattern with no host matches every host.
+// A pattern with a host matches URLs on that host only.
+//
+// A path can include wildcard segments of the form {NAME} or {NAME...}.
+// For example, "/b/{bucket}/o/{objectname...}".
+// The wildcard name must be a valid Go identifier.
+// Wildcards must be full path segments: they must be preceded by a slash and followed by
+// either a slash or the end of the string.
+// For example, "/b_{bucket}" is not a valid pattern.
+//
+// Normally a wildcard matches only a single, non-empty path segment,
+// ending at the next literal slash (not %2F) in the request URL.
+// But if the "..." is present, then the wildcard matches the remainder of the URL path, including slashes.
+// (Therefore it is invalid for a "..." wildcard to appear anywhere but at the end of a pattern.)
+// The match for a wildcard can be obtained by calling Request.PathValue with the wildcard's name.
+// A trailing slash in a path acts as an anonymous "..." wildcard.
+//
+// The special wildcard {$} matches only the end of the URL.
+// For example, the pattern "/{$}" matches only the path "/",
+// whereas the pattern "/" matches every path.
+//
+// Precedence
+//
+// If two or more patterns match a request, then the most specific pattern takes precedence.
+// A pattern P1 is more specific than P2 if P1 matches a strict subset of P2's requests;
+// that is, if P2 matches all the requests of P1 and more.
+// If neither is more specific, then the patterns conflict.
+// There is one exception to this rule, for backwards compatibility:
+// if two patterns would otherwise conflict and one has a host while the other does not,
+// then the pattern with the host takes precedence.
+// If a pattern passed to ServeMux.Handle or ServeMux.HandleFunc conflicts with
+// another pattern that is already registered, those functions panic.
+//
+// As an example of the general rule, "/images/thumbnails/" is more specific than "/images/",
+// so both can be registered.
+// The former matches paths beginning with "/images/thumbnails/"
+// and the latter will match any other path in the "/images/" subtree.
+//
+// As another example, consider the patterns "GET /" and "/index.html":
+// both match a GET request for "/index.html", but the former pattern
+// matches all other GET and HEAD requests, while the latter matches any
+// request for "/index.html" that uses a different method.
+// The patterns conflict.
 //
 // Note that since a pattern ending in a slash names a rooted subtree,
 // the pattern "/" matches all paths not matched by other registered
 // patterns, not just the URL with Path == "/".
 //
-// If a subtree has been registered and a request is received naming the
-// subtree root without its trailing slash, ServeMux redirects that
-// request to the subtree root (adding the trailing slash). This behavior can
-// be overridden with a separate registration for the path without
-// the trailing slash. For example, registering "/images/" causes ServeMux
+// Trailing-slash redirection
+//
+// Consider a ServeMux with a handler for a subtree, registered using a trailing slash or "..." wildcard.
+// If the ServeMux receives a request for the subtree root without a trailing slash,
+// it redirects the request by adding the trailing slash.
+// This behavior can be overridden with a separate registration for the path without
+// the trailing slash or "..." wildcard. For example, registering "/images/" causes ServeMux
 // to redirect a request for "/images" to "/images/", unless "/images" has
 // been registered separately.
 //
-// Patterns may optionally begin with a host name, restricting matches to
-// URLs on that host only. Host-specific patterns take precedence over
-// general patterns, so that a handler might register for the two patterns
-// "/codesearch" and "codesearch.google.com/" without also taking over
-// requests for "http://www.google.com/".
+// Request sanitizing
 //
 // ServeMux also takes care of sanitizing the URL request path and the Host
 // header, stripping the port number and redirecting any request containing . or
 // .. elements or repeated slashes to an equivalent, cleaner URL.
+//
+// If a request's path matches some registered pattern but its method
+// matches none of them, ServeMux replies with 405 Method Not Allowed
+// and an Allow header listing the methods that would have matched.
+//
+// Compatibility
+//
+// The pattern syntax and matching behavior of ServeMux changed
+// significantly in Go 1.16. Before, a pattern was a literal path,
+// optionally preceded by a host, so that a pattern containing spaces
+// or braces matched a path containing them. Now such a pattern has
+// a method or wildcards, or it is invalid and Handle panics.
+// To restore the old behavior, set the GODEBUG environment variable
+// to "httpmuxgo115=1". This setting is read once, at program startup;
+// changes during execution are ignored.
 type ServeMux struct {
-	mu    sync.RWMutex
-	m     map[string]muxEntry
-	es    []muxEntry // slice of entries sorted from longest to shortest.
-	hosts bool       // whether any patterns contain hostnames
+	mu     sync.RWMutex
+	es     []*muxEntry  // registered entries, in registration order
+	index  routingIndex // the entries of es, for lookup by request
+	hosts  bool         // whether any patterns contain hostnames
+	mux115 serveMux115  // used instead of the above with GODEBUG=httpmuxgo115=1
 }
 
 type muxEntry struct {
-	h       Handler
-	pattern string
+	h   Handler
+	pat *pattern
 }
 
 // NewServeMux allocates and returns a new ServeMux.
@@ -2274,64 +2377,92 @@ func stripHostPort(h string) string {
 	return host
 }
 
-// Find a handler on a handler map given a path string.
-// Most-specific (longest) pattern wins.
-func (mux *ServeMux) match(path string) (h Handler, pattern string) {
-	// Check for exact match first.
-	v, ok := mux.m[path]
-	if ok {
-		return v.h, v.pattern
+// Find a handler on a handler map given a host, method and path.
+// Most-specific pattern wins.
+func (mux *ServeMux) match(host, method, path string) (e *muxEntry, matches []string) {
+	mux.index.lookup(host, path, func(e2 *muxEntry) {
+		if !e2.pat.matchMethod(method) {
+			return
+		}
+		m, ok := e2.pat.matchPath(path)
+		if !ok {
+			return
+		}
+		if e == nil || e2.pat.comparePathsAndMethods(e.pat) == moreSpecific {
+			e, matches = e2, m
+		}
+	})
+	return e, matches
+}
+
+// matchHost is like match, but host-specific patterns take precedence
+// over generic ones.
+func (mux *ServeMux) matchHost(host, method, path string) (e *muxEntry, matches []string) {
+	if mux.hosts {
+		e, matches = mux.match(host, method, path)
 	}
+	if e == nil {
+		e, matches = mux.match("", method, path)
+	}
+	return e, matches
+}
 
-	// Check for longest valid match.  mux.es contains all patterns
-	// that end in / sorted from longest to shortest.
-	for _, e := range mux.es {
-		if strings.HasPrefix(path, e.pattern) {
-			return e.h, e.pattern
+// allowedMethodsRLocked returns the sorted methods of the patterns
+// that match host and path, including HEAD if GET is present.
+func (mux *ServeMux) allowedMethodsRLocked(host, path string) []string {
+	methods := map[string]bool{}
+	add := func(e *muxEntry) {
+		if _, ok := e.pat.matchPath(path); ok && e.pat.method != "" {
+			methods[e.pat.method] = true
+			if e.pat.method == "GET" {
+				methods["HEAD"] = true
+			}
 		}
 	}
-	return nil, ""
+	if mux.hosts && host != "" {
+		mux.index.lookup(host, path, add)
+	}
+	mux.index.lookup("", path, add)
+	if len(methods) == 0 {
+		return nil
+	}
+	allow := make([]string, 0, len(methods))
+	for m := range methods {
+		allow = append(allow, m)
+	}
+	sort.Strings(allow)
+	return allow
 }
 
 // redirectToPathSlash determines if the given path needs appending "/" to it.
 // This occurs when a handler for path + "/" was already registered, but
 // not for path itself. If the path needs appending to, it creates a new
 // URL, setting the path to u.Path + "/" and returning true to indicate so.
-func (mux *ServeMux) redirectToPathSlash(host, path string, u *url.URL) (*url.URL, bool) {
+func (mux *ServeMux) redirectToPathSlash(host, method, path string, u *url.URL) (*url.URL, bool) {
 	mux.mu.RLock()
-	shouldRedirect := mux.shouldRedirectRLocked(host, path)
+	shouldRedirect := mux.shouldRedirectRLocked(host, method, path)
 	mux.mu.RUnlock()
 	if !shouldRedirect {
 		return u, false
 	}
 	path = path + "/"
-	u = &url.URL{Path: path, RawQuery: u.RawQuery}
+	u = &url.URL{Path: pathUnescape(path), RawPath: path, RawQuery: u.RawQuery}
 	return u, true
 }
 
 // shouldRedirectRLocked reports whether the given path and host should be redirected to
 // path+"/". This should happen if a handler is registered for path+"/" but
 // not path -- see comments at ServeMux.
-func (mux *ServeMux) shouldRedirectRLocked(host, path string) bool {
-	p := []string{path, host + path}
-
-	for _, c := range p {
-		if _, exist := mux.m[c]; exist {
-			return false
-		}
-	}
-
+func (mux *ServeMux) shouldRedirectRLocked(host, method, path string) bool {
 	n := len(path)
-	if n == 0 {
+	if n == 0 || path[n-1] == '/' {
 		return false
 	}
-	for _, c := range p {
-		if _, exist := mux.m[c+"/"]; exist {
-			return path[n-1] != '/'
-		}
+	if e, _ := mux.matchHost(host, method, path); e != nil && exactMatch(e.pat, path) {
+		return false
 	}
-
-	return false
+	e, _ := mux.matchHost(host, method, path+"/")
+	return e != nil && exactMatch(e.pat, path+"/")
 }
 
 // Handler returns the handler to use for the given request,
@@ -2350,17 +2481,28 @@ func (mux *ServeMux) shouldRedirectRLocked(host, path string) bool {
 // If there is no registered handler that applies to the request,
 // Handler returns a ``page not found'' handler and an empty pattern.
 func (mux *ServeMux) Handler(r *Request) (h Handler, pattern string) {
+	if use115 {
+		return mux.mux115.findHandler(r)
+	}
+	h, pattern, _, _ = mux.findHandler(r)
+	return
+}
+
+// findHandler is the implementation of Handler. In addition to the
+// handler and pattern string, it returns the matched pattern, if any,
+// and the values of its wildcards.
+func (mux *ServeMux) findHandler(r *Request) (h Handler, patStr string, _ *pattern, matches []string) {
 
 	// CONNECT requests are not canonicalized.
 	if r.Method == "CONNECT" {
 		// If r.URL.Path is /tree and its handler is not registered,
 		// the /tree -> /tree/ redirect applies to CONNECT requests
 		// but the path canonicalization does not.
-		if u, ok := mux.redirectToPathSlash(r.URL.Host, r.URL.Path, r.URL); ok {
-			return RedirectHandler(u.String(), StatusMovedPermanently), u.Path
+		if u, ok := mux.redirectToPathSlash(r.URL.Host, r.Method, r.URL.EscapedPath(), r.URL); ok {
+			return RedirectHandler(u.String(), StatusMovedPermanently), u.Path, nil, nil
 		}
 
-		return mux.handler(r.Host, r.URL.Path)
+		return mux.handler(r.Host, r.Method, r.URL.EscapedPath())
 	}
 
 	// All other requests have any port stripped and path cleaned
@@ -2368,39 +2510,54 @@ func (mux *ServeMux) Handler(r *Request) (h Handler, pattern string) {
 	host := stripHostPort(r.Host)
 	path := cleanPath(r.URL.Path)
 
+	// Patterns are matched against the escaped path, so that a wildcard
+	// ends at a literal slash but not at %2F. If the path is about to be
+	// cleaned by a redirect, match against the path of the redirect.
+	epath := r.URL.EscapedPath()
+	if path != r.URL.Path {
+		epath = (&url.URL{Path: path}).EscapedPath()
+	}
+
 	// If the given path is /tree and its handler is not registered,
 	// redirect for /tree/.
-	if u, ok := mux.redirectToPathSlash(host, path, r.URL); ok {
-		return RedirectHandler(u.String(), StatusMovedPermanently), u.Path
+	if u, ok := mux.redirectToPathSlash(host, r.Method, epath, r.URL); ok {
+		return RedirectHandler(u.String(), StatusMovedPermanently), u.Path, nil, nil
 	}
 
 	if path != r.URL.Path {
-		_, pattern = mux.handler(host, path)
+		_, patStr, _, _ = mux.handler(host, r.Method, epath)
 		url := *r.URL
 		url.Path = path
-		return RedirectHandler(url.String(), StatusMovedPermanently), pattern
+		return RedirectHandler(url.String(), StatusMovedPermanently), patStr, nil, nil
 	}
 
-	return mux.handler(host, r.URL.Path)
+	return mux.handler(host, r.Method, epath)
 }
 
 // handler is the main implementation of Handler.
-// The path is known to be in canonical form, except for CONNECT methods.
-func (mux *ServeMux) handler(host, path string) (h Handler, pattern string) {
+// The path is escaped, and known to be in canonical form, except for
+// CONNECT methods.
+func (mux *ServeMux) handler(host, method, path string) (h Handler, patStr string, _ *pattern, matches []string) {
 	mux.mu.RLock()
 	defer mux.mu.RUnlock()
 
-	// Host-specific pattern takes precedence over generic ones
-	if mux.hosts {
-		h, pattern = mux.match(host + path)
-	}
-	if h == nil {
-		h, pattern = mux.match(path)
+	e, matches := mux.matchHost(host, method, path)
+	if e != nil {
+		return e.h, e.pat.str, e.pat, matches
 	}
-	if h == nil {
-		h, pattern = NotFoundHandler(), ""
+	if allow := mux.allowedMethodsRLocked(host, path); len(allow) > 0 {
+		return methodNotAllowedHandler(allow), "", nil, nil
 	}
-	return
+	return NotFoundHandler(), "", nil, nil
+}
+
+// methodNotAllowedHandler returns a handler that replies to each
+// request with a 405 Method Not Allowed error listing the allowed methods.
+func methodNotAllowedHandler(allow []string) Handler {
+	return HandlerFunc(func(w ResponseWriter, r *Request) {
+		w.Header().Set("Allow", strings.Join(allow, ", "))
+		Error(w, StatusText(StatusMethodNotAllowed), StatusMethodNotAllowed)
+	})
 }
 
 // ServeHTTP dispatches the request to the handler whose
@@ -2413,13 +2570,25 @@ func (mux *ServeMux) ServeHTTP(w ResponseWriter, r *Request) {
 		w.WriteHeader(StatusBadRequest)
 		return
 	}
-	h, _ := mux.Handler(r)
+	if use115 {
+		h, _ := mux.mux115.findHandler(r)
+		h.ServeHTTP(w, r)
+		return
+	}
+	h, _, pat, matches := mux.findHandler(r)
+	r.pat = pat
+	r.matches = matches
 	h.ServeHTTP(w, r)
 }
 
 // Handle registers the handler for the given pattern.
-// If a handler already exists for pattern, Handle panics.
+// If the given pattern conflicts with one that is already registered,
+// Handle panics.
 func (mux *ServeMux) Handle(pattern string, handler Handler) {
+	if use115 {
+		mux.mux115.handle(pattern, handler)
+		return
+	}
 	mux.mu.Lock()
 	defer mux.mu.Unlock()
 
@@ -2429,39 +2598,27 @@ func (mux *ServeMux) Handle(pattern string, handler Handler) {
 	if handler == nil {
 		panic("http: nil handler")
 	}
-	if _, exist := mux.m[pattern]; exist {
-		panic("http: multiple registrations for " + pattern)
-	}
-
-	if mux.m == nil {
-		mux.m = make(map[string]muxEntry)
+	pat, err := parsePattern(pattern)
+	if err != nil {
+		panic(fmt.Sprintf("http: parsing %q: %v", pattern, err))
 	}
-	e := muxEntry{h: handler, pattern: pattern}
-	mux.m[pattern] = e
-	if pattern[len(pattern)-1] == '/' {
-		mux.es = appendSorted(mux.es, e)
+	for _, e := range mux.es {
+		if e.pat.str == pattern {
+			panic("http: multiple registrations for " + pattern)
+		}
+		if pat.conflictsWith(e.pat) {
+			panic(fmt.Sprintf("http: pattern %q conflicts with pattern %q: neither is more specific than the other", pattern, e.pat.str))
+		}
 	}
 
-	if pattern[0] != '/' {
+	e := &muxEntry{h: handler, pat: pat}
+	mux.es = append(mux.es, e)
+	mux.index.add(e)
+	if pat.host != "" {
 		mux.hosts = true
 	}
 }
 
-func appendSorted(es []muxEntry, e muxEntry) []muxEntry {
-	n := len(es)
-	i := sort.Search(n, func(i int) bool {
-		return len(es[i].pattern) < len(e.pattern)
-	})
-	if i == n {
-		return append(es, e)
-	}
-	// we now know that i points at where we want to insert
-	es = append(es, muxEntry{}) // try to grow the slice in place, any entry works.
-	copy(es[i+1:], es[i:])      // Move shorter entries down
-	es[i] = e
-	return es
-}
-
 // HandleFunc registers the handler function for the given pattern.
 func (mux *ServeMux) HandleFunc(pattern string, handler func(ResponseWriter, *Request)) {
 	if handler == nil {
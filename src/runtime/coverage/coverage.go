@@ -0,0 +1,245 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package coverage contains APIs for writing coverage profile data at
+// runtime from programs built with 'go build -cover'.
+//
+// A program built with -cover writes its coverage data automatically
+// when it exits by returning from main.main or by calling os.Exit.
+// The data is written to the directory named by the GOCOVERDIR
+// environment variable; if GOCOVERDIR is not set, the program prints
+// a warning and writes nothing. The functions in this package are
+// intended for long-running programs, such as servers, that do not
+// terminate normally, or that want to take snapshots of their
+// coverage while they run.
+//
+// The files written are read by 'go tool covdata'.
+package coverage
+
+import (
+	"errors"
+	"fmt"
+	"internal/coverage"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+)
+
+// Provided by package runtime.
+func runtime_addExitHook(f func(), runOnNonZeroExit bool)
+func runtime_coverFileCount() int
+func runtime_coverFile(i int) (mode, name string, counter, pos []uint32, numStmt []uint16)
+
+func init() {
+	runtime_addExitHook(emitOnExit, true)
+}
+
+// errNoCoverage is returned when the program was not built with -cover.
+var errNoCoverage = errors.New("no meta-data available (binary not built with -cover?)")
+
+// WriteMetaDir writes a coverage meta-data file for the currently
+// running program to the directory specified in 'dir'. An error will
+// be returned if the operation can't be completed successfully (for
+// example, if the currently running program was not built with
+// "-cover", or if the directory does not exist).
+func WriteMetaDir(dir string) error {
+	m, err := meta()
+	if err != nil {
+		return err
+	}
+	return writeMetaDir(dir, m)
+}
+
+// WriteMeta writes the meta-data content (the payload that would
+// normally be emitted to a meta-data file) for the currently running
+// program to the writer 'w'. An error will be returned if the
+// operation can't be completed successfully (for example, if the
+// currently running program was not built with "-cover", or if a
+// write fails).
+func WriteMeta(w io.Writer) error {
+	if w == nil {
+		return errors.New("error: nil writer in WriteMeta")
+	}
+	m, err := meta()
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(m.Encode())
+	return err
+}
+
+// WriteCountersDir writes a coverage counter-data file for the
+// currently running program to the directory specified in 'dir'. An
+// error will be returned if the operation can't be completed
+// successfully (for example, if the currently running program was not
+// built with "-cover", or if the directory does not exist). The
+// counter data written will be a snapshot taken at the point of the
+// call. The meta-data file that the counters refer to is written
+// to the same directory if it is not already present.
+func WriteCountersDir(dir string) error {
+	m, err := meta()
+	if err != nil {
+		return err
+	}
+	return writeDir(dir, m)
+}
+
+// WriteCounters writes coverage counter-data content for the
+// currently running program to the writer 'w'. An error will be
+// returned if the operation can't be completed successfully (for
+// example, if the currently running program was not built with
+// "-cover", or if a write fails). The counter data written will be a
+// snapshot taken at the point of the invocation.
+func WriteCounters(w io.Writer) error {
+	if w == nil {
+		return errors.New("error: nil writer in WriteCounters")
+	}
+	m, err := meta()
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(counters(m).Encode())
+	return err
+}
+
+// ClearCounters clears/resets all coverage counter variables in the
+// currently running program. It returns an error if the program in
+// question was not built with the "-cover" flag. Clearing of coverage
+// counters is also not supported for programs not using atomic
+// counter mode (see more detailed comments below for the rationale
+// here).
+func ClearCounters() error {
+	n := runtime_coverFileCount()
+	if n == 0 {
+		return errNoCoverage
+	}
+	// Clearing is only supported in atomic mode: in set and count
+	// modes the counters are updated with plain stores, which may
+	// race with the clearing and leave the counters in an
+	// inconsistent state.
+	if mode, _, _, _, _ := runtime_coverFile(0); mode != "atomic" {
+		return fmt.Errorf("ClearCounters invoked for program built with -covermode=%s (please use -covermode=atomic)", mode)
+	}
+	for i := 0; i < n; i++ {
+		_, _, counter, _, _ := runtime_coverFile(i)
+		for j := range counter {
+			atomic.StoreUint32(&counter[j], 0)
+		}
+	}
+	return nil
+}
+
+// emitOnExit is registered with the runtime to write coverage data
+// into $GOCOVERDIR when the program exits.
+func emitOnExit() {
+	if runtime_coverFileCount() == 0 {
+		return
+	}
+	dir := os.Getenv("GOCOVERDIR")
+	if dir == "" {
+		fmt.Fprintf(os.Stderr, "warning: GOCOVERDIR not set, no coverage data emitted\n")
+		return
+	}
+	m, err := meta()
+	if err == nil {
+		err = writeDir(dir, m)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: coverage data emit failed: %v\n", err)
+	}
+}
+
+// meta returns the meta-data for the currently running program.
+func meta() (*coverage.Meta, error) {
+	n := runtime_coverFileCount()
+	if n == 0 {
+		return nil, errNoCoverage
+	}
+	m := &coverage.Meta{Files: make([]coverage.FileMeta, n)}
+	for i := 0; i < n; i++ {
+		mode, name, _, pos, numStmt := runtime_coverFile(i)
+		m.Mode = mode
+		f := &m.Files[i]
+		f.Name = name
+		f.Blocks = make([]coverage.Block, len(numStmt))
+		for j := range f.Blocks {
+			// Each block has three words of position information,
+			// in the layout written by cmd/cover: the start line,
+			// the end line, and the end and start columns packed
+			// into the high and low halves of the third word.
+			p := pos[3*j : 3*j+3]
+			f.Blocks[j] = coverage.Block{
+				StartLine: p[0],
+				StartCol:  p[2] & 0xFFFF,
+				EndLine:   p[1],
+				EndCol:    p[2] >> 16,
+				NumStmt:   numStmt[j],
+			}
+		}
+	}
+	return m, nil
+}
+
+// counters returns a snapshot of the counters of the currently
+// running program, which is described by m.
+func counters(m *coverage.Meta) *coverage.Counters {
+	c := &coverage.Counters{
+		MetaHash: m.Hash(),
+		Counts:   make([][]uint32, len(m.Files)),
+	}
+	for i := range c.Counts {
+		_, _, counter, _, _ := runtime_coverFile(i)
+		counts := make([]uint32, len(counter))
+		for j := range counter {
+			counts[j] = atomic.LoadUint32(&counter[j])
+		}
+		c.Counts[i] = counts
+	}
+	return c
+}
+
+// writeDir writes the counters of the currently running program, and
+// the meta-data file if needed, into dir.
+func writeDir(dir string, m *coverage.Meta) error {
+	if err := writeMetaDir(dir, m); err != nil {
+		return err
+	}
+	c := counters(m)
+	name := fmt.Sprintf("%s.%s.%d.%d", coverage.CounterFilePref, c.MetaHash, os.Getpid(), time.Now().UnixNano())
+	return writeFile(dir, name, c.Encode())
+}
+
+// writeMetaDir writes the meta-data file for m into dir, unless a
+// previous run of the same program already wrote it.
+func writeMetaDir(dir string, m *coverage.Meta) error {
+	name := coverage.MetaFilePref + "." + m.Hash()
+	if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+		return nil
+	}
+	return writeFile(dir, name, m.Encode())
+}
+
+// writeFile writes data to the named file in dir. It writes to a
+// temporary file first, so that concurrent readers and writers of the
+// same directory never see a partially written file.
+func writeFile(dir, name string, data []byte) error {
+	f, err := ioutil.TempFile(dir, "tmp."+name)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), filepath.Join(dir, name))
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+	return err
+}
@@ -0,0 +1,49 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package runtime
+
+import _ "unsafe" // for go:linkname
+
+// coverFile records the coverage counters of one source file
+// instrumented by 'go build -cover'.
+type coverFile struct {
+	mode    string   // coverage mode: "set", "count" or "atomic"
+	name    string   // file name as reported in coverage profiles
+	counter []uint32 // one counter per basic block
+	pos     []uint32 // three words of position information per block
+	numStmt []uint16 // number of statements per block
+}
+
+// coverFiles holds the files registered by addCoverFile, in
+// registration order.
+var coverFiles []coverFile
+
+// addCoverFile is called from the init function that cmd/cover adds to
+// each source file it instruments for 'go build -cover'. The cover tool
+// refers to it with a go:linkname directive, so that instrumented
+// packages need not import anything but unsafe.
+//
+// Registration happens during package initialization, which runs on a
+// single goroutine, so no locking is needed.
+func addCoverFile(mode, name string, counter, pos []uint32, numStmt []uint16) {
+	coverFiles = append(coverFiles, coverFile{
+		mode:    mode,
+		name:    name,
+		counter: counter,
+		pos:     pos,
+		numStmt: numStmt,
+	})
+}
+
+//go:linkname coverage_runtime_coverFileCount runtime/coverage.runtime_coverFileCount
+func coverage_runtime_coverFileCount() int {
+	return len(coverFiles)
+}
+
+//go:linkname coverage_runtime_coverFile runtime/coverage.runtime_coverFile
+func coverage_runtime_coverFile(i int) (mode, name string, counter, pos []uint32, numStmt []uint16) {
+	f := &coverFiles[i]
+	return f.mode, f.name, f.counter, f.pos, f.numStmt
+}
@@ -6,6 +6,7 @@ package debug_test
 
 import (
 	"internal/testenv"
+	"math"
 	"runtime"
 	. "runtime/debug"
 	"testing"
@@ -179,6 +180,45 @@ func abs64(a int64) int64 {
 	return a
 }
 
+var setMemoryLimitBallast interface{}
+
+func TestSetMemoryLimit(t *testing.T) {
+	// Test that the limit is being set and returned correctly.
+	old := SetMemoryLimit(123 << 20)
+	defer SetMemoryLimit(old)
+	if old != math.MaxInt64 {
+		t.Skipf("memory limit already set to %d; skipping", old)
+	}
+	if got := SetMemoryLimit(-1); got != 123<<20 {
+		t.Errorf("SetMemoryLimit(-1) = %d, want %d", got, 123<<20)
+	}
+
+	// Test that the limit lowers the heap goal, even with GC off.
+	defer func() {
+		setMemoryLimitBallast = nil
+	}()
+	defer SetGCPercent(SetGCPercent(-1))
+	const (
+		baseline = 32 << 20
+		limit    = 128 << 20
+	)
+	setMemoryLimitBallast = make([]byte, baseline)
+	runtime.GC()
+	SetMemoryLimit(limit)
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	if ms.NextGC <= baseline || ms.NextGC >= limit {
+		t.Errorf("NextGC = %d MB, want between %d MB and %d MB", ms.NextGC>>20, baseline>>20, limit>>20)
+	}
+
+	// Removing the limit turns the GC off again.
+	SetMemoryLimit(math.MaxInt64)
+	runtime.ReadMemStats(&ms)
+	if ms.NextGC < limit {
+		t.Errorf("NextGC = %d MB after removing the limit, want at least %d MB", ms.NextGC>>20, limit>>20)
+	}
+}
+
 func TestSetMaxThreadsOvf(t *testing.T) {
 	// Verify that a big threads count will not overflow the int32
 	// maxmcount variable, causing a panic (see Issue 16076).
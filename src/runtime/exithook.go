@@ -0,0 +1,68 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package runtime
+
+import _ "unsafe" // for go:linkname
+
+// exitHook stores a function to be run on program exit, registered
+// by addExitHook.
+type exitHook struct {
+	f                func() // func to run
+	runOnNonZeroExit bool   // whether to run on non-zero exit code
+}
+
+var (
+	exitHooks        []exitHook
+	runningExitHooks bool
+)
+
+// addExitHook registers the specified function 'f' to be run at
+// program termination (e.g. when someone invokes os.Exit(), or when
+// main.main returns). Hooks are run in reverse order of registration:
+// first hook added is the last one run.
+//
+// Hooks are not run on a crash, an unrecovered panic, or a fatal
+// signal, nor when the program exits by calling syscall.Exit.
+//
+// CAREFUL: the expectation is that addExitHook should only be called
+// from a safe context (e.g. not an error/panic path or signal
+// handler, preemption enabled, allocation allowed, write barriers
+// allowed, etc), and that the exit function 'f' will be invoked under
+// similar circumstances. That is to say, we are expecting that 'f'
+// uses normal / high-level Go code as opposed to one of the more
+// restricted dialects used for the trickier parts of the runtime.
+//
+//go:linkname addExitHook runtime/coverage.runtime_addExitHook
+func addExitHook(f func(), runOnNonZeroExit bool) {
+	exitHooks = append(exitHooks, exitHook{f: f, runOnNonZeroExit: runOnNonZeroExit})
+}
+
+// runExitHooks runs any registered exit hook functions (funcs
+// previously registered using addExitHook). Here 'exitCode' is the
+// status code being passed to os.Exit, or zero if the program is
+// terminating normally without calling os.Exit.
+func runExitHooks(exitCode int) {
+	if runningExitHooks {
+		throw("internal error: exit hook invoked exit")
+	}
+	if len(exitHooks) == 0 {
+		return
+	}
+	runningExitHooks = true
+	defer func() {
+		if x := recover(); x != nil {
+			throw("internal error: exit hook invoked panic")
+		}
+	}()
+	for i := range exitHooks {
+		h := exitHooks[len(exitHooks)-i-1]
+		if exitCode != 0 && !h.runOnNonZeroExit {
+			continue
+		}
+		h.f()
+	}
+	exitHooks = nil
+	runningExitHooks = false
+}
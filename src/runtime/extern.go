@@ -17,6 +17,15 @@ GOGC 变量设置初始垃圾收集目标百分比。当新分配的数据与上
 runtime/debug 包的 SetGCPercent 函数允许在运行时改变这个百分比的值。
 请参见 https://golang.org/pkg/runtime/debug/#SetGCPercent。
 
+GOMEMLIMIT 变量为运行时设置一个软内存限制(soft memory limit)。该限制包括 Go 堆以及运行时管理的所有其他内存，
+但不包括二进制文件本身的映射、其他语言管理的内存以及操作系统代表 Go 程序持有的内存。
+GOMEMLIMIT 是一个以字节为单位的数值，可带有可选的单位后缀。支持的后缀包括 B、KiB、MiB、GiB 和 TiB。
+当总内存接近该限制时，垃圾收集器会更频繁地运行，并更积极地将空闲内存归还给操作系统。
+为避免垃圾收集陷入"死亡螺旋"，当垃圾收集占用过多 CPU 时间时，运行时允许内存超出该限制。
+默认情况下 GOMEMLIMIT=off，即不设置限制。即使 GOGC=off，该限制仍然有效，此时仅在接近限制时才进行垃圾收集。
+runtime/debug 包的 SetMemoryLimit 函数允许在运行时改变这个限制。
+请参见 https://golang.org/pkg/runtime/debug/#SetMemoryLimit。
+
 GODEBUG 变量控制运行时中的调试变量(debugging variables)。
 它是一个逗号分隔的成对出现的 name=val 组成列表，用于设置这些指定的变量:
 
@@ -0,0 +1,109 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package metrics_test
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"runtime/metrics"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestDescriptionNameFormat(t *testing.T) {
+	r := regexp.MustCompile("^(?P<name>/[^:]+):(?P<unit>[^:*/]+(?:[*/][^:*/]+)*)$")
+	descriptions := metrics.All()
+	for _, desc := range descriptions {
+		if !r.MatchString(desc.Name) {
+			t.Errorf("metrics %q does not match regexp %s", desc.Name, r)
+		}
+	}
+}
+
+func TestDescriptionsSorted(t *testing.T) {
+	descriptions := metrics.All()
+	if !sort.SliceIsSorted(descriptions, func(i, j int) bool {
+		return descriptions[i].Name < descriptions[j].Name
+	}) {
+		t.Error("metric descriptions are not sorted by name")
+	}
+}
+
+func formatDesc(t *testing.T) string {
+	var b strings.Builder
+	for i, d := range metrics.All() {
+		if i > 0 {
+			fmt.Fprintf(&b, "\n")
+		}
+		fmt.Fprintf(&b, "\t%s\n", d.Name)
+		fmt.Fprintf(&b, "%s", wrap("\t\t", strings.ReplaceAll(d.Description, "\n", " "), 80-2*8))
+	}
+	return b.String()
+}
+
+var generate = flag.Bool("generate", false, "update doc.go for go generate")
+
+func TestDocs(t *testing.T) {
+	want := "\n" + formatDesc(t)
+
+	src, err := ioutil.ReadFile("doc.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	label := "\nBelow is the full list of supported metrics, ordered lexicographically.\n"
+	i := bytes.Index(src, []byte(label))
+	if i < 0 {
+		t.Fatalf("doc.go: cannot find label")
+	}
+	i += len(label)
+	j := bytes.Index(src[i:], []byte("*/\n"))
+	if j < 0 {
+		t.Fatalf("doc.go: cannot find end of doc comment")
+	}
+	got := string(src[i : i+j])
+	if got != want {
+		if *generate {
+			out := append(src[:i:i], want...)
+			out = append(out, src[i+j:]...)
+			if err := ioutil.WriteFile("doc.go", out, 0666); err != nil {
+				t.Fatal(err)
+			}
+			t.Logf("updated doc.go")
+			return
+		}
+		t.Fatalf("doc.go comment is out of date; run 'go test -run=TestDocs -generate' in runtime/metrics")
+	}
+}
+
+// wrap wraps text to width columns, prefixing each line with prefix.
+func wrap(prefix, text string, width int) string {
+	doc := &strings.Builder{}
+	w := bufio.NewWriter(doc)
+	defer w.Flush()
+
+	line := 0
+	for _, word := range strings.Fields(text) {
+		if line > 0 && line+1+len(word) > width {
+			w.WriteString("\n")
+			line = 0
+		}
+		if line == 0 {
+			w.WriteString(prefix)
+		} else {
+			w.WriteString(" ")
+			line++
+		}
+		w.WriteString(word)
+		line += len(word)
+	}
+	w.WriteString("\n")
+	w.Flush()
+	return doc.String()
+}
@@ -0,0 +1,211 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package runtime_test
+
+import (
+	"runtime"
+	"runtime/debug"
+	"runtime/metrics"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"unsafe"
+)
+
+func prepareAllMetricsSamples() (map[string]metrics.Description, []metrics.Sample) {
+	all := metrics.All()
+	samples := make([]metrics.Sample, len(all))
+	descs := make(map[string]metrics.Description)
+	for i := range all {
+		samples[i].Name = all[i].Name
+		descs[all[i].Name] = all[i]
+	}
+	return descs, samples
+}
+
+func TestReadMetrics(t *testing.T) {
+	// Tests whether readMetrics produces values aligning
+	// with ReadMemStats while the world is stopped.
+	var mstats runtime.MemStats
+	_, samples := prepareAllMetricsSamples()
+
+	// Read once so that histograms are allocated before
+	// the values are compared.
+	metrics.Read(samples)
+	runtime.ReadMetricsSlow(&mstats, unsafe.Pointer(&samples[0]), len(samples), cap(samples))
+
+	checkUint64 := func(t *testing.T, m string, got, want uint64) {
+		t.Helper()
+		if got != want {
+			t.Errorf("metric %q: got %d, want %d", m, got, want)
+		}
+	}
+
+	// Check to make sure the values we read line up with other values we read.
+	for i := range samples {
+		switch name := samples[i].Name; name {
+		case "/gc/cycles/automatic:gc-cycles":
+			checkUint64(t, name, samples[i].Value.Uint64(), uint64(mstats.NumGC-mstats.NumForcedGC))
+		case "/gc/cycles/forced:gc-cycles":
+			checkUint64(t, name, samples[i].Value.Uint64(), uint64(mstats.NumForcedGC))
+		case "/gc/cycles/total:gc-cycles":
+			checkUint64(t, name, samples[i].Value.Uint64(), uint64(mstats.NumGC))
+		case "/gc/gomemlimit:bytes":
+			checkUint64(t, name, samples[i].Value.Uint64(), uint64(debug.SetMemoryLimit(-1)))
+		case "/gc/heap/goal:bytes":
+			checkUint64(t, name, samples[i].Value.Uint64(), mstats.NextGC)
+		case "/gc/pauses:seconds":
+			// Every GC cycle has at least two stop-the-world pauses.
+			var total uint64
+			for _, c := range samples[i].Value.Float64Histogram().Counts {
+				total += c
+			}
+			if total < 2*uint64(mstats.NumGC) {
+				t.Errorf("metric %q: got %d pauses, want at least %d", name, total, 2*mstats.NumGC)
+			}
+		case "/memory/classes/heap/free:bytes":
+			checkUint64(t, name, samples[i].Value.Uint64(), mstats.HeapIdle-mstats.HeapReleased)
+		case "/memory/classes/heap/in-use:bytes":
+			checkUint64(t, name, samples[i].Value.Uint64(), mstats.HeapInuse)
+		case "/memory/classes/heap/released:bytes":
+			checkUint64(t, name, samples[i].Value.Uint64(), mstats.HeapReleased)
+		case "/memory/classes/heap/stacks:bytes":
+			checkUint64(t, name, samples[i].Value.Uint64(), mstats.StackInuse)
+		case "/memory/classes/metadata/mcache/free:bytes":
+			checkUint64(t, name, samples[i].Value.Uint64(), mstats.MCacheSys-mstats.MCacheInuse)
+		case "/memory/classes/metadata/mcache/inuse:bytes":
+			checkUint64(t, name, samples[i].Value.Uint64(), mstats.MCacheInuse)
+		case "/memory/classes/metadata/mspan/free:bytes":
+			checkUint64(t, name, samples[i].Value.Uint64(), mstats.MSpanSys-mstats.MSpanInuse)
+		case "/memory/classes/metadata/mspan/inuse:bytes":
+			checkUint64(t, name, samples[i].Value.Uint64(), mstats.MSpanInuse)
+		case "/memory/classes/metadata/other:bytes":
+			checkUint64(t, name, samples[i].Value.Uint64(), mstats.GCSys)
+		case "/memory/classes/os-stacks:bytes":
+			checkUint64(t, name, samples[i].Value.Uint64(), mstats.StackSys-mstats.StackInuse)
+		case "/memory/classes/other:bytes":
+			checkUint64(t, name, samples[i].Value.Uint64(), mstats.OtherSys)
+		case "/memory/classes/profiling/buckets:bytes":
+			checkUint64(t, name, samples[i].Value.Uint64(), mstats.BuckHashSys)
+		case "/memory/classes/total:bytes":
+			checkUint64(t, name, samples[i].Value.Uint64(), mstats.Sys)
+		case "/sched/goroutines:goroutines":
+			checkUint64(t, name, samples[i].Value.Uint64(), uint64(runtime.NumGoroutine()))
+		}
+	}
+}
+
+func TestReadMetricsConsistency(t *testing.T) {
+	// Tests whether readMetrics produces consistent, sensible values.
+	// The values are read concurrently with the runtime doing other
+	// things (e.g. allocating) so what we read can't reasonably compared
+	// to runtime values.
+
+	// Run a couple of GC cycles and start some goroutines to make sure
+	// the distributions have something in them.
+	runtime.GC()
+	runtime.GC()
+	var wg sync.WaitGroup
+	for i := 0; i < 64; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				runtime.Gosched()
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Read all the supported metrics through the metrics package.
+	descs, samples := prepareAllMetricsSamples()
+	metrics.Read(samples)
+
+	// Check to make sure the values we read make sense.
+	var totalVirtual struct {
+		got, want uint64
+	}
+	var gc struct {
+		numGC, numForced, numAuto uint64
+	}
+	for i := range samples {
+		kind := samples[i].Value.Kind()
+		if want := descs[samples[i].Name].Kind; kind != want {
+			t.Errorf("supported metric %q has unexpected kind: got %d, want %d", samples[i].Name, kind, want)
+			continue
+		}
+		if samples[i].Name != "/memory/classes/total:bytes" && strings.HasPrefix(samples[i].Name, "/memory/classes") {
+			v := samples[i].Value.Uint64()
+			totalVirtual.want += v
+
+			// None of these stats should ever get this big.
+			// If they do, there's probably overflow involved,
+			// usually due to bad accounting.
+			if int64(v) < 0 {
+				t.Errorf("%q has high/negative value: %d", samples[i].Name, v)
+			}
+		}
+		switch samples[i].Name {
+		case "/memory/classes/total:bytes":
+			totalVirtual.got = samples[i].Value.Uint64()
+		case "/gc/cycles/total:gc-cycles":
+			gc.numGC = samples[i].Value.Uint64()
+		case "/gc/cycles/automatic:gc-cycles":
+			gc.numAuto = samples[i].Value.Uint64()
+		case "/gc/cycles/forced:gc-cycles":
+			gc.numForced = samples[i].Value.Uint64()
+		case "/sched/goroutines:goroutines":
+			if samples[i].Value.Uint64() < 1 {
+				t.Error("number of goroutines is less than one")
+			}
+		case "/gc/pauses:seconds", "/sched/latencies:seconds":
+			h := samples[i].Value.Float64Histogram()
+			if len(h.Buckets) != len(h.Counts)+1 {
+				t.Errorf("%q: got %d buckets for %d counts", samples[i].Name, len(h.Buckets), len(h.Counts))
+				continue
+			}
+			if !sort.Float64sAreSorted(h.Buckets) {
+				t.Errorf("%q: buckets are not sorted", samples[i].Name)
+			}
+			var total uint64
+			for _, c := range h.Counts {
+				total += c
+			}
+			if total == 0 {
+				t.Errorf("%q: histogram is empty", samples[i].Name)
+			}
+		}
+	}
+	if totalVirtual.got != totalVirtual.want {
+		t.Errorf(`"/memory/classes/total:bytes" does not match sum of /memory/classes/**: got %d, want %d`, totalVirtual.got, totalVirtual.want)
+	}
+	if gc.numForced+gc.numAuto != gc.numGC {
+		t.Errorf("automatic GCs (%d) + forced GCs (%d) != total GCs (%d)", gc.numAuto, gc.numForced, gc.numGC)
+	}
+	if gc.numForced < 2 {
+		t.Errorf("expected at least 2 forced GCs, got %d", gc.numForced)
+	}
+}
+
+func TestReadMetricsUnknown(t *testing.T) {
+	samples := []metrics.Sample{{Name: "/does/not/exist:bytes"}}
+	metrics.Read(samples)
+	if kind := samples[0].Value.Kind(); kind != metrics.KindBad {
+		t.Errorf("unknown metric has kind %d, want KindBad", kind)
+	}
+	// Reading an empty slice is a no-op.
+	metrics.Read(nil)
+}
+
+func BenchmarkReadMetrics(b *testing.B) {
+	_, samples := prepareAllMetricsSamples()
+	metrics.Read(samples)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		metrics.Read(samples)
+	}
+}
@@ -185,8 +185,10 @@ func gcinit() {
 	// This will go into computing the initial GC goal.
 	memstats.heap_marked = uint64(float64(heapminimum) / (1 + memstats.triggerRatio))
 
-	// Set gcpercent from the environment. This will also compute
-	// and set the GC trigger and goal.
+	// Set the memory limit and gcpercent from the environment.
+	// Setting gcpercent will also compute and set the GC trigger
+	// and goal.
+	memoryLimit = readGOMEMLIMIT()
 	_ = setGCPercent(readgogc())
 
 	work.startSema = 1
@@ -487,11 +489,16 @@ func (c *gcControllerState) startCycle() {
 // is when assists are enabled and the necessary statistics are
 // available).
 func (c *gcControllerState) revise() {
-	gcpercent := gcpercent
+	growthRatio := float64(gcpercent) / 100
 	if gcpercent < 0 {
 		// If GC is disabled but we're running a forced GC,
 		// act like GOGC is huge for the below calculations.
-		gcpercent = 100000
+		growthRatio = 1000
+	}
+	if r := gcEffectiveGrowthRatio(); r < growthRatio {
+		// The memory limit has lowered the heap goal, so less
+		// of the heap is expected to be garbage.
+		growthRatio = r
 	}
 	live := atomic.Load64(&memstats.heap_live)
 
@@ -508,7 +515,7 @@ func (c *gcControllerState) revise() {
 	//
 	// (This is a float calculation to avoid overflowing on
 	// 100*heap_scan.)
-	scanWorkExpected := int64(float64(memstats.heap_scan) * 100 / float64(100+gcpercent))
+	scanWorkExpected := int64(float64(memstats.heap_scan) / (1 + growthRatio))
 
 	if live > memstats.next_gc || c.scanWork > scanWorkExpected {
 		// We're past the soft goal, or we've already done more scan
@@ -757,7 +764,7 @@ func pollFractionalWorkerExit() bool {
 // This can be called any time. If GC is the in the middle of a
 // concurrent phase, it will adjust the pacing of that phase.
 //
-// This depends on gcpercent, memstats.heap_marked, and
+// This depends on gcpercent, memoryLimit, memstats.heap_marked, and
 // memstats.heap_live. These must be up to date.
 //
 // mheap_.lock must be held or the world must be stopped.
@@ -814,8 +821,29 @@ func gcSetTriggerRatio(triggerRatio float64) {
 	trigger := ^uint64(0)
 	if gcpercent >= 0 {
 		trigger = uint64(float64(memstats.heap_marked) * (1 + triggerRatio))
-		// Don't trigger below the minimum heap size.
+	}
+
+	// If the memory limit implies a lower goal, use it instead. Place the
+	// trigger the same fraction of the way from heap_marked to the goal
+	// as the trigger ratio would for the GOGC-based goal.
+	limited := false
+	if limitGoal := gcMemoryLimitHeapGoal(); limitGoal < goal {
+		runway := memoryLimitTriggerRunway
+		if gcpercent > 0 {
+			runway = triggerRatio / (float64(gcpercent) / 100)
+		}
+		goal = limitGoal
+		trigger = memstats.heap_marked + uint64(float64(goal-memstats.heap_marked)*runway)
+		limited = true
+	}
+
+	if trigger != ^uint64(0) {
+		// Don't trigger below the minimum heap size, unless the
+		// memory limit asks for a smaller heap.
 		minTrigger := heapminimum
+		if limited {
+			minTrigger = 0
+		}
 		if !isSweepDone() {
 			// Concurrent sweep happens in the heap growth
 			// from heap_live to gc_trigger, so ensure
@@ -1274,6 +1302,12 @@ func gcStart(trigger gcTrigger) {
 	} else if debug.gcstoptheworld == 2 {
 		mode = gcForceBlockMode
 	}
+	// A goroutine leak detection cycle must not let user goroutines
+	// run while it marks. See mgcleak.go.
+	leak := atomic.Load(&goroutineLeak.requested) != 0
+	if leak && mode == gcBackgroundMode {
+		mode = gcForceMode
+	}
 
 	// Ok, we're doing it! Stop everybody else
 	semacquire(&gcsema)
@@ -1322,6 +1356,11 @@ func gcStart(trigger gcTrigger) {
 	clearpools()
 
 	work.cycles++
+	if leak {
+		goroutineLeak.enabled = true
+		atomic.Store(&goroutineLeak.cycle, work.cycles)
+		atomic.Store(&goroutineLeak.requested, 0)
+	}
 
 	gcController.startCycle()
 	work.heapGoal = memstats.next_gc
@@ -1347,6 +1386,11 @@ func gcStart(trigger gcTrigger) {
 	// allocations are blocked until assists can
 	// happen, we want enable assists as early as
 	// possible.
+	// Leak detection must hide g.waiting before write barriers
+	// are enabled.
+	if goroutineLeak.enabled {
+		gcLeakPrepare()
+	}
 	setGCPhase(_GCmark)
 
 	gcBgMarkPrepare() // Must happen before assist enable.
@@ -1378,6 +1422,7 @@ func gcStart(trigger gcTrigger) {
 	systemstack(func() {
 		now = startTheWorldWithSema(trace.enabled)
 		work.pauseNS += now - work.pauseStart
+		memstats.gcPauseDist.record(now - work.pauseStart)
 		work.tMark = now
 	})
 
@@ -1596,6 +1641,27 @@ top:
 			systemstack(func() {
 				now := startTheWorldWithSema(true)
 				work.pauseNS += now - work.pauseStart
+				memstats.gcPauseDist.record(now - work.pauseStart)
+			})
+			semrelease(&worldsema)
+			goto top
+		}
+	}
+
+	// During goroutine leak detection, marking is not complete
+	// until every candidate's stack has been scanned. Scanning
+	// more stacks finds more work, so resume concurrent mark.
+	if goroutineLeak.enabled {
+		var more bool
+		systemstack(func() {
+			more = gcLeakScan()
+		})
+		if more {
+			getg().m.preemptoff = ""
+			systemstack(func() {
+				now := startTheWorldWithSema(true)
+				work.pauseNS += now - work.pauseStart
+				memstats.gcPauseDist.record(now - work.pauseStart)
 			})
 			semrelease(&worldsema)
 			goto top
@@ -1700,14 +1766,12 @@ func gcMarkTermination(nextTriggerRatio float64) {
 	memstats.last_next_gc = memstats.next_gc
 	memstats.last_heap_inuse = memstats.heap_inuse
 
-	// Update GC trigger and pacing for the next cycle.
-	gcSetTriggerRatio(nextTriggerRatio)
-
 	// Update timing memstats
 	now := nanotime()
 	sec, nsec, _ := time_now()
 	unixNow := sec*1e9 + int64(nsec)
 	work.pauseNS += now - work.pauseStart
+	memstats.gcPauseDist.record(now - work.pauseStart)
 	work.tEnd = now
 	atomic.Store64(&memstats.last_gc_unix, uint64(unixNow)) // must be Unix time to make sense to user
 	atomic.Store64(&memstats.last_gc_nanotime, uint64(now)) // monotonic time for us
@@ -1728,6 +1792,12 @@ func gcMarkTermination(nextTriggerRatio float64) {
 	totalCpu := sched.totaltime + (now-sched.procresizetime)*int64(gomaxprocs)
 	memstats.gc_cpu_fraction = float64(work.totaltime) / float64(totalCpu)
 
+	// Update GC trigger and pacing for the next cycle. This comes after
+	// the CPU accounting so the memory limit's pacing can account for
+	// this cycle's CPU use.
+	gcCPULimiter.update(cycleCpu, now)
+	gcSetTriggerRatio(nextTriggerRatio)
+
 	// Reset sweep state.
 	sweep.nbgsweep = 0
 	sweep.npausesweep = 0
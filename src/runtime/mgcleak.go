@@ -0,0 +1,295 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Garbage collector: goroutine leak detection
+//
+// A goroutine blocked on a channel, mutex or condition variable can
+// only be woken by another goroutine that can reach the same object.
+// If no running goroutine can reach it, directly or through other
+// goroutines that may yet be woken, the goroutine is blocked forever:
+// it has leaked.
+//
+// A leak detection cycle is an ordinary GC cycle in which the stacks
+// of goroutines blocked on such objects ("candidates") are not treated
+// as roots. Instead, when marking otherwise completes, gcLeakScan
+// checks which candidates are blocked on an object that has been
+// marked, scans their stacks, and resumes marking. Once no more
+// candidates become reachable this way, the remaining candidates are
+// leaked. Their stacks are scanned too, so the cycle frees exactly
+// what an ordinary cycle would.
+//
+// Two references from always-reachable runtime structures would make
+// every blocking object look reachable. A g points to the sudogs of
+// its channel operations through g.waiting, so that list is hidden
+// from the GC in g.leakWaiting while the candidate's stack is not yet
+// scanned. The semaphore table refers to semaphores only through
+// sudog.semaddr, which is not a pointer.
+//
+// User goroutines do not run during a leak detection cycle (it uses
+// gcForceMode), so candidates cannot run while g.waiting is hidden.
+// They may still be readied by the runtime, for example by a timer
+// sending on a channel; such goroutines are no longer candidates.
+
+package runtime
+
+import (
+	"runtime/internal/atomic"
+	"unsafe"
+)
+
+var goroutineLeak struct {
+	// requested is set by goroutineLeakGC to make the next GC
+	// cycle a leak detection cycle. Accessed atomically.
+	requested uint32
+
+	// enabled is set during a leak detection cycle until all
+	// candidates have been classified. It is only written with
+	// the world stopped.
+	enabled bool
+
+	// cycle is the number of the most recent leak detection cycle,
+	// as in work.cycles. Accessed atomically.
+	cycle uint32
+}
+
+// isLeakCandidate reports whether gp is blocked in a way that only
+// another goroutine reaching the object it is blocked on can end.
+func isLeakCandidate(gp *g) bool {
+	if readgstatus(gp) != _Gwaiting || isSystemGoroutine(gp, false) {
+		return false
+	}
+	switch gp.waitreason {
+	case waitReasonChanReceive, waitReasonChanSend, waitReasonSelect,
+		waitReasonChanReceiveNilChan, waitReasonChanSendNilChan, waitReasonSelectNoCases,
+		waitReasonSemacquire, waitReasonSyncCondWait:
+		return true
+	}
+	return false
+}
+
+// gcLeakPrepare selects the candidates of a leak detection cycle and
+// hides their g.waiting lists from the GC.
+//
+// The world must be stopped and write barriers must not yet be
+// enabled, since a write barrier on g.waiting would shade the sudogs.
+func gcLeakPrepare() {
+	if writeBarrier.enabled {
+		throw("gcLeakPrepare with write barrier enabled")
+	}
+	for _, gp := range allgs {
+		gp.leaked = false
+		if !isLeakCandidate(gp) {
+			continue
+		}
+		gp.leakCandidate = true
+		gp.leakWaiting = uintptr(unsafe.Pointer(gp.waiting))
+		gp.waiting = nil
+	}
+}
+
+// gcLeakScan scans the stacks of candidates that have become
+// reachable. If there are none, it records the remaining candidates
+// as leaked and scans their stacks, ending leak detection for this
+// cycle. It reports whether it found more mark work.
+//
+// The world must be stopped and marking must otherwise be complete.
+//
+//go:systemstack
+func gcLeakScan() bool {
+	gcw := &getg().m.p.ptr().gcw
+	scanned := false
+	for _, gp := range allgs {
+		if gp.leakCandidate && leakReachable(gp) {
+			gcLeakScanStack(gp, gcw)
+			scanned = true
+		}
+	}
+	if !scanned {
+		for _, gp := range allgs {
+			if gp.leakCandidate {
+				gp.leaked = true
+				gcLeakScanStack(gp, gcw)
+				scanned = true
+			}
+		}
+		goroutineLeak.enabled = false
+	}
+	if scanned {
+		// Make the work visible to the other Ps.
+		gcw.dispose()
+	}
+	return scanned
+}
+
+// leakReachable reports whether candidate gp may still be woken,
+// either because it has been readied or because an object it is
+// blocked on has been marked.
+func leakReachable(gp *g) bool {
+	if readgstatus(gp) != _Gwaiting {
+		return true
+	}
+	switch gp.waitreason {
+	case waitReasonChanReceive, waitReasonChanSend, waitReasonSelect:
+		for sg := (*sudog)(unsafe.Pointer(gp.leakWaiting)); sg != nil; sg = sg.waitlink {
+			if leakObjectMarked(uintptr(unsafe.Pointer(sg.c))) {
+				return true
+			}
+		}
+	case waitReasonSemacquire, waitReasonSyncCondWait:
+		return leakObjectMarked(gp.syncobj)
+	}
+	// Blocked on a nil channel or in an empty select.
+	return false
+}
+
+// leakObjectMarked reports whether the object containing p has been
+// marked in the current cycle. Memory outside the heap, such as
+// global variables, counts as marked.
+func leakObjectMarked(p uintptr) bool {
+	base, span, objIndex := findObject(p, 0, 0)
+	if base == 0 {
+		return true
+	}
+	return span.markBitsForIndex(objIndex).isMarked()
+}
+
+// gcLeakScanStack restores gp.waiting and scans gp's stack, which
+// markroot skipped.
+//
+//go:systemstack
+func gcLeakScanStack(gp *g, gcw *gcWork) {
+	gp.leakCandidate = false
+	gp.waiting = (*sudog)(unsafe.Pointer(gp.leakWaiting))
+	gp.leakWaiting = 0
+
+	// The user G that got here through gcMarkDone is running;
+	// put it in _Gwaiting so suspendG does not see a
+	// non-preemptible caller.
+	userG := getg().m.curg
+	running := userG != nil && readgstatus(userG) == _Grunning
+	if running {
+		casgstatus(userG, _Grunning, _Gwaiting)
+		userG.waitreason = waitReasonGarbageCollectionScan
+	}
+	stopped := suspendG(gp)
+	if stopped.dead {
+		gp.gcscandone = true
+	} else {
+		if gp.gcscandone {
+			throw("g already scanned")
+		}
+		scanstack(gp, gcw)
+		gp.gcscandone = true
+		resumeG(stopped)
+	}
+	if running {
+		casgstatus(userG, _Gwaiting, _Grunning)
+	}
+}
+
+// goroutineLeakGC runs a leak detection cycle and waits for it to
+// complete. Afterwards g.leaked is set for the leaked goroutines.
+//
+// User goroutines do not run while the cycle marks.
+func goroutineLeakGC() {
+	atomic.Store(&goroutineLeak.requested, 1)
+	// Another goroutine may start the next cycle before it sees the
+	// request, so keep starting cycles until one has picked it up.
+	for atomic.Load(&goroutineLeak.requested) != 0 {
+		n := atomic.Load(&work.cycles)
+		gcWaitOnMark(n)
+		gcStart(gcTrigger{kind: gcTriggerCycle, n: n + 1})
+	}
+	gcWaitOnMark(atomic.Load(&goroutineLeak.cycle))
+}
+
+//go:linkname runtime_goroutineLeakGC runtime/pprof.runtime_goroutineLeakGC
+func runtime_goroutineLeakGC() {
+	goroutineLeakGC()
+}
+
+//go:linkname runtime_goroutineLeakProfileWithLabels runtime/pprof.runtime_goroutineLeakProfileWithLabels
+func runtime_goroutineLeakProfileWithLabels(p []StackRecord, labels []unsafe.Pointer) (n int, ok bool) {
+	return goroutineLeakProfileWithLabels(p, labels)
+}
+
+// goroutineLeakProfileWithLabels is like goroutineProfileWithLabels,
+// but only returns the goroutines that the most recent leak detection
+// cycle found leaked and that are still blocked.
+// labels may be nil. If labels is non-nil, it must have the same length as p.
+func goroutineLeakProfileWithLabels(p []StackRecord, labels []unsafe.Pointer) (n int, ok bool) {
+	if labels != nil && len(labels) != len(p) {
+		labels = nil
+	}
+
+	isOK := func(gp1 *g) bool {
+		return gp1.leaked && readgstatus(gp1) == _Gwaiting
+	}
+
+	stopTheWorld("profile")
+
+	for _, gp1 := range allgs {
+		if isOK(gp1) {
+			n++
+		}
+	}
+
+	if n <= len(p) {
+		ok = true
+		r, lbl := p, labels
+		for _, gp1 := range allgs {
+			if isOK(gp1) {
+				saveg(^uintptr(0), ^uintptr(0), gp1, &r[0])
+				if labels != nil {
+					lbl[0] = gp1.labels
+					lbl = lbl[1:]
+				}
+				r = r[1:]
+			}
+		}
+	}
+
+	startTheWorld()
+	return n, ok
+}
+
+//go:linkname runtime_goroutineLeakStacks runtime/pprof.runtime_goroutineLeakStacks
+func runtime_goroutineLeakStacks(buf []byte) int {
+	return goroutineLeakStacks(buf)
+}
+
+// goroutineLeakStacks is like Stack(buf, true), but only formats the
+// goroutines that the most recent leak detection cycle found leaked and
+// that are still blocked.
+func goroutineLeakStacks(buf []byte) int {
+	stopTheWorld("stack trace")
+
+	n := 0
+	if len(buf) > 0 {
+		systemstack(func() {
+			g0 := getg()
+			// See the comment in Stack.
+			g0.m.traceback = 1
+			g0.writebuf = buf[0:0:len(buf)]
+			first := true
+			for _, gp := range allgs {
+				if !gp.leaked || readgstatus(gp) != _Gwaiting {
+					continue
+				}
+				if !first {
+					print("\n")
+				}
+				first = false
+				goroutineheader(gp)
+				traceback(^uintptr(0), ^uintptr(0), 0, gp)
+			}
+			g0.m.traceback = 0
+			n = len(g0.writebuf)
+			g0.writebuf = nil
+		})
+	}
+
+	startTheWorld()
+	return n
+}
@@ -0,0 +1,265 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Soft memory limit.
+//
+// The memory limit bounds the total amount of memory mapped by the Go
+// runtime and not returned to the OS: the heap, goroutine stacks, and the
+// runtime's own data structures. It is set by $GOMEMLIMIT or by
+// runtime/debug.SetMemoryLimit and is disabled (math.MaxInt64) by default.
+//
+// The limit is enforced on two fronts. The pacer lowers the heap goal so
+// that the heap, together with the runtime's other memory, stays below the
+// limit (see gcMemoryLimitHeapGoal), and the scavenger lowers its goal for
+// retained heap memory to match, returning free pages to the OS eagerly on
+// heap growth (see gcPaceScavenger).
+//
+// The limit is soft: it never causes an allocation to fail. If the live
+// heap alone approaches the limit, honoring it would mean collecting
+// continuously, a "death spiral" in which the program makes almost no
+// progress. To prevent that, the heap goal always leaves some room over the
+// marked heap, and gcCPULimiter lets the heap grow further past the limit
+// when the GC has recently used more than gcCPULimitUtilization of the
+// available CPU time.
+
+package runtime
+
+import (
+	"runtime/internal/atomic"
+	_ "unsafe" // for go:linkname
+)
+
+const (
+	// maxMemoryLimit is the value of memoryLimit when no limit is set.
+	maxMemoryLimit = 1<<63 - 1
+
+	// memoryLimitHeapGoalHeadroomDivisor sets the fraction of the memory
+	// available to the heap under the limit that the heap goal leaves
+	// unused, to accommodate allocation during the mark phase and
+	// fragmentation.
+	memoryLimitHeapGoalHeadroomDivisor = 16
+
+	// memoryLimitRetainedHeadroomDivisor is like
+	// memoryLimitHeapGoalHeadroomDivisor, but for the memory the scavenger
+	// lets the heap retain.
+	memoryLimitRetainedHeadroomDivisor = 32
+
+	// memoryLimitMinGrowthDivisor sets the minimum heap growth over the
+	// marked heap that the heap goal allows when the memory limit is
+	// binding, so that the GC never runs back-to-back.
+	memoryLimitMinGrowthDivisor = 16
+
+	// memoryLimitTriggerRunway is where the GC trigger is placed between
+	// the marked heap and a heap goal set by the memory limit, when the
+	// GOGC-based trigger ratio does not apply.
+	memoryLimitTriggerRunway = 0.7
+
+	// gcCPULimitUtilization is the fraction of the available CPU time the
+	// GC may use to keep the heap under the memory limit before
+	// gcCPULimiter relaxes the limit.
+	gcCPULimitUtilization = 0.5
+)
+
+// memoryLimit is the soft memory limit in bytes, or maxMemoryLimit if
+// there is none.
+//
+// Protected by mheap_.lock; it may also be read with the world stopped.
+var memoryLimit int64 = maxMemoryLimit
+
+// gcCPULimiter tracks the GC's recent CPU utilization to detect when
+// the memory limit is driving the program into a GC death spiral.
+var gcCPULimiter gcCPULimiterState
+
+// gcCPULimiterState is the state of gcCPULimiter. It is accessed only
+// with the world stopped or under mheap_.lock.
+type gcCPULimiterState struct {
+	// utilization is an exponentially weighted moving average of the
+	// fraction of the available CPU time spent on GC, measured for
+	// each cycle from the end of the previous cycle.
+	utilization float64
+
+	// lastEnd is the nanotime at which the last GC cycle ended,
+	// or 0 before the first cycle.
+	lastEnd int64
+
+	// overLimit is set when utilization exceeds gcCPULimitUtilization
+	// and cleared when it falls below half of it. While it is set, the
+	// memory limit may not hold the heap goal below twice the marked heap.
+	overLimit bool
+}
+
+// update records that a GC cycle that used cycleCPU nanoseconds of CPU
+// time ended at now.
+//
+// The world must be stopped.
+func (l *gcCPULimiterState) update(cycleCPU, now int64) {
+	if l.lastEnd != 0 {
+		if avail := (now - l.lastEnd) * int64(gomaxprocs); avail > 0 {
+			u := float64(cycleCPU) / float64(avail)
+			if u > 1 {
+				u = 1
+			}
+			l.utilization = (l.utilization + u) / 2
+		}
+	}
+	l.lastEnd = now
+
+	if l.utilization > gcCPULimitUtilization {
+		l.overLimit = true
+	} else if l.utilization < gcCPULimitUtilization/2 {
+		l.overLimit = false
+	}
+}
+
+// nonHeapSys returns the memory mapped by the runtime and not returned to
+// the OS for purposes other than heap objects: goroutine stacks, span and
+// mcache structures, GC metadata, profiling buckets, and other runtime data.
+func nonHeapSys() uint64 {
+	return atomic.Load64(&memstats.stacks_inuse) +
+		atomic.Load64(&memstats.stacks_sys) +
+		atomic.Load64(&memstats.mspan_sys) +
+		atomic.Load64(&memstats.mcache_sys) +
+		atomic.Load64(&memstats.buckhash_sys) +
+		atomic.Load64(&memstats.gc_sys) +
+		atomic.Load64(&memstats.other_sys)
+}
+
+// memoryLimitHeapAvail returns the memory that the memory limit leaves
+// for the heap after the runtime's other memory, and whether a memory
+// limit is set.
+//
+// mheap_.lock must be held or the world must be stopped.
+func memoryLimitHeapAvail() (uint64, bool) {
+	if memoryLimit == maxMemoryLimit {
+		return 0, false
+	}
+	nonHeap := nonHeapSys()
+	if uint64(memoryLimit) <= nonHeap {
+		return 0, true
+	}
+	return uint64(memoryLimit) - nonHeap, true
+}
+
+// gcMemoryLimitHeapGoal returns the heap goal implied by the memory limit,
+// or ^uint64(0) if there is no limit.
+//
+// The goal is bounded below so that the GC never runs back-to-back, and
+// while gcCPULimiter reports that the GC has been using too much CPU, the
+// bound is twice the marked heap: the limit then stops lowering the goal
+// below what GOGC=100 would give.
+//
+// mheap_.lock must be held or the world must be stopped.
+func gcMemoryLimitHeapGoal() uint64 {
+	avail, ok := memoryLimitHeapAvail()
+	if !ok {
+		return ^uint64(0)
+	}
+	goal := avail - avail/memoryLimitHeapGoalHeadroomDivisor
+
+	minGoal := memstats.heap_marked + memstats.heap_marked/memoryLimitMinGrowthDivisor
+	if gcCPULimiter.overLimit {
+		minGoal = memstats.heap_marked * 2
+	}
+	if goal < minGoal {
+		goal = minGoal
+	}
+	return goal
+}
+
+// memoryLimitRetainedGoal returns the maximum amount of memory the heap
+// should retain under the memory limit, as a goal for the scavenger, or
+// ^uint64(0) if there is no limit.
+//
+// mheap_.lock must be held or the world must be stopped.
+func memoryLimitRetainedGoal() uint64 {
+	avail, ok := memoryLimitHeapAvail()
+	if !ok {
+		return ^uint64(0)
+	}
+	return avail - avail/memoryLimitRetainedHeadroomDivisor
+}
+
+// readGOMEMLIMIT returns the memory limit set by $GOMEMLIMIT,
+// or maxMemoryLimit if it is unset or "off".
+func readGOMEMLIMIT() int64 {
+	p := gogetenv("GOMEMLIMIT")
+	if p == "" || p == "off" {
+		return maxMemoryLimit
+	}
+	n, ok := parseByteCount(p)
+	if !ok {
+		print("GOMEMLIMIT=", p, "\n")
+		throw("malformed GOMEMLIMIT; see `go doc runtime`")
+	}
+	return n
+}
+
+// parseByteCount parses a string that represents a count of bytes:
+// a non-negative decimal integer, optionally followed by one of the
+// unit suffixes B, KiB, MiB, GiB, or TiB. It reports false if s is not
+// valid or the count overflows an int64.
+func parseByteCount(s string) (int64, bool) {
+	if s == "" {
+		return 0, false
+	}
+	// The suffix B by itself, or a binary-prefixed unit.
+	unit := int64(1)
+	if s[len(s)-1] == 'B' {
+		s = s[:len(s)-1]
+		if len(s) >= 2 && s[len(s)-1] == 'i' {
+			switch s[len(s)-2] {
+			case 'K':
+				unit = 1 << 10
+			case 'M':
+				unit = 1 << 20
+			case 'G':
+				unit = 1 << 30
+			case 'T':
+				unit = 1 << 40
+			default:
+				return 0, false
+			}
+			s = s[:len(s)-2]
+		}
+	}
+	if s == "" {
+		return 0, false
+	}
+	var n int64
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c < '0' || c > '9' {
+			return 0, false
+		}
+		if n > (maxMemoryLimit-int64(c-'0'))/10 {
+			return 0, false
+		}
+		n = n*10 + int64(c-'0')
+	}
+	if n > maxMemoryLimit/unit {
+		return 0, false
+	}
+	return n * unit, true
+}
+
+//go:linkname setMemoryLimit runtime/debug.setMemoryLimit
+func setMemoryLimit(in int64) (out int64) {
+	// Run on the system stack since we grab the heap lock.
+	systemstack(func() {
+		lock(&mheap_.lock)
+		out = memoryLimit
+		if in >= 0 {
+			memoryLimit = in
+			// Update pacing and the scavenger's goal in response
+			// to the new limit.
+			gcSetTriggerRatio(memstats.triggerRatio)
+		}
+		unlock(&mheap_.lock)
+	})
+	if in >= 0 && in < out {
+		// The scavenger may have work to do under the lower limit.
+		wakeScavenger()
+	}
+	return out
+}
@@ -213,6 +213,12 @@ func markroot(gcw *gcWork, i uint32) {
 			gp.waitsince = work.tstart
 		}
 
+		if gp.leakCandidate {
+			// Goroutine leak detection scans this stack once
+			// it knows whether gp is reachable. See gcLeakScan.
+			return
+		}
+
 		// scanstack must be done on the system stack in case
 		// we're trying to scan our own stack.
 		systemstack(func() {
@@ -47,6 +47,10 @@
 // horizontal axis is time and vertical axis is estimated heap RSS, and the
 // scavenger attempts to stay below that line at all times.
 //
+// If a memory limit is set (see mgclimit.go), the goal is also capped at the
+// memory the limit leaves for the heap after the runtime's other memory.
+// This cap applies from the start of the program.
+//
 // The synchronous heap-growth scavenging happens whenever the heap grows in
 // size, for some definition of heap-growth. The intuition behind this is that
 // the application had to grow the heap because existing fragments were
@@ -114,22 +118,32 @@ func heapRetained() uint64 {
 //
 // mheap_.lock must be held or the world must be stopped.
 func gcPaceScavenger() {
-	// If we're called before the first GC completed, disable scavenging.
-	// We never scavenge before the 2nd GC cycle anyway (we don't have enough
-	// information about the heap yet) so this is fine, and avoids a fault
-	// or garbage data later.
-	if memstats.last_next_gc == 0 {
+	// Compute our scavenging goal.
+	//
+	// If we're called before the first GC completed, only the memory limit
+	// sets a goal. We never scavenge for the heap goal before the 2nd GC
+	// cycle anyway (we don't have enough information about the heap yet)
+	// so this is fine, and avoids a fault or garbage data later.
+	retainedGoal := ^uint64(0)
+	if memstats.last_next_gc != 0 {
+		goalRatio := float64(memstats.next_gc) / float64(memstats.last_next_gc)
+		retainedGoal = uint64(float64(memstats.last_heap_inuse) * goalRatio)
+		// Add retainExtraPercent overhead to retainedGoal. This calculation
+		// looks strange but the purpose is to arrive at an integer division
+		// (e.g. if retainExtraPercent = 12.5, then we get a divisor of 8)
+		// that also avoids the overflow from a multiplication.
+		retainedGoal += retainedGoal / (1.0 / (retainExtraPercent / 100.0))
+	}
+	// Under a memory limit, the heap must not retain more than the limit
+	// leaves it. Because heap growth scavenges down to this goal (see
+	// mheap.grow), the heap returns free memory eagerly near the limit.
+	if limitGoal := memoryLimitRetainedGoal(); limitGoal < retainedGoal {
+		retainedGoal = limitGoal
+	}
+	if retainedGoal == ^uint64(0) {
 		mheap_.scavengeGoal = ^uint64(0)
 		return
 	}
-	// Compute our scavenging goal.
-	goalRatio := float64(memstats.next_gc) / float64(memstats.last_next_gc)
-	retainedGoal := uint64(float64(memstats.last_heap_inuse) * goalRatio)
-	// Add retainExtraPercent overhead to retainedGoal. This calculation
-	// looks strange but the purpose is to arrive at an integer division
-	// (e.g. if retainExtraPercent = 12.5, then we get a divisor of 8)
-	// that also avoids the overflow from a multiplication.
-	retainedGoal += retainedGoal / (1.0 / (retainExtraPercent / 100.0))
 	// Align it to a physical page boundary to make the following calculations
 	// a bit more exact.
 	retainedGoal = (retainedGoal + uint64(physPageSize) - 1) &^ (uint64(physPageSize) - 1)
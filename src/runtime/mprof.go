@@ -810,6 +810,43 @@ func Stack(buf []byte, all bool) int {
 	return n
 }
 
+//go:linkname testing_runtime_goid testing.runtime_goid
+func testing_runtime_goid() int64 {
+	return getg().goid
+}
+
+// testing_runtime_goroutineStack is like Stack(buf, false), but formats
+// the stack trace of the goroutine with ID goid instead of the current
+// one. It writes nothing if there is no such goroutine.
+//
+//go:linkname testing_runtime_goroutineStack testing.runtime_goroutineStack
+func testing_runtime_goroutineStack(goid int64, buf []byte) int {
+	stopTheWorld("stack trace")
+
+	n := 0
+	if len(buf) > 0 {
+		systemstack(func() {
+			g0 := getg()
+			// See the comment in Stack.
+			g0.m.traceback = 1
+			g0.writebuf = buf[0:0:len(buf)]
+			for _, gp := range allgs {
+				if gp.goid == goid && readgstatus(gp) != _Gdead {
+					goroutineheader(gp)
+					traceback(^uintptr(0), ^uintptr(0), 0, gp)
+					break
+				}
+			}
+			g0.m.traceback = 0
+			n = len(g0.writebuf)
+			g0.writebuf = nil
+		})
+	}
+
+	startTheWorld()
+	return n
+}
+
 // Tracing of alloc/free/gc.
 
 var tracelock mutex
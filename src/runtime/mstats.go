@@ -142,6 +142,12 @@ type mstats struct {
 	// unlike heap_live, heap_marked does not change until the
 	// next mark termination.
 	heap_marked uint64
+
+	// gcPauseDist represents the distribution of all GC-related
+	// application pauses in the runtime.
+	//
+	// Each individual pause is counted separately, unlike pause_ns.
+	gcPauseDist timeHistogram
 }
 
 var memstats mstats
@@ -385,6 +391,10 @@ func init() {
 		println(unsafe.Offsetof(memstats.heap_live))
 		throw("memstats.heap_live not aligned to 8 bytes")
 	}
+	if unsafe.Offsetof(memstats.gcPauseDist)%8 != 0 {
+		println(unsafe.Offsetof(memstats.gcPauseDist))
+		throw("memstats.gcPauseDist not aligned to 8 bytes")
+	}
 }
 
 // ReadMemStats 用内存分配器统计信息填充 m。
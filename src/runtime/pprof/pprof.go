@@ -99,12 +99,13 @@ import (
 //
 // Each Profile has a unique name. A few profiles are predefined:
 //
-//	goroutine    - stack traces of all current goroutines
-//	heap         - a sampling of memory allocations of live objects
-//	allocs       - a sampling of all past memory allocations
-//	threadcreate - stack traces that led to the creation of new OS threads
-//	block        - stack traces that led to blocking on synchronization primitives
-//	mutex        - stack traces of holders of contended mutexes
+//	goroutine     - stack traces of all current goroutines
+//	goroutineleak - stack traces of goroutines blocked forever
+//	heap          - a sampling of memory allocations of live objects
+//	allocs        - a sampling of all past memory allocations
+//	threadcreate  - stack traces that led to the creation of new OS threads
+//	block         - stack traces that led to blocking on synchronization primitives
+//	mutex         - stack traces of holders of contended mutexes
 //
 // These predefined profiles maintain themselves and panic on an explicit
 // Add or Remove method call.
@@ -126,6 +127,16 @@ import (
 // pprof display to -alloc_space, the total number of bytes allocated since
 // the program began (including garbage-collected bytes).
 //
+// The goroutineleak profile reports goroutines that are blocked on a
+// channel, sync.Mutex, sync.RWMutex, sync.WaitGroup or sync.Cond that no
+// other goroutine can reach, or on a nil channel or an empty select.
+// Such goroutines can never be woken up. To find them, writing the
+// profile runs a garbage collection that treats the stacks of blocked
+// goroutines as live only once the object they are blocked on is found
+// to be reachable. User goroutines do not run while that collection
+// marks the heap, so collecting the profile is more expensive than
+// collecting the goroutine profile. Leaked goroutines are not freed.
+//
 // The CPU profile is not available as a Profile. It has a special API,
 // the StartCPUProfile and StopCPUProfile functions, because it streams
 // output to a writer during profiling.
@@ -150,6 +161,12 @@ var goroutineProfile = &Profile{
 	write: writeGoroutine,
 }
 
+var goroutineLeakProfile = &Profile{
+	name:  "goroutineleak",
+	count: countGoroutineLeak,
+	write: writeGoroutineLeak,
+}
+
 var threadcreateProfile = &Profile{
 	name:  "threadcreate",
 	count: countThreadCreate,
@@ -185,12 +202,13 @@ func lockProfiles() {
 	if profiles.m == nil {
 		// Initial built-in profiles.
 		profiles.m = map[string]*Profile{
-			"goroutine":    goroutineProfile,
-			"threadcreate": threadcreateProfile,
-			"heap":         heapProfile,
-			"allocs":       allocsProfile,
-			"block":        blockProfile,
-			"mutex":        mutexProfile,
+			"goroutine":     goroutineProfile,
+			"goroutineleak": goroutineLeakProfile,
+			"threadcreate":  threadcreateProfile,
+			"heap":          heapProfile,
+			"allocs":        allocsProfile,
+			"block":         blockProfile,
+			"mutex":         mutexProfile,
 		}
 	}
 }
@@ -322,7 +340,8 @@ func (p *Profile) Remove(value interface{}) {
 // The predefined profiles may assign meaning to other debug values;
 // for example, when printing the "goroutine" profile, debug=2 means to
 // print the goroutine stacks in the same form that a Go program uses
-// when dying due to an unrecovered panic.
+// when dying due to an unrecovered panic. The same holds for the
+// "goroutineleak" profile.
 func (p *Profile) WriteTo(w io.Writer, debug int) error {
 	if p.name == "" {
 		panic("pprof: use of zero Profile")
@@ -684,13 +703,46 @@ func writeGoroutine(w io.Writer, debug int) error {
 	return writeRuntimeProfile(w, debug, "goroutine", runtime_goroutineProfileWithLabels)
 }
 
+// runtime_goroutineLeakGC is defined in runtime/mgcleak.go
+func runtime_goroutineLeakGC()
+
+// runtime_goroutineLeakProfileWithLabels is defined in runtime/mgcleak.go
+func runtime_goroutineLeakProfileWithLabels(p []runtime.StackRecord, labels []unsafe.Pointer) (n int, ok bool)
+
+// runtime_goroutineLeakStacks is defined in runtime/mgcleak.go
+func runtime_goroutineLeakStacks(buf []byte) int
+
+// countGoroutineLeak returns the number of leaked goroutines.
+// It runs a goroutine leak detection cycle to find them.
+func countGoroutineLeak() int {
+	runtime_goroutineLeakGC()
+	n, _ := runtime_goroutineLeakProfileWithLabels(nil, nil)
+	return n
+}
+
+// writeGoroutineLeak writes the stacks of the leaked goroutines to w.
+// It runs a goroutine leak detection cycle to find them.
+func writeGoroutineLeak(w io.Writer, debug int) error {
+	runtime_goroutineLeakGC()
+	if debug >= 2 {
+		return writeStacks(w, runtime_goroutineLeakStacks)
+	}
+	return writeRuntimeProfile(w, debug, "goroutineleak", runtime_goroutineLeakProfileWithLabels)
+}
+
 func writeGoroutineStacks(w io.Writer) error {
+	return writeStacks(w, func(buf []byte) int { return runtime.Stack(buf, true) })
+}
+
+// writeStacks writes the stack traces formatted by stack, which is
+// runtime.Stack or a variant of it, to w.
+func writeStacks(w io.Writer, stack func(buf []byte) int) error {
 	// We don't know how big the buffer needs to be to collect
 	// all the goroutines. Start with 1 MB and try a few times, doubling each time.
 	// Give up and use a truncated trace if 64 MB is not enough.
 	buf := make([]byte, 1<<20)
 	for i := 0; ; i++ {
-		n := runtime.Stack(buf, true)
+		n := stack(buf)
 		if n < len(buf) {
 			buf = buf[:n]
 			break
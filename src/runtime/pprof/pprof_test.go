@@ -1052,6 +1052,115 @@ func containsInOrder(s string, all ...string) bool {
 	return true
 }
 
+func leakChanReceive(c chan int)        { <-c }
+func leakChanSend(c chan int)           { c <- 1 }
+func leakNilChan()                      { <-(chan int)(nil) }
+func leakMutex(mu *sync.Mutex)          { mu.Lock() }
+func blockedOnLiveChan(c chan int)      { <-c }
+func blockedOnLiveMutex(mu *sync.Mutex) { mu.Lock(); mu.Unlock() }
+
+func leakSelect(c, d chan int) {
+	select {
+	case <-c:
+	case d <- 1:
+	}
+}
+
+func leakCond(c *sync.Cond) {
+	c.L.Lock()
+	c.Wait()
+}
+
+func TestGoroutineLeakProfile(t *testing.T) {
+	// Other tests, and earlier runs of this one, leak goroutines too.
+	prof := Lookup("goroutineleak")
+	base := prof.Count()
+
+	// Goroutines blocked forever. Each one is the only holder of the
+	// object it is blocked on, apart from other leaked goroutines.
+	go leakChanReceive(make(chan int))
+	go leakChanReceive(make(chan int))
+	shared := make(chan int)
+	go leakChanSend(shared)
+	go leakChanSend(shared)
+	go leakSelect(make(chan int), make(chan int))
+	go leakNilChan()
+	mu := new(sync.Mutex)
+	mu.Lock()
+	go leakMutex(mu)
+	mu = nil
+	go leakCond(sync.NewCond(new(sync.Mutex)))
+	leaked := base + 8
+
+	// Goroutines that are blocked, but can still be woken up.
+	live := make(chan int)
+	defer close(live)
+	go blockedOnLiveChan(live)
+	var liveMu sync.Mutex
+	liveMu.Lock()
+	defer liveMu.Unlock()
+	go blockedOnLiveMutex(&liveMu)
+
+	// Wait for all the goroutines to block.
+	var w bytes.Buffer
+	for i := 0; ; i++ {
+		w.Reset()
+		if err := prof.WriteTo(&w, 1); err != nil {
+			t.Fatal(err)
+		}
+		if strings.HasPrefix(w.String(), fmt.Sprintf("goroutineleak profile: total %d\n", leaked)) {
+			break
+		}
+		if i == 100 {
+			t.Fatalf("want %d leaked goroutines, got profile:\n%s", leaked, w.String())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	for _, fn := range []string{"leakChanReceive", "leakChanSend", "leakSelect", "leakNilChan", "leakMutex", "leakCond"} {
+		if !strings.Contains(w.String(), "runtime/pprof."+fn+"+") {
+			t.Errorf("profile does not contain %s:\n%s", fn, w.String())
+		}
+	}
+	if strings.Contains(w.String(), "blockedOnLive") {
+		t.Errorf("profile contains goroutines that can still be woken up:\n%s", w.String())
+	}
+
+	// The leaked goroutines stay leaked.
+	if n := prof.Count(); n != leaked {
+		t.Errorf("Count() = %d, want %d", n, leaked)
+	}
+	w.Reset()
+	if err := prof.WriteTo(&w, 0); err != nil {
+		t.Fatal(err)
+	}
+	p, err := profile.Parse(&w)
+	if err != nil {
+		t.Fatalf("error parsing protobuf profile: %v", err)
+	}
+	var n int64
+	for _, s := range p.Sample {
+		n += s.Value[0]
+	}
+	if n != int64(leaked) {
+		t.Errorf("protobuf profile has %d goroutines, want %d", n, leaked)
+	}
+
+	// debug=2 prints each leaked goroutine as runtime.Stack does.
+	w.Reset()
+	if err := prof.WriteTo(&w, 2); err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(w.String(), "goroutine "); n != leaked {
+		t.Errorf("debug=2 profile has %d goroutines, want %d:\n%s", n, leaked, w.String())
+	}
+	if !strings.Contains(w.String(), "runtime/pprof.leakMutex(") {
+		t.Errorf("debug=2 profile does not contain leakMutex:\n%s", w.String())
+	}
+	if strings.Contains(w.String(), "blockedOnLive") {
+		t.Errorf("debug=2 profile contains goroutines that can still be woken up:\n%s", w.String())
+	}
+}
+
 func containsCountsLabels(prof *profile.Profile, countLabels map[int64]map[string]string) bool {
 	m := make(map[int64]int)
 	type nkey struct {
@@ -370,6 +370,12 @@ type sudog struct {
 	waitlink *sudog // g.waiting list or semaRoot
 	waittail *sudog // semaRoot
 	c        *hchan // channel
+
+	// semaddr is the address a semaRoot waiter is blocked on. It is
+	// not a pointer so that the semaRoot does not keep the semaphore
+	// reachable; the blocked goroutine does that through its stack.
+	// Goroutine leak detection depends on this.
+	semaddr uintptr
 }
 
 type libcall struct {
@@ -441,7 +447,14 @@ type g struct {
 
 	paniconfault bool // panic (instead of crash) on unexpected fault address
 	gcscandone   bool // g has scanned stack; protected by _Gscan bit in status
-	throwsplit   bool // must not split stack
+	// leakCandidate indicates that goroutine leak detection has
+	// deferred scanning this g's stack until it knows whether the
+	// objects the g is blocked on are reachable. See mgcleak.go.
+	leakCandidate bool
+	// leaked indicates that the most recent goroutine leak detection
+	// found this g blocked forever.
+	leaked     bool
+	throwsplit bool // must not split stack
 	// activeStackChans indicates that there are unlocked channels
 	// pointing into this goroutine's stack. If true, stack
 	// copying needs to acquire channel locks to protect these
@@ -451,10 +464,17 @@ type g struct {
 	// park on a chansend or chanrecv. Used to signal an unsafe point
 	// for stack shrinking. It's a boolean value, but is updated atomically.
 	parkingOnChan uint8
+	// tracking indicates whether the scheduling latency of this G
+	// is being sampled for the /sched/latencies:seconds metric.
+	tracking bool
+	// trackingSeq is used to decide whether to track this G.
+	trackingSeq uint8
 
 	raceignore     int8     // ignore race detection events
 	sysblocktraced bool     // StartTrace has emitted EvGoInSyscall about this goroutine
 	sysexitticks   int64    // cputicks when syscall has returned (for tracing)
+	runnableStamp  int64    // timestamp of when the G last became runnable, only used when tracking
+	runnableTime   int64    // the amount of time spent runnable, cleared when running, only used when tracking
 	traceseq       uint64   // trace event sequencer
 	tracelastp     puintptr // last P emitted an event for this goroutine
 	lockedm        muintptr
@@ -468,6 +488,8 @@ type g struct {
 	startpc        uintptr         // pc of goroutine function
 	racectx        uintptr
 	waiting        *sudog         // sudog structures this g is waiting on (that have a valid elem ptr); in lock order
+	leakWaiting    uintptr        // waiting, hidden from the GC during goroutine leak detection
+	syncobj        uintptr        // semaphore or notify list this g is blocked on in semacquire or sync.Cond.Wait
 	cgoCtxt        []uintptr      // cgo traceback context
 	labels         unsafe.Pointer // profiler labels
 	timer          *timer         // cached timer for time.Sleep
@@ -778,6 +800,13 @@ type schedt struct {
 	// Acquire and hold this mutex to block sysmon from interacting
 	// with the rest of the runtime.
 	sysmonlock mutex
+
+	// timeToRun is a distribution of scheduling latencies, defined
+	// as the sum of time a G spends in the _Grunnable state before
+	// it transitions to _Grunning.
+	//
+	// timeToRun is updated atomically and must be 8-byte aligned.
+	timeToRun timeHistogram
 }
 
 // Values for the flags field of a sigTabT.
@@ -27,7 +27,7 @@ import (
 
 // Asynchronous semaphore for sync.Mutex.
 
-// A semaRoot holds a balanced tree of sudog with distinct addresses (s.elem).
+// A semaRoot holds a balanced tree of sudog with distinct addresses (s.semaddr).
 // Each of those sudog may in turn point (through s.waitlink) to a list
 // of other sudogs waiting on the same address.
 // The operations on the inner lists of sudogs with the same address
@@ -141,7 +141,9 @@ func semacquire1(addr *uint32, lifo bool, profile semaProfileFlags, skipframes i
 		// Any semrelease after the cansemacquire knows we're waiting
 		// (we set nwait above), so go to sleep.
 		root.queue(addr, s, lifo)
+		gp.syncobj = uintptr(unsafe.Pointer(addr))
 		goparkunlock(&root.lock, waitReasonSemacquire, traceEvGoBlockSync, 4+skipframes)
+		gp.syncobj = 0
 		if s.ticket != 0 || cansemacquire(addr) {
 			break
 		}
@@ -233,14 +235,14 @@ func cansemacquire(addr *uint32) bool {
 // queue adds s to the blocked goroutines in semaRoot.
 func (root *semaRoot) queue(addr *uint32, s *sudog, lifo bool) {
 	s.g = getg()
-	s.elem = unsafe.Pointer(addr)
+	s.semaddr = uintptr(unsafe.Pointer(addr))
 	s.next = nil
 	s.prev = nil
 
 	var last *sudog
 	pt := &root.treap
 	for t := *pt; t != nil; t = *pt {
-		if t.elem == unsafe.Pointer(addr) {
+		if t.semaddr == uintptr(unsafe.Pointer(addr)) {
 			// Already have addr in list.
 			if lifo {
 				// Substitute s in t's place in treap.
@@ -279,7 +281,7 @@ func (root *semaRoot) queue(addr *uint32, s *sudog, lifo bool) {
 			return
 		}
 		last = t
-		if uintptr(unsafe.Pointer(addr)) < uintptr(t.elem) {
+		if uintptr(unsafe.Pointer(addr)) < t.semaddr {
 			pt = &t.prev
 		} else {
 			pt = &t.next
@@ -288,7 +290,7 @@ func (root *semaRoot) queue(addr *uint32, s *sudog, lifo bool) {
 
 	// Add s as new leaf in tree of unique addrs.
 	// The balanced tree is a treap using ticket as the random heap priority.
-	// That is, it is a binary tree ordered according to the elem addresses,
+	// That is, it is a binary tree ordered according to the semaphore addresses,
 	// but then among the space of possible binary trees respecting those
 	// addresses, it is kept balanced on average by maintaining a heap ordering
 	// on the ticket: s.ticket <= both s.prev.ticket and s.next.ticket.
@@ -322,10 +324,10 @@ func (root *semaRoot) dequeue(addr *uint32) (found *sudog, now int64) {
 	ps := &root.treap
 	s := *ps
 	for ; s != nil; s = *ps {
-		if s.elem == unsafe.Pointer(addr) {
+		if s.semaddr == uintptr(unsafe.Pointer(addr)) {
 			goto Found
 		}
-		if uintptr(unsafe.Pointer(addr)) < uintptr(s.elem) {
+		if uintptr(unsafe.Pointer(addr)) < s.semaddr {
 			ps = &s.prev
 		} else {
 			ps = &s.next
@@ -380,7 +382,7 @@ Found:
 		}
 	}
 	s.parent = nil
-	s.elem = nil
+	s.semaddr = 0
 	s.next = nil
 	s.prev = nil
 	s.ticket = 0
@@ -495,8 +497,9 @@ func notifyListWait(l *notifyList, t uint32) {
 	}
 
 	// Enqueue itself.
+	gp := getg()
 	s := acquireSudog()
-	s.g = getg()
+	s.g = gp
 	s.ticket = t
 	s.releasetime = 0
 	t0 := int64(0)
@@ -510,7 +513,9 @@ func notifyListWait(l *notifyList, t uint32) {
 		l.tail.next = s
 	}
 	l.tail = s
+	gp.syncobj = uintptr(unsafe.Pointer(l))
 	goparkunlock(&l.lock, waitReasonSyncCondWait, traceEvGoBlockCond, 3)
+	gp.syncobj = 0
 	if t0 != 0 {
 		blockevent(s.releasetime-t0, 2)
 	}
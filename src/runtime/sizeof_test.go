@@ -21,8 +21,8 @@ func TestSizeof(t *testing.T) {
 		_32bit uintptr     // size on 32bit platforms
 		_64bit uintptr     // size on 64bit platforms
 	}{
-		{runtime.G{}, 216, 376},   // g, but exported for testing
-		{runtime.Sudog{}, 56, 88}, // sudog, but exported for testing
+		{runtime.G{}, 244, 408},   // g, but exported for testing
+		{runtime.Sudog{}, 60, 96}, // sudog, but exported for testing
 	}
 
 	for _, tt := range tests {
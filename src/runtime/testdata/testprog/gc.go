@@ -19,6 +19,7 @@ func init() {
 	register("GCFairness2", GCFairness2)
 	register("GCSys", GCSys)
 	register("GCPhys", GCPhys)
+	register("GCMemoryLimit", GCMemoryLimit)
 	register("DeferLiveness", DeferLiveness)
 	register("GCZombie", GCZombie)
 }
@@ -300,3 +301,50 @@ func GCZombie() {
 	runtime.KeepAlive(keep)
 	runtime.KeepAlive(zombies)
 }
+
+// GCMemoryLimit checks that the memory limit set by $GOMEMLIMIT keeps the
+// runtime's memory use in check even with the GC otherwise turned off.
+// It must be run with GOGC=off and GOMEMLIMIT=64MiB.
+func GCMemoryLimit() {
+	const (
+		limit = 64 << 20
+		live  = 8 << 20
+		total = 1 << 30
+
+		// The limit is soft. Allow the runtime some slack to account for
+		// memory that the scavenger can't reach right away.
+		slack = 16 << 20
+	)
+	if got := debug.SetMemoryLimit(-1); got != limit {
+		fmt.Printf("memory limit = %d, want %d\n", got, limit)
+		return
+	}
+	keep := make([][]byte, 0, live>>10)
+	for i := 0; i < live; i += 1 << 10 {
+		keep = append(keep, make([]byte, 1<<10))
+	}
+	var ms runtime.MemStats
+	var peak uint64
+	// Allocate garbage that contains pointers, so that assists pace the
+	// mutator's allocation against the GC's scan work.
+	for i := 0; i < total; i += 64 << 10 {
+		sink2 = make([]*byte, (64<<10)/unsafe.Sizeof((*byte)(nil)))
+		if i%(16<<20) == 0 {
+			runtime.ReadMemStats(&ms)
+			if used := ms.Sys - ms.HeapReleased; used > peak {
+				peak = used
+			}
+		}
+	}
+	runtime.KeepAlive(keep)
+	runtime.ReadMemStats(&ms)
+	if ms.NumGC == 0 {
+		fmt.Println("GC did not run under the memory limit")
+		return
+	}
+	if peak > limit+slack {
+		fmt.Printf("peak memory use = %d MiB, want at most %d MiB\n", peak>>20, (limit+slack)>>20)
+		return
+	}
+	fmt.Println("OK")
+}
@@ -68,7 +68,8 @@ const (
 	traceEvUserTaskEnd       = 46 // end of a task [timestamp, internal task id, stack]
 	traceEvUserRegion        = 47 // trace.WithRegion [timestamp, internal task id, mode(0:start, 1:end), stack, name string]
 	traceEvUserLog           = 48 // trace.Log [timestamp, internal task id, key string id, stack, value string]
-	traceEvCount             = 49
+	traceEvPartitionEnd      = 49 // end of a trace partition [partition sequence number]
+	traceEvCount             = 50
 	// Byte is used but only 6 bits are available for event type.
 	// The remaining 2 bits are used to specify the number of arguments.
 	// That means, the max event type value is 63.
@@ -102,6 +103,8 @@ const (
 	// Such wakeups happen on buffered channels and sync.Mutex,
 	// but are generally not interesting for end user.
 	traceFutileWakeup byte = 128
+	// Header at the start of every trace partition.
+	traceHeader = "go 1.16 trace\x00\x00\x00"
 )
 
 // trace is global tracing context.
@@ -114,11 +117,12 @@ var trace struct {
 	footerWritten bool        // whether ReadTrace has emitted trace footer
 	shutdownSema  uint32      // used to wait for ReadTrace completion
 	seqStart      uint64      // sequence number when tracing was started
-	ticksStart    int64       // cputicks when tracing was started
+	ticksStart    int64       // cputicks when the current partition was started
 	ticksEnd      int64       // cputicks when tracing was stopped
-	timeStart     int64       // nanotime when tracing was started
+	timeStart     int64       // nanotime when the current partition was started
 	timeEnd       int64       // nanotime when tracing was stopped
 	seqGC         uint64      // GC start/done sequencer
+	partition     uint64      // sequence number of the current partition, starting at 1
 	reading       traceBufPtr // buffer currently handed off to user
 	empty         traceBufPtr // stack of empty buffers
 	fullHead      traceBufPtr // queue of full buffers
@@ -148,6 +152,7 @@ type traceBufHeader struct {
 	link      traceBufPtr             // in trace.empty/full
 	lastTicks uint64                  // when we wrote the last event
 	pos       int                     // next write offset in arr
+	partition uint64                  // sequence number of the partition this buffer starts, or 0
 	stk       [traceStackSize]uintptr // scratch buffer for traceback
 }
 
@@ -218,6 +223,48 @@ func StartTrace() error {
 	stackID := traceStackID(mp, stkBuf, 2)
 	releasem(mp)
 
+	trace.partition = 1
+	traceSnapshot(stackID)
+	// Note: ticksStart needs to be set after we emit traceEvGoInSyscall events.
+	// If we do it the other way around, it is possible that exitsyscall will
+	// query sysexitticks after ticksStart but before traceEvGoInSyscall timestamp.
+	// It will lead to a false conclusion that cputicks is broken.
+	trace.ticksStart = cputicks()
+	trace.timeStart = nanotime()
+	trace.headerWritten = false
+	trace.footerWritten = false
+
+	// string to id mapping
+	//  0 : reserved for an empty string
+	//  remaining: other strings registered by traceString
+	trace.stringSeq = 0
+	trace.strings = make(map[string]uint64)
+
+	trace.seqGC = 0
+	_g_.m.startingtrace = false
+	trace.enabled = true
+
+	// Register runtime goroutine labels.
+	_, pid, bufp := traceAcquireBuffer()
+	for i, label := range gcMarkWorkerModeStrings[:] {
+		trace.markWorkerLabels[i], bufp = traceString(bufp, pid, label)
+	}
+	traceReleaseBuffer(pid)
+
+	unlock(&trace.bufLock)
+
+	unlock(&sched.sysmonlock)
+
+	startTheWorldGC()
+	return nil
+}
+
+// traceSnapshot emits the events that describe the state of all goroutines
+// and of the current P at the start of a trace partition. stackID is the
+// stack used for the traceEvGoCreate events.
+//
+// The world must be stopped and trace.bufLock must be held.
+func traceSnapshot(stackID uint64) {
 	for _, gp := range allgs {
 		status := readgstatus(gp)
 		if status != _Gdead {
@@ -241,26 +288,109 @@ func StartTrace() error {
 	}
 	traceProcStart()
 	traceGoStart()
-	// Note: ticksStart needs to be set after we emit traceEvGoInSyscall events.
-	// If we do it the other way around, it is possible that exitsyscall will
-	// query sysexitticks after ticksStart but before traceEvGoInSyscall timestamp.
-	// It will lead to a false conclusion that cputicks is broken.
-	trace.ticksStart = cputicks()
-	trace.timeStart = nanotime()
-	trace.headerWritten = false
-	trace.footerWritten = false
+}
 
-	// string to id mapping
-	//  0 : reserved for an empty string
-	//  remaining: other strings registered by traceString
+// traceAdvance ends the current trace partition and starts a new one.
+// It returns the sequence number of the partition that was ended, or 0
+// if tracing is not enabled.
+//
+// A partition is a self-contained piece of the trace. It begins with the
+// trace header and a snapshot of the state of all goroutines, and it ends
+// with the stack table and the timer frequency for its events, followed
+// by traceEvPartitionEnd. Each partition can be parsed on its own, so any
+// suffix of the partitions of a trace is a valid trace as well. This lets
+// the tracer run continuously while a consumer keeps only the most recent
+// partitions (see runtime/trace.FlightRecorder), and it bounds the memory
+// held by the string and stack tables.
+//
+// traceAdvance stops the world, so it is called only by the flight
+// recorder and not while writing an ordinary trace.
+//
+//go:linkname traceAdvance runtime/trace.runtime_traceAdvance
+func traceAdvance() uint64 {
+	// Stopping the world with stopTheWorldGC ensures that a partition
+	// never splits a GC cycle, as in StartTrace.
+	stopTheWorldGC("trace advance")
+
+	// See the comment in StartTrace.
+	lock(&sched.sysmonlock)
+
+	// See the comment in StartTrace. Holding bufLock also keeps
+	// exitsyscall from emitting events while we swap the tables below.
+	lock(&trace.bufLock)
+
+	if !trace.enabled {
+		unlock(&trace.bufLock)
+		unlock(&sched.sysmonlock)
+		startTheWorldGC()
+		return 0
+	}
+
+	// End the partition with no goroutine running, as StopTrace does.
+	// The snapshot below starts the current goroutine again.
+	traceGoSched()
+
+	// Queue all the events of the current partition.
+	lock(&trace.lock)
+	for _, p := range allp[:cap(allp)] {
+		buf := p.tracebuf
+		if buf != 0 {
+			traceFullQueue(buf)
+			p.tracebuf = 0
+		}
+	}
+	if trace.buf != 0 {
+		buf := trace.buf
+		trace.buf = 0
+		if buf.ptr().pos != 0 {
+			traceFullQueue(buf)
+		}
+	}
+	unlock(&trace.lock)
+
+	ticksEnd, timeEnd := cputicks(), nanotime()
+	for timeEnd == trace.timeStart {
+		// Windows time can tick only every 15ms, wait for at least one tick.
+		osyield()
+		ticksEnd, timeEnd = cputicks(), nanotime()
+	}
+	// Use float64 because (ticksEnd - trace.ticksStart) * 1e9 can overflow int64.
+	freq := float64(ticksEnd-trace.ticksStart) * 1e9 / float64(timeEnd-trace.timeStart) / traceTickDiv
+
+	// Write the footer of the current partition and the header of the
+	// next one. The header goes into a buffer of its own that records the
+	// partition it starts, which readTrace reports to runtime/trace.
+	trace.stackTab.dump()
+	lock(&trace.lock)
+	buf := traceEmptyBuf().ptr()
+	buf.byte(traceEvFrequency | 0<<traceArgCountShift)
+	buf.varint(uint64(freq))
+	buf.byte(traceEvPartitionEnd | 0<<traceArgCountShift)
+	buf.varint(trace.partition)
+	traceFullQueue(traceBufPtrOf(buf))
+	buf = traceEmptyBuf().ptr()
+	buf.pos += copy(buf.arr[:], traceHeader)
+	buf.partition = trace.partition + 1
+	traceFullQueue(traceBufPtrOf(buf))
+	unlock(&trace.lock)
+
+	// Start the next partition with fresh tables and a new snapshot.
+	seq := trace.partition
+	trace.partition++
 	trace.stringSeq = 0
 	trace.strings = make(map[string]uint64)
-
 	trace.seqGC = 0
-	_g_.m.startingtrace = false
-	trace.enabled = true
 
-	// Register runtime goroutine labels.
+	mp := acquirem()
+	stkBuf := make([]uintptr, traceStackSize)
+	stackID := traceStackID(mp, stkBuf, 2)
+	releasem(mp)
+
+	traceSnapshot(stackID)
+	// See the comment in StartTrace.
+	trace.ticksStart = cputicks()
+	trace.timeStart = nanotime()
+
 	_, pid, bufp := traceAcquireBuffer()
 	for i, label := range gcMarkWorkerModeStrings[:] {
 		trace.markWorkerLabels[i], bufp = traceString(bufp, pid, label)
@@ -272,7 +402,7 @@ func StartTrace() error {
 	unlock(&sched.sysmonlock)
 
 	startTheWorldGC()
-	return nil
+	return seq
 }
 
 // 如果先前已启用，StopTrace 将停止跟踪。
@@ -370,6 +500,16 @@ func StopTrace() {
 // 在再次调用 ReadTrace 之前，调用方必须复制返回的数据。
 // 一次只能从一个 goroutine 调用 ReadTrace。
 func ReadTrace() []byte {
+	data, _ := readTrace()
+	return data
+}
+
+// readTrace is like ReadTrace, but it also returns the sequence number
+// of the trace partition that data starts, or 0 if data continues the
+// current partition.
+//
+//go:linkname readTrace runtime/trace.runtime_readTrace
+func readTrace() (data []byte, partition uint64) {
 	// This function may need to lock trace.lock recursively
 	// (goparkunlock -> traceGoPark -> traceEvent -> traceFlush).
 	// To allow this we use trace.lockOwner.
@@ -386,7 +526,7 @@ func ReadTrace() []byte {
 		trace.lockOwner = nil
 		unlock(&trace.lock)
 		println("runtime: ReadTrace called from multiple goroutines simultaneously")
-		return nil
+		return nil, 0
 	}
 	// Recycle the old buffer.
 	if buf := trace.reading; buf != 0 {
@@ -399,7 +539,7 @@ func ReadTrace() []byte {
 		trace.headerWritten = true
 		trace.lockOwner = nil
 		unlock(&trace.lock)
-		return []byte("go 1.11 trace\x00\x00\x00")
+		return []byte(traceHeader), 1
 	}
 	// Wait for new data.
 	if trace.fullHead == 0 && !trace.shutdown {
@@ -413,7 +553,7 @@ func ReadTrace() []byte {
 		trace.reading = buf
 		trace.lockOwner = nil
 		unlock(&trace.lock)
-		return buf.ptr().arr[:buf.ptr().pos]
+		return buf.ptr().arr[:buf.ptr().pos], buf.ptr().partition
 	}
 	// Write footer with timer frequency.
 	if !trace.footerWritten {
@@ -428,7 +568,7 @@ func ReadTrace() []byte {
 		// This will emit a bunch of full buffers, we will pick them up
 		// on the next iteration.
 		trace.stackTab.dump()
-		return data
+		return data, 0
 	}
 	// Done.
 	if trace.shutdown {
@@ -442,13 +582,13 @@ func ReadTrace() []byte {
 		}
 		// trace.enabled is already reset, so can call traceable functions.
 		semrelease(&trace.shutdownSema)
-		return nil
+		return nil, 0
 	}
 	// Also bad, but see the comment above.
 	trace.lockOwner = nil
 	unlock(&trace.lock)
 	println("runtime: spurious wakeup of trace reader")
-	return nil
+	return nil, 0
 }
 
 // traceReader returns the trace reader that should be woken up, if any.
@@ -632,18 +772,8 @@ func traceFlush(buf traceBufPtr, pid int32) traceBufPtr {
 	if buf != 0 {
 		traceFullQueue(buf)
 	}
-	if trace.empty != 0 {
-		buf = trace.empty
-		trace.empty = buf.ptr().link
-	} else {
-		buf = traceBufPtr(sysAlloc(unsafe.Sizeof(traceBuf{}), &memstats.other_sys))
-		if buf == 0 {
-			throw("trace: out of memory")
-		}
-	}
+	buf = traceEmptyBuf()
 	bufp := buf.ptr()
-	bufp.link.set(nil)
-	bufp.pos = 0
 
 	// initialize the buffer for a new batch
 	ticks := uint64(cputicks()) / traceTickDiv
@@ -658,6 +788,28 @@ func traceFlush(buf traceBufPtr, pid int32) traceBufPtr {
 	return buf
 }
 
+// traceEmptyBuf returns an empty buffer, allocating a new one if there
+// are no buffers to reuse.
+//
+// trace.lock must be held.
+func traceEmptyBuf() traceBufPtr {
+	var buf traceBufPtr
+	if trace.empty != 0 {
+		buf = trace.empty
+		trace.empty = buf.ptr().link
+	} else {
+		buf = traceBufPtr(sysAlloc(unsafe.Sizeof(traceBuf{}), &memstats.other_sys))
+		if buf == 0 {
+			throw("trace: out of memory")
+		}
+	}
+	bufp := buf.ptr()
+	bufp.link.set(nil)
+	bufp.pos = 0
+	bufp.partition = 0
+	return buf
+}
+
 // traceString adds a string to the trace.strings and returns the id.
 func traceString(bufp *traceBufPtr, pid int32, s string) (uint64, *traceBufPtr) {
 	if s == "" {
@@ -0,0 +1,12 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package trace
+
+// Advance ends the current trace partition and starts a new one.
+func Advance() uint64 {
+	return runtime_traceAdvance()
+}
+
+const AdvancePeriod = advancePeriod
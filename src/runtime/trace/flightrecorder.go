@@ -0,0 +1,252 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package trace
+
+import (
+	"errors"
+	"io"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// FlightRecorderConfig configures a FlightRecorder.
+type FlightRecorderConfig struct {
+	// MinAge is a lower bound on the age of the oldest event kept by the
+	// flight recorder. That is, a snapshot covers at least the last MinAge
+	// of execution, unless that would exceed MaxBytes.
+	//
+	// The trace is recorded in partitions about a second long, so the
+	// snapshot may cover up to about a second more than MinAge.
+	//
+	// If zero, a default of 10 seconds is used.
+	MinAge time.Duration
+
+	// MaxBytes is an upper bound on the size of the trace kept by the
+	// flight recorder. It takes precedence over MinAge, except that the
+	// most recent partition is always kept.
+	//
+	// If zero, a default of 10 MiB is used.
+	MaxBytes uint64
+}
+
+// A FlightRecorder records an execution trace continuously into a ring
+// buffer in memory, keeping only the most recent part of it. A snapshot
+// of the recorded trace can be written out at any time with WriteTo,
+// for example when a request takes longer than expected.
+//
+// The flight recorder ends a trace partition about once a second, so
+// that it can drop the oldest ones. Each time, it briefly stops the
+// world, as runtime.GC does. Apart from these pauses, recording costs
+// as much per event as a trace started by Start: the flight recorder
+// does not make tracing cheaper, and leaving it running in production
+// slows the program down as much as tracing it does.
+//
+// The flight recorder uses the same tracer as Start, so it cannot be
+// started while tracing is enabled, and vice versa.
+type FlightRecorder struct {
+	cfg FlightRecorderConfig
+
+	mu      sync.Mutex
+	cond    sync.Cond // signaled when a partition is complete or the reader exits
+	enabled bool
+	writing bool // whether a WriteTo call is in progress
+
+	// The following fields are protected by mu and written by the
+	// trace reader goroutine.
+	parts   []frPartition // complete partitions, oldest first
+	size    uint64        // total size of parts in bytes
+	sealed  uint64        // sequence number of the last complete partition
+	stopped bool          // whether the trace reader has exited
+}
+
+// frPartition is a complete trace partition held by a FlightRecorder.
+type frPartition struct {
+	chunks [][]byte  // trace data returned by the runtime
+	size   uint64    // total size of chunks in bytes
+	end    time.Time // when the partition was received
+}
+
+// NewFlightRecorder creates a new flight recorder with the given
+// configuration. The flight recorder is not started.
+func NewFlightRecorder(cfg FlightRecorderConfig) *FlightRecorder {
+	if cfg.MinAge == 0 {
+		cfg.MinAge = 10 * time.Second
+	}
+	if cfg.MaxBytes == 0 {
+		cfg.MaxBytes = 10 << 20
+	}
+	fr := &FlightRecorder{cfg: cfg}
+	fr.cond.L = &fr.mu
+	return fr
+}
+
+// Start starts recording. It returns an error if tracing is already
+// enabled, either by Start or by another FlightRecorder, or if the
+// flight recorder is already started.
+func (fr *FlightRecorder) Start() error {
+	tracing.Lock()
+	defer tracing.Unlock()
+
+	fr.mu.Lock()
+	defer fr.mu.Unlock()
+	if fr.enabled {
+		return errors.New("flight recorder is already started")
+	}
+	if err := runtime.StartTrace(); err != nil {
+		return err
+	}
+	fr.enabled = true
+	fr.parts = nil
+	fr.size = 0
+	fr.sealed = 0
+	fr.stopped = false
+	go fr.read()
+
+	tracing.advancer = startAdvancer(advancePeriod)
+	tracing.recorder = fr
+	atomic.StoreInt32(&tracing.enabled, 1)
+	return nil
+}
+
+// Stop stops recording and discards the recorded trace.
+// It does nothing if the flight recorder is not started.
+func (fr *FlightRecorder) Stop() {
+	tracing.Lock()
+	defer tracing.Unlock()
+
+	fr.mu.Lock()
+	if !fr.enabled {
+		fr.mu.Unlock()
+		return
+	}
+	fr.enabled = false
+	fr.mu.Unlock()
+
+	atomic.StoreInt32(&tracing.enabled, 0)
+	tracing.advancer.stop()
+	tracing.advancer = nil
+	tracing.recorder = nil
+	// StopTrace waits for fr.read to exit.
+	runtime.StopTrace()
+
+	fr.mu.Lock()
+	fr.parts = nil
+	fr.size = 0
+	fr.mu.Unlock()
+}
+
+// Enabled reports whether the flight recorder is recording.
+func (fr *FlightRecorder) Enabled() bool {
+	fr.mu.Lock()
+	defer fr.mu.Unlock()
+	return fr.enabled
+}
+
+// WriteTo writes a snapshot of the recorded trace to w. The snapshot
+// includes all events up to the call and is itself a complete trace
+// that can be read by `go tool trace`.
+//
+// Only one WriteTo call may be in progress at a time; concurrent calls
+// return an error. WriteTo returns the number of bytes written and the
+// first error encountered while writing, if any.
+func (fr *FlightRecorder) WriteTo(w io.Writer) (n int64, err error) {
+	fr.mu.Lock()
+	if !fr.enabled {
+		fr.mu.Unlock()
+		return 0, errors.New("flight recorder is not started")
+	}
+	if fr.writing {
+		fr.mu.Unlock()
+		return 0, errors.New("flight recorder WriteTo is already in progress")
+	}
+	fr.writing = true
+	fr.mu.Unlock()
+
+	// End the current partition, so that the snapshot includes
+	// everything up to this point, and wait for the reader to
+	// receive all of it.
+	seq := runtime_traceAdvance()
+
+	fr.mu.Lock()
+	for fr.sealed < seq && !fr.stopped {
+		fr.cond.Wait()
+	}
+	parts := append([]frPartition(nil), fr.parts...)
+	fr.mu.Unlock()
+
+	defer func() {
+		fr.mu.Lock()
+		fr.writing = false
+		fr.mu.Unlock()
+	}()
+	for _, p := range parts {
+		for _, c := range p.chunks {
+			m, err := w.Write(c)
+			n += int64(m)
+			if err != nil {
+				return n, err
+			}
+		}
+	}
+	return n, nil
+}
+
+// read receives the trace from the runtime and splits it into
+// partitions until tracing is stopped.
+func (fr *FlightRecorder) read() {
+	var cur frPartition
+	var seq uint64
+	for {
+		data, partition := runtime_readTrace()
+		if data == nil {
+			break
+		}
+		if partition != 0 {
+			// The previous partition is complete.
+			if seq > 0 {
+				cur.end = time.Now()
+				fr.add(cur, seq)
+			}
+			cur = frPartition{}
+			seq = partition
+		}
+		// ReadTrace reuses its buffer on the next call.
+		cur.chunks = append(cur.chunks, append([]byte(nil), data...))
+		cur.size += uint64(len(data))
+	}
+	// The partition in progress when tracing stopped is dropped
+	// along with the rest of the recording.
+	fr.mu.Lock()
+	fr.stopped = true
+	fr.cond.Broadcast()
+	fr.mu.Unlock()
+}
+
+// add adds a complete partition with sequence number seq and drops the
+// oldest partitions that are no longer needed.
+func (fr *FlightRecorder) add(p frPartition, seq uint64) {
+	fr.mu.Lock()
+	defer fr.mu.Unlock()
+
+	fr.parts = append(fr.parts, p)
+	fr.size += p.size
+	fr.sealed = seq
+
+	// A partition is not needed once all of its events are older than
+	// MinAge, or if keeping it would exceed MaxBytes.
+	minEnd := p.end.Add(-fr.cfg.MinAge)
+	for len(fr.parts) > 1 {
+		oldest := fr.parts[0]
+		if fr.size <= fr.cfg.MaxBytes && !oldest.end.Before(minEnd) {
+			break
+		}
+		fr.parts[0] = frPartition{}
+		fr.parts = fr.parts[1:]
+		fr.size -= oldest.size
+	}
+	fr.cond.Broadcast()
+}
@@ -0,0 +1,136 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package trace_test
+
+import (
+	"bytes"
+	"context"
+	"internal/trace"
+	"io/ioutil"
+	. "runtime/trace"
+	"sync"
+	"testing"
+	"time"
+)
+
+const traceHeader = "go 1.16 trace\x00\x00\x00"
+
+func TestFlightRecorder(t *testing.T) {
+	if IsEnabled() {
+		t.Skip("skipping because -test.trace is set")
+	}
+	fr := NewFlightRecorder(FlightRecorderConfig{})
+	if fr.Enabled() {
+		t.Fatal("flight recorder is enabled before Start")
+	}
+	if _, err := fr.WriteTo(ioutil.Discard); err == nil {
+		t.Fatal("WriteTo succeeded before Start")
+	}
+	if err := fr.Start(); err != nil {
+		t.Fatalf("failed to start flight recorder: %v", err)
+	}
+	defer fr.Stop()
+	if !fr.Enabled() || !IsEnabled() {
+		t.Fatal("tracing is not enabled after Start")
+	}
+	if err := fr.Start(); err == nil {
+		t.Fatal("started flight recorder twice")
+	}
+	if err := Start(ioutil.Discard); err == nil {
+		t.Fatal("started tracing while the flight recorder is running")
+	}
+	// Stop does not stop the flight recorder.
+	Stop()
+	if !fr.Enabled() {
+		t.Fatal("Stop stopped the flight recorder")
+	}
+
+	// Each snapshot must contain the regions that ended before it
+	// was taken.
+	var wg sync.WaitGroup
+	done := make(chan bool)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		<-done
+	}()
+	for i := 0; i < 3; i++ {
+		ctx, task := NewTask(context.Background(), "snapshot")
+		WithRegion(ctx, "work", func() {
+			c := make(chan int)
+			go func() { c <- 1 }()
+			<-c
+		})
+		task.End()
+
+		var buf bytes.Buffer
+		n, err := fr.WriteTo(&buf)
+		if err != nil {
+			t.Fatalf("WriteTo failed: %v", err)
+		}
+		if n != int64(buf.Len()) {
+			t.Errorf("WriteTo returned %d, wrote %d bytes", n, buf.Len())
+		}
+		saveTrace(t, &buf, "TestFlightRecorder")
+		events, _ := parseTrace(t, &buf)
+		regions := 0
+		for _, ev := range events {
+			if ev.Type == trace.EvUserRegion && ev.SArgs[0] == "work" {
+				regions++
+			}
+		}
+		// Each region has a start and an end event.
+		if regions != 2*(i+1) {
+			t.Errorf("snapshot %d has %d region events, want %d", i, regions, 2*(i+1))
+		}
+	}
+	close(done)
+	wg.Wait()
+
+	fr.Stop()
+	if fr.Enabled() || IsEnabled() {
+		t.Fatal("tracing is enabled after Stop")
+	}
+	if _, err := fr.WriteTo(ioutil.Discard); err == nil {
+		t.Fatal("WriteTo succeeded after Stop")
+	}
+}
+
+func TestFlightRecorderWindow(t *testing.T) {
+	if IsEnabled() {
+		t.Skip("skipping because -test.trace is set")
+	}
+	// With a long MinAge, the second snapshot keeps the partition ended
+	// by the first WriteTo, and any partitions ended periodically.
+	// With a MaxBytes smaller than any partition, it keeps only the last.
+	for _, tc := range []struct {
+		name               string
+		cfg                FlightRecorderConfig
+		minParts, maxParts int // partitions in the second snapshot
+	}{
+		{"MinAge", FlightRecorderConfig{MinAge: time.Hour}, 2, 100},
+		{"MaxBytes", FlightRecorderConfig{MinAge: time.Hour, MaxBytes: 1}, 1, 1},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			fr := NewFlightRecorder(tc.cfg)
+			if err := fr.Start(); err != nil {
+				t.Fatalf("failed to start flight recorder: %v", err)
+			}
+			defer fr.Stop()
+			// Each call to WriteTo ends a partition.
+			if _, err := fr.WriteTo(ioutil.Discard); err != nil {
+				t.Fatalf("WriteTo failed: %v", err)
+			}
+			var buf bytes.Buffer
+			if _, err := fr.WriteTo(&buf); err != nil {
+				t.Fatalf("WriteTo failed: %v", err)
+			}
+			if got := bytes.Count(buf.Bytes(), []byte(traceHeader)); got < tc.minParts || got > tc.maxParts {
+				t.Errorf("snapshot has %d partitions, want %d to %d", got, tc.minParts, tc.maxParts)
+			}
+			parseTrace(t, &buf)
+		})
+	}
+}
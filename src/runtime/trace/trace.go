@@ -34,6 +34,29 @@
 // See the net/http/pprof package for more details about all of the
 // debug endpoints installed by this import.
 //
+// Flight recording
+//
+// Tracing is often most useful for understanding a problem that has
+// already happened, such as a request that took much longer than its
+// deadline. A FlightRecorder keeps tracing continuously into a ring buffer
+// in memory that holds only the last few seconds of the trace. When the
+// program notices a problem, it calls WriteTo to save a snapshot of the
+// recent past:
+//
+//     fr := trace.NewFlightRecorder(trace.FlightRecorderConfig{
+//             MinAge: 5 * time.Second,
+//     })
+//     fr.Start()
+//     ...
+//     if elapsed > slo {
+//             var b bytes.Buffer
+//             fr.WriteTo(&b)
+//             // Save b.Bytes() for later inspection with `go tool trace`.
+//     }
+//
+// The flight recorder has the same per-event cost as Start, and it
+// also stops the world briefly about once a second.
+//
 // User annotation
 //
 // Package trace provides user annotation APIs that can be used to
@@ -112,6 +135,7 @@ import (
 	"runtime"
 	"sync"
 	"sync/atomic"
+	"time"
 )
 
 // Start enables tracing for the current program.
@@ -139,15 +163,72 @@ func Start(w io.Writer) error {
 
 // Stop stops the current tracing, if any.
 // Stop only returns after all the writes for the trace have completed.
+// Stop does not stop a FlightRecorder.
 func Stop() {
 	tracing.Lock()
 	defer tracing.Unlock()
+	if tracing.recorder != nil {
+		return
+	}
 	atomic.StoreInt32(&tracing.enabled, 0)
 
 	runtime.StopTrace()
 }
 
 var tracing struct {
-	sync.Mutex       // gate mutators (Start, Stop)
-	enabled    int32 // accessed via atomic
+	sync.Mutex                 // gate mutators (Start, Stop, FlightRecorder.Start, FlightRecorder.Stop)
+	enabled    int32           // accessed via atomic
+	advancer   *advancer       // ends trace partitions while the flight recorder runs
+	recorder   *FlightRecorder // flight recorder that enabled tracing, if any
+}
+
+// advancePeriod is how often the flight recorder splits the trace into a
+// new partition. Ending a partition stops the world, so an ordinary trace
+// started by Start is written as a single partition.
+const advancePeriod = 1 * time.Second
+
+// An advancer periodically ends the current trace partition and starts
+// a new one.
+type advancer struct {
+	done    chan struct{} // closed to stop the advancer
+	stopped chan struct{} // closed when the advancer has stopped
 }
+
+func startAdvancer(period time.Duration) *advancer {
+	a := &advancer{
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	go func() {
+		defer close(a.stopped)
+		t := time.NewTicker(period)
+		defer t.Stop()
+		for {
+			select {
+			case <-t.C:
+				runtime_traceAdvance()
+			case <-a.done:
+				return
+			}
+		}
+	}()
+	return a
+}
+
+// stop stops the advancer and waits for it to exit.
+func (a *advancer) stop() {
+	close(a.done)
+	<-a.stopped
+}
+
+// runtime_traceAdvance ends the current trace partition and starts a new
+// one. It returns the sequence number of the partition that was ended,
+// or 0 if tracing is not enabled.
+// The function body is defined in runtime/trace.go.
+func runtime_traceAdvance() uint64
+
+// runtime_readTrace is like runtime.ReadTrace, but it also returns the
+// sequence number of the trace partition that data starts, or 0 if data
+// continues the current partition.
+// The function body is defined in runtime/trace.go.
+func runtime_readTrace() (data []byte, partition uint64)
@@ -590,3 +590,122 @@ func saveTrace(t *testing.T, buf *bytes.Buffer, name string) {
 		t.Errorf("failed to write trace file: %s", err)
 	}
 }
+
+func TestTraceAdvance(t *testing.T) {
+	if runtime.GOOS == "js" {
+		t.Skip("no os.Pipe on js")
+	}
+	if IsEnabled() {
+		t.Skip("skipping because -test.trace is set")
+	}
+	if testing.Short() {
+		t.Skip("skipping in -short mode")
+	}
+
+	var wg sync.WaitGroup
+	done := make(chan bool)
+
+	// Create goroutines that are blocked and blocked in a syscall
+	// across partitions.
+	rp, wp, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("failed to create pipe: %v", err)
+	}
+	defer func() {
+		rp.Close()
+		wp.Close()
+	}()
+	wg.Add(2)
+	go func() {
+		<-done
+		wg.Done()
+	}()
+	go func() {
+		var tmp [1]byte
+		rp.Read(tmp[:])
+		wg.Done()
+	}()
+
+	buf := new(bytes.Buffer)
+	if err := Start(buf); err != nil {
+		t.Fatalf("failed to start tracing: %v", err)
+	}
+
+	// End partitions while goroutines are running, blocking, and
+	// being created and destroyed.
+	for p := 0; p < 4; p++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				c := make(chan int)
+				go func() { c <- 1 }()
+				<-c
+				runtime.Gosched()
+			}
+		}()
+	}
+	go func() {
+		runtime.Gosched()
+		select {}
+	}()
+	const partitions = 20
+	for i := 0; i < partitions; i++ {
+		if i == partitions/2 {
+			runtime.GC()
+		}
+		if seq := Advance(); seq == 0 {
+			t.Fatal("Advance returned 0 while tracing")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	var tmp [1]byte
+	wp.Write(tmp[:])
+	close(done)
+	wg.Wait()
+
+	Stop()
+	if seq := Advance(); seq != 0 {
+		t.Errorf("Advance returned %d after Stop", seq)
+	}
+	saveTrace(t, buf, "TestTraceAdvance")
+	if got := bytes.Count(buf.Bytes(), []byte(traceHeader)); got < partitions+1 {
+		t.Errorf("trace has %d partitions, want at least %d", got, partitions+1)
+	}
+	data := buf.Bytes()
+	events, _ := parseTrace(t, buf)
+	created := make(map[uint64]bool)
+	for _, ev := range events {
+		if ev.Type == trace.EvGoCreate {
+			if created[ev.Args[0]] {
+				t.Fatalf("goroutine %d created twice", ev.Args[0])
+			}
+			created[ev.Args[0]] = true
+		}
+	}
+	testBrokenTimestamps(t, data)
+}
+
+func TestStartSinglePartition(t *testing.T) {
+	if IsEnabled() {
+		t.Skip("skipping because -test.trace is set")
+	}
+	if testing.Short() {
+		t.Skip("skipping in -short mode")
+	}
+	buf := new(bytes.Buffer)
+	if err := Start(buf); err != nil {
+		t.Fatalf("failed to start tracing: %v", err)
+	}
+	// Only the flight recorder ends partitions periodically.
+	time.Sleep(AdvancePeriod + AdvancePeriod/2)
+	Stop()
+	if got := bytes.Count(buf.Bytes(), []byte(traceHeader)); got != 1 {
+		t.Errorf("trace has %d partitions, want 1", got)
+	}
+}
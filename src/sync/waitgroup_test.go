@@ -118,6 +118,7 @@ func TestWaitGroupMisuse2(t *testing.T) {
 			}()
 			atomic.AddUint32(&here, 1)
 			pollUntilEqual(&here, 3)
+			//vet:ignore waitgroup
 			wg.Add(1) // This is the bad guy.
 			wg.Done()
 		}()
@@ -35,8 +35,9 @@ var (
 )
 
 type benchTimeFlag struct {
-	d time.Duration
-	n int
+	d         time.Duration
+	n         int
+	allowZero bool // if true, allow user to specify zero duration or count
 }
 
 func (f *benchTimeFlag) String() string {
@@ -49,17 +50,17 @@ func (f *benchTimeFlag) String() string {
 func (f *benchTimeFlag) Set(s string) error {
 	if strings.HasSuffix(s, "x") {
 		n, err := strconv.ParseInt(s[:len(s)-1], 10, 0)
-		if err != nil || n <= 0 {
+		if err != nil || n < 0 || (!f.allowZero && n == 0) {
 			return fmt.Errorf("invalid count")
 		}
-		*f = benchTimeFlag{n: int(n)}
+		*f = benchTimeFlag{n: int(n), allowZero: f.allowZero}
 		return nil
 	}
 	d, err := time.ParseDuration(s)
-	if err != nil || d <= 0 {
+	if err != nil || d < 0 || (!f.allowZero && d == 0) {
 		return fmt.Errorf("invalid duration")
 	}
-	*f = benchTimeFlag{d: d}
+	*f = benchTimeFlag{d: d, allowZero: f.allowZero}
 	return nil
 }
 
@@ -546,7 +547,7 @@ func runBenchmarks(importPath string, matchString func(pat, str string) (bool, e
 // processBench runs bench b for the configured CPU counts and prints the results.
 func (ctx *benchContext) processBench(b *B) {
 	for i, procs := range cpuList {
-		for j := uint(0); j < *count; j++ {
+		for j := uint(0); j < count.n; j++ {
 			runtime.GOMAXPROCS(procs)
 			benchName := benchmarkName(b.name, procs)
 
@@ -0,0 +1,47 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fstest
+
+import (
+	"io/fs"
+	"strings"
+	"testing"
+)
+
+func TestMapFS(t *testing.T) {
+	m := MapFS{
+		"hello":             {Data: []byte("hello, world\n")},
+		"fortune/k/ken.txt": {Data: []byte("If a program is too slow, it must have a loop.\n")},
+		"empty":             {Mode: fs.ModeDir},
+	}
+	if err := TestFS(m, "hello", "fortune/k/ken.txt", "empty"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMapFSMissing(t *testing.T) {
+	m := MapFS{"hello": {Data: []byte("hello")}}
+	err := TestFS(m, "hello", "goodbye")
+	if err == nil || !strings.Contains(err.Error(), "expected but not found: goodbye") {
+		t.Errorf("TestFS with missing file = %v, want report of goodbye", err)
+	}
+}
+
+// brokenFS reports directory entries that cannot be opened.
+type brokenFS struct{ MapFS }
+
+func (b brokenFS) Open(name string) (fs.File, error) {
+	if name == "hello" {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrPermission}
+	}
+	return b.MapFS.Open(name)
+}
+
+func TestTestFSReportsErrors(t *testing.T) {
+	err := TestFS(brokenFS{MapFS{"hello": {Data: []byte("hello")}}}, "hello")
+	if err == nil || !strings.Contains(err.Error(), "hello: Open: open hello: permission denied") {
+		t.Errorf("TestFS on broken file system = %v, want Open error", err)
+	}
+}
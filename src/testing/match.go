@@ -74,6 +74,17 @@ func (m *matcher) fullName(c *common, subname string) (name string, ok, partial
 	return name, true, len(elem) < len(m.filter)
 }
 
+// clearSubNames clears the matcher's internal state, potentially freeing
+// memory. After this is called, T.Name may return the same strings as it did
+// for earlier subtests.
+func (m *matcher) clearSubNames() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for key := range m.subNames {
+		delete(m.subNames, key)
+	}
+}
+
 func splitRegexp(s string) []string {
 	a := make([]string, 0, strings.Count(s, "/"))
 	cs := 0
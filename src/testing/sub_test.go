@@ -840,6 +840,205 @@ func TestLogAfterComplete(t *T) {
 	}
 }
 
+func TestTestTimeout(t *T) {
+	ctx := newTestContext(1, newMatcher(regexp.MatchString, "", ""))
+	ctx.testTimeout = 100 * time.Millisecond
+	buf := &bytes.Buffer{}
+	root := &T{
+		common: common{
+			signal: make(chan bool),
+			name:   "Test",
+			w:      buf,
+		},
+		context: ctx,
+	}
+	block := make(chan bool)
+	defer close(block)
+
+	if root.Run("stuck", func(t *T) { <-block }) {
+		t.Errorf("stuck test did not fail")
+	}
+	if !root.Run("fine", func(t *T) {
+		if d, ok := t.Deadline(); !ok || time.Until(d) > ctx.testTimeout {
+			t.Errorf("Deadline() = %v, %v; want a deadline within %v", d, ok, ctx.testTimeout)
+		}
+	}) {
+		t.Errorf("test after the stuck test failed")
+	}
+	ctx.release()
+
+	got := buf.String()
+	for _, want := range []string{
+		"--- FAIL: stuck (",
+		"    test timed out after 100ms\n",
+		"testing.TestTestTimeout.func",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output does not contain %q:\n%s", want, got)
+		}
+	}
+	// Only the stack of the test's goroutine is printed.
+	for _, bad := range []string{"testing.(*T).timeout", "time.goFunc"} {
+		if strings.Contains(got, bad) {
+			t.Errorf("output contains %q:\n%s", bad, got)
+		}
+	}
+}
+
+func TestTestTimeoutSubtest(t *T) {
+	ctx := newTestContext(1, newMatcher(regexp.MatchString, "", ""))
+	ctx.testTimeout = 100 * time.Millisecond
+	buf := &bytes.Buffer{}
+	root := &T{
+		common: common{
+			signal: make(chan bool),
+			name:   "Test",
+			w:      buf,
+		},
+		context: ctx,
+	}
+	block := make(chan bool)
+	defer close(block)
+
+	root.Run("parent", func(t *T) {
+		t.Run("child", func(t *T) { <-block })
+		<-block
+	})
+	ctx.release()
+
+	// Whichever timer fires first, both tests time out and are reported,
+	// each with the stack of its own goroutine. If the child's fires
+	// first, the parent resumes and then blocks until its own fires.
+	got := buf.String()
+	for _, want := range []string{
+		"--- FAIL: parent (",
+		"    --- FAIL: parent/child (",
+		"testing.TestTestTimeoutSubtest.func1.1(",
+		"testing.TestTestTimeoutSubtest.func1(",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output does not contain %q:\n%s", want, got)
+		}
+	}
+	if n := strings.Count(got, "test timed out after"); n != 2 {
+		t.Errorf("output has %d timeouts, want 2:\n%s", n, got)
+	}
+}
+
+func TestTestTimeoutLateLog(t *T) {
+	ctx := newTestContext(1, newMatcher(regexp.MatchString, "", ""))
+	ctx.testTimeout = 50 * time.Millisecond
+	root := &T{
+		common: common{
+			signal: make(chan bool),
+			name:   "Test",
+			w:      &bytes.Buffer{},
+		},
+		context: ctx,
+	}
+	release := make(chan bool)
+	recovered := make(chan interface{})
+	root.Run("stuck", func(t *T) {
+		defer func() { recovered <- recover() }()
+		<-release
+		t.Log("log after timeout")
+	})
+	ctx.release()
+	close(release)
+
+	p := <-recovered
+	const want = "Log in goroutine after stuck has completed: log after timeout"
+	if s, ok := p.(string); !ok || !strings.Contains(s, want) {
+		t.Errorf("late Log panicked with %v, want %q", p, want)
+	}
+}
+
+func TestTestTimeoutParentDeadline(t *T) {
+	// A subtest times out no later than its parent.
+	ctx := newTestContext(1, newMatcher(regexp.MatchString, "", ""))
+	ctx.testTimeout = 400 * time.Millisecond
+	root := &T{
+		common: common{
+			signal: make(chan bool),
+			name:   "Test",
+			w:      &bytes.Buffer{},
+		},
+		context: ctx,
+	}
+	block := make(chan bool)
+	defer close(block)
+
+	timedOut := make(chan time.Duration, 1)
+	root.Run("parent", func(t *T) {
+		time.Sleep(200 * time.Millisecond)
+		t.Run("child", func(t *T) {
+			start := time.Now()
+			d, _ := t.Deadline()
+			t.parent.mu.RLock()
+			pd := t.parent.deadline
+			t.parent.mu.RUnlock()
+			if !d.Equal(pd) {
+				t.Errorf("Deadline() = %v, want parent's deadline %v", d, pd)
+			}
+			for !t.Failed() {
+				time.Sleep(10 * time.Millisecond)
+			}
+			timedOut <- time.Since(start)
+			<-block
+		})
+	})
+	ctx.release()
+
+	if d := <-timedOut; d > 350*time.Millisecond {
+		t.Errorf("child timed out after %v, want about 200ms", d)
+	}
+}
+
+func TestTestTimeoutAtDeadline(t *T) {
+	// Tests that finish just as their timer fires are reported exactly
+	// once, either as passed or as timed out, and do not block the tests
+	// after them. Run with -race -count=N to exercise the race.
+	ctx := newTestContext(1, newMatcher(regexp.MatchString, "", ""))
+	ctx.testTimeout = time.Millisecond
+	buf := &bytes.Buffer{}
+	root := &T{
+		common: common{
+			signal: make(chan bool),
+			name:   "Test",
+			w:      buf,
+		},
+		context: ctx,
+	}
+	root.chatty = newChattyPrinter(root.w)
+
+	const n = 200
+	failedBefore := atomic.LoadUint32(&numFailed)
+	failed := 0
+	for i := 0; i < n; i++ {
+		// Spread the ends of the tests on both sides of the deadline,
+		// where their timers fire.
+		late := time.Duration(i%50-25) * 20 * time.Microsecond
+		if !root.Run("edge", func(t *T) {
+			d, _ := t.Deadline()
+			time.Sleep(time.Until(d.Add(late)))
+		}) {
+			failed++
+		}
+	}
+	ctx.release()
+
+	got := buf.String()
+	if reports := strings.Count(got, "--- PASS: edge") + strings.Count(got, "--- FAIL: edge"); reports != n {
+		t.Errorf("output has %d reports, want %d:\n%s", reports, n, got)
+	}
+	if fails := strings.Count(got, "--- FAIL: edge"); fails != failed {
+		t.Errorf("output has %d failures, Run reported %d", fails, failed)
+	}
+	if d := int(atomic.LoadUint32(&numFailed) - failedBefore); d != failed {
+		t.Errorf("numFailed grew by %d, want %d", d, failed)
+	}
+}
+
 func TestBenchmark(t *T) {
 	if Short() {
 		t.Skip("skipping in short mode")
@@ -132,6 +132,45 @@
 // example function, at least one other function, type, variable, or constant
 // declaration, and no test or benchmark functions.
 //
+// Fuzzing
+//
+// 'go test' and the testing package support fuzzing, a testing technique where
+// a function is called with randomly generated inputs to find bugs not
+// anticipated by unit tests.
+//
+// Functions of the form
+//     func FuzzXxx(*testing.F)
+// are considered fuzz targets. For example:
+//
+//     func FuzzHex(f *testing.F) {
+//         for _, seed := range [][]byte{{}, {0}, {9}, {0xa}, {0xf}, {1, 2, 3, 4}} {
+//             f.Add(seed)
+//         }
+//         f.Fuzz(func(t *testing.T, in []byte) {
+//             enc := hex.EncodeToString(in)
+//             out, err := hex.DecodeString(enc)
+//             if err != nil {
+//                 t.Fatalf("%v: decode: %v", in, err)
+//             }
+//             if !bytes.Equal(in, out) {
+//                 t.Fatalf("%v: not equal after round trip: %v", in, out)
+//             }
+//         })
+//     }
+//
+// A fuzz target must call F.Fuzz exactly once with a fuzz function: a function
+// whose first parameter is *T and whose remaining parameters are the arguments
+// to be fuzzed. The seed corpus for a target is the set of values registered
+// with F.Add together with the files in the directory testdata/fuzz/FuzzXxx.
+//
+// By default, 'go test' runs the fuzz function once for each seed corpus
+// entry, as a subtest named FuzzXxx/<entry>. When the -fuzz flag selects a
+// target, 'go test' instead builds the test binary with coverage
+// instrumentation and repeatedly calls the fuzz function with inputs mutated
+// from the corpus, keeping inputs that reach new code. A failing input is
+// minimized and written to testdata/fuzz/FuzzXxx, so that later runs of
+// 'go test' replay it as part of the seed corpus.
+//
 // Skipping
 //
 // Tests or benchmarks may be skipped at run time with a call to
@@ -243,7 +282,9 @@ import (
 	"internal/race"
 	"io"
 	"io/ioutil"
+	"math/rand"
 	"os"
+	"reflect"
 	"runtime"
 	"runtime/debug"
 	"runtime/trace"
@@ -283,7 +324,8 @@ func Init() {
 	outputDir = flag.String("test.outputdir", "", "write profiles to `dir`")
 	// Report as tests are run; default is silent for success.
 	chatty = flag.Bool("test.v", false, "verbose: print additional output")
-	count = flag.Uint("test.count", 1, "run tests and benchmarks `n` times")
+	count = &countFlag{n: 1}
+	flag.Var(count, "test.count", "run tests and benchmarks `n` times, or \"untilfail\" to repeat tests until one fails")
 	coverProfile = flag.String("test.coverprofile", "", "write a coverage profile to `file`")
 	matchList = flag.String("test.list", "", "list tests, examples, and benchmarks matching `regexp` then exit")
 	match = flag.String("test.run", "", "run only tests and examples matching `regexp`")
@@ -296,11 +338,15 @@ func Init() {
 	mutexProfileFraction = flag.Int("test.mutexprofilefraction", 1, "if >= 0, calls runtime.SetMutexProfileFraction()")
 	traceFile = flag.String("test.trace", "", "write an execution trace to `file`")
 	timeout = flag.Duration("test.timeout", 0, "panic test binary after duration `d` (default 0, timeout disabled)")
+	goroutineLeakCheck = flag.Bool("test.goroutineleakcheck", false, "fail each top-level test that leaves goroutines blocked forever")
+	testTimeout = flag.Duration("test.testtimeout", 0, "fail each test that runs longer than duration `d` (default 0, per-test timeout disabled)")
+	shuffle = flag.String("test.shuffle", "off", "randomize the execution order of tests and benchmarks (\"off\", \"on\", or a random `seed`)")
 	cpuListStr = flag.String("test.cpu", "", "comma-separated `list` of cpu counts to run each test with")
 	parallel = flag.Int("test.parallel", runtime.GOMAXPROCS(0), "run at most `n` tests in parallel")
 	testlog = flag.String("test.testlogfile", "", "write test action log to `file` (for use only by cmd/go)")
 
 	initBenchmarkFlags()
+	initFuzzFlags()
 }
 
 var (
@@ -309,7 +355,7 @@ var (
 	failFast             *bool
 	outputDir            *string
 	chatty               *bool
-	count                *uint
+	count                *countFlag
 	coverProfile         *string
 	matchList            *string
 	match                *string
@@ -322,6 +368,9 @@ var (
 	mutexProfileFraction *int
 	traceFile            *string
 	timeout              *time.Duration
+	testTimeout          *time.Duration
+	goroutineLeakCheck   *bool
+	shuffle              *string
 	cpuListStr           *string
 	parallel             *int
 	testlog              *string
@@ -330,6 +379,7 @@ var (
 
 	cpuList     []int
 	testlogFile *os.File
+	leaks       *leakChecker // set by -test.goroutineleakcheck
 
 	numFailed uint32 // number of test failures
 )
@@ -389,6 +439,9 @@ type common struct {
 	failed      bool                // Test or benchmark has failed.
 	skipped     bool                // Test of benchmark has been skipped.
 	done        bool                // Test is finished and all subtests have completed.
+	timedOut    bool                // Test timed out and its goroutine was abandoned (-test.testtimeout).
+	deadline    time.Time           // Time at which the running test times out (-test.testtimeout); zero if none.
+	running     map[*T]bool         // Subtests started by Run that have not completed (-test.testtimeout).
 	helpers     map[string]struct{} // functions to be skipped when writing file/line info
 	cleanup     func()              // optional function to be called at the end of the test
 	cleanupName string              // Name of the cleanup function.
@@ -635,6 +688,8 @@ type TB interface {
 	Skipf(format string, args ...interface{})
 	Skipped() bool
 	TempDir() string
+	Setenv(key, value string)
+	Chdir(dir string)
 
 	// A private method to prevent users implementing the
 	// interface and so future additions to it will not
@@ -657,7 +712,16 @@ var _ TB = (*B)(nil)
 type T struct {
 	common
 	isParallel bool
+	inFuzzFn   bool         // Whether the test is running a fuzz function.
 	context    *testContext // For running tests and subtests.
+
+	parallelAncestor bool // Some ancestor of the test called Parallel.
+	denyParallel     bool // Setenv or Chdir was called; the test must not call Parallel.
+
+	// Per-test timeout state, guarded by mu. See startTimeout.
+	timer    *time.Timer // fires when the test times out
+	timerGen int         // generation of timer; incremented when it is stopped
+	goid     int64       // ID of the goroutine running the test function
 }
 
 func (c *common) private() {}
@@ -747,12 +811,17 @@ func (c *common) logDepth(s string, depth int) {
 		for parent := c.parent; parent != nil; parent = parent.parent {
 			parent.mu.Lock()
 			defer parent.mu.Unlock()
+			if parent.parent == nil && c.timedOut {
+				// The output of the root test is never printed, so
+				// the message would be lost.
+				break
+			}
 			if !parent.done {
 				parent.output = append(parent.output, parent.decorate(s, depth+1)...)
 				return
 			}
 		}
-		panic("Log in goroutine after " + c.name + " has completed")
+		panic("Log in goroutine after " + c.name + " has completed: " + s)
 	} else {
 		if c.chatty != nil {
 			if c.bench {
@@ -928,6 +997,90 @@ func (c *common) TempDir() string {
 	return dir
 }
 
+// Setenv calls os.Setenv(key, value) and uses Cleanup to
+// restore the environment variable to its original value
+// after the test.
+//
+// Because Setenv affects the whole process, it cannot be used
+// in parallel tests or tests with parallel ancestors.
+func (c *common) Setenv(key, value string) {
+	c.Helper()
+	prevValue, ok := os.LookupEnv(key)
+	if err := os.Setenv(key, value); err != nil {
+		c.Fatalf("Setenv: %v", err)
+	}
+	if ok {
+		c.Cleanup(func() {
+			os.Setenv(key, prevValue)
+		})
+	} else {
+		c.Cleanup(func() {
+			os.Unsetenv(key)
+		})
+	}
+}
+
+// Chdir calls os.Chdir(dir) and uses Cleanup to restore the current
+// working directory to its original value after the test.
+//
+// Because Chdir affects the whole process, it cannot be used
+// in parallel tests or tests with parallel ancestors.
+func (c *common) Chdir(dir string) {
+	c.Helper()
+	oldwd, err := os.Getwd()
+	if err != nil {
+		c.Fatalf("Chdir: %v", err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		c.Fatalf("Chdir: %v", err)
+	}
+	c.Cleanup(func() {
+		if err := os.Chdir(oldwd); err != nil {
+			// Continuing in the wrong directory would make
+			// the remaining tests fail in confusing ways.
+			panic("testing: Chdir: cannot restore working directory: " + err.Error())
+		}
+	})
+}
+
+// Setenv calls os.Setenv(key, value) and uses Cleanup to
+// restore the environment variable to its original value
+// after the test.
+//
+// Because Setenv affects the whole process, it cannot be used
+// in parallel tests or tests with parallel ancestors: it panics
+// if the test or one of its ancestors has called Parallel, and
+// a later call to Parallel panics.
+func (t *T) Setenv(key, value string) {
+	t.checkNotParallel("Setenv")
+	t.denyParallel = true
+	t.common.Setenv(key, value)
+}
+
+// Chdir calls os.Chdir(dir) and uses Cleanup to restore the current
+// working directory to its original value after the test.
+//
+// Because Chdir affects the whole process, it cannot be used
+// in parallel tests or tests with parallel ancestors: it panics
+// if the test or one of its ancestors has called Parallel, and
+// a later call to Parallel panics.
+func (t *T) Chdir(dir string) {
+	t.checkNotParallel("Chdir")
+	t.denyParallel = true
+	t.common.Chdir(dir)
+}
+
+// checkNotParallel panics if t or one of its ancestors is a parallel
+// test. The name of the calling method is used in the message.
+func (t *T) checkNotParallel(method string) {
+	if t.isParallel {
+		panic("testing: t." + method + " called after t.Parallel; cannot change the process state in parallel tests")
+	}
+	if t.parallelAncestor {
+		panic("testing: t." + method + " called in a subtest of a parallel test; cannot change the process state in parallel tests")
+	}
+}
+
 // panicHanding is an argument to runCleanup.
 type panicHandling int
 
@@ -980,6 +1133,17 @@ func (t *T) Parallel() {
 	if t.isParallel {
 		panic("testing: t.Parallel called multiple times")
 	}
+	if t.inFuzzFn {
+		panic("testing: t.Parallel called inside a fuzz function")
+	}
+	if t.denyParallel {
+		panic("testing: t.Parallel called after t.Setenv or t.Chdir; cannot change the process state in parallel tests")
+	}
+	// Time spent waiting for serial tests does not count towards the
+	// per-test timeout; it restarts when the test resumes.
+	if !t.stopTimeout() {
+		runtime.Goexit()
+	}
 	t.isParallel = true
 
 	// We don't want to include the time we spend waiting for serial tests
@@ -1011,6 +1175,7 @@ func (t *T) Parallel() {
 
 	t.start = time.Now()
 	t.raceErrors += -race.Errors()
+	t.startTimeout()
 }
 
 // InternalTest is an internal type but exported because it is cross-package;
@@ -1030,6 +1195,19 @@ func tRunner(t *T, fn func(t *T)) {
 	// a call to runtime.Goexit, record the duration and send
 	// a signal saying that the test is done.
 	defer func() {
+		if !t.stopTimeout() {
+			// The test timed out and has already been reported
+			// and released by t.timeout; nobody is waiting for
+			// this goroutine any more. A panic, such as one from
+			// logging after the test completed, is not recovered.
+			return
+		}
+		if p := t.parent; p != nil && t.context.testTimeout > 0 {
+			p.mu.Lock()
+			delete(p.running, t)
+			p.mu.Unlock()
+		}
+
 		if t.Failed() {
 			atomic.AddUint32(&numFailed, 1)
 		}
@@ -1053,6 +1231,19 @@ func tRunner(t *T, fn func(t *T)) {
 			}
 		}
 
+		if err != nil && t.context.isFuzzing {
+			// While fuzzing, a panic in the fuzz function is a failing input,
+			// not a reason to stop the process. Record it as a test failure
+			// with the stack so the fuzzing engine can report it.
+			prefix := "panic: "
+			if err == errNilPanicOrGoexit {
+				prefix = ""
+			}
+			t.Errorf("%s%s\n%s\n", prefix, err, string(debug.Stack()))
+			t.finished = true
+			err = nil
+		}
+
 		doPanic := func(err interface{}) {
 			t.Fail()
 			if r := t.runCleanup(recoverAndReturnPanic); r != nil {
@@ -1102,6 +1293,9 @@ func tRunner(t *T, fn func(t *T)) {
 			// test. See comment in Run method.
 			t.context.release()
 		}
+		if t.context.leaks != nil && t.level == 1 {
+			t.context.leaks.check(t)
+		}
 		t.report() // Report after all subtests have finished.
 
 		// Do not lock t.done to allow race detector to detect race in case
@@ -1120,6 +1314,10 @@ func tRunner(t *T, fn func(t *T)) {
 
 	t.start = time.Now()
 	t.raceErrors = -race.Errors()
+	t.mu.Lock()
+	t.goid = runtime_goid()
+	t.mu.Unlock()
+	t.startTimeout()
 	fn(t)
 
 	// code beyond here will not be executed when FailNow is invoked
@@ -1153,7 +1351,8 @@ func (t *T) Run(name string, f func(t *T)) bool {
 			creator: pc[:n],
 			chatty:  t.chatty,
 		},
-		context: t.context,
+		context:          t.context,
+		parallelAncestor: t.isParallel || t.parallelAncestor,
 	}
 	t.w = indenter{&t.common}
 
@@ -1165,6 +1364,16 @@ func (t *T) Run(name string, f func(t *T)) bool {
 	// count correct. This ensures that a sequence of sequential tests runs
 	// without being preempted, even when their parent is a parallel test. This
 	// may especially reduce surprises if *parallel == 1.
+	if t.context.testTimeout > 0 {
+		// Let a timeout of the parent find t. See (*T).timeout.
+		p := t.parent
+		p.mu.Lock()
+		if p.running == nil {
+			p.running = make(map[*T]bool)
+		}
+		p.running[t] = true
+		p.mu.Unlock()
+	}
 	go tRunner(t, f)
 	if !<-t.signal {
 		// At this point, it is likely that FailNow was called on one of the
@@ -1175,20 +1384,251 @@ func (t *T) Run(name string, f func(t *T)) bool {
 }
 
 // Deadline reports the time at which the test binary will have
-// exceeded the timeout specified by the -timeout flag.
+// exceeded the timeout specified by the -timeout flag, or, if it is
+// earlier, the time at which the test or one of its parents will have
+// exceeded the per-test timeout specified by the -testtimeout flag.
 //
-// The ok result is false if the -timeout flag indicates “no timeout” (0).
+// The ok result is false if both flags indicate “no timeout” (0).
 func (t *T) Deadline() (deadline time.Time, ok bool) {
 	deadline = t.context.deadline
+	t.mu.RLock()
+	if !t.deadline.IsZero() && (deadline.IsZero() || t.deadline.Before(deadline)) {
+		deadline = t.deadline
+	}
+	t.mu.RUnlock()
 	return deadline, !deadline.IsZero()
 }
 
+// startTimeout arranges for t to time out if it runs for longer than
+// the -test.testtimeout duration. It is called whenever the test starts
+// or resumes running.
+func (t *T) startTimeout() {
+	d := t.context.testTimeout
+	if d <= 0 || t.level == 0 {
+		return
+	}
+	deadline := time.Now().Add(d)
+	t.parent.mu.RLock()
+	if pd := t.parent.deadline; !pd.IsZero() && pd.Before(deadline) {
+		// The parent times out first; so does t.
+		deadline = pd
+		d = time.Until(deadline).Round(time.Millisecond)
+	}
+	t.parent.mu.RUnlock()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.deadline = deadline
+	t.timerGen++
+	gen := t.timerGen
+	t.timer = time.AfterFunc(time.Until(deadline), func() { t.timeout(gen, d) })
+}
+
+// stopTimeout stops the timer started by startTimeout. It reports
+// whether the test is still alive: if it returns false, the test has
+// timed out and the caller must not report it or signal its parent.
+func (t *T) stopTimeout() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.timedOut {
+		return false
+	}
+	if t.timer != nil {
+		t.timer.Stop()
+		t.timer = nil
+	}
+	t.timerGen++
+	t.deadline = time.Time{}
+	return true
+}
+
+// timeout is called when t has been running for d without stopping the
+// timer of generation gen. It fails t, reporting the stack of the
+// goroutine running it, and releases the parent test so that the
+// remaining tests can run. Subtests that are still running are failed
+// and reported first, each with its own stack. The goroutines of these
+// tests are abandoned: when they eventually finish, tRunner ignores
+// them, and their cleanup functions are not guaranteed to run. Anything
+// they log afterwards is logged with an ancestor that has not completed,
+// as for any test that has completed, or panics if there is none.
+func (t *T) timeout(gen int, d time.Duration) {
+	t.context.timeoutMu.Lock()
+	defer t.context.timeoutMu.Unlock()
+
+	t.mu.Lock()
+	if gen != t.timerGen {
+		t.mu.Unlock()
+		return
+	}
+	// Mark t as timed out before releasing t.mu, so that a
+	// concurrent stopTimeout either wins or sees the timeout.
+	t.markTimedOut()
+	t.mu.Unlock()
+
+	t.abandon(d)
+	t.signal <- true
+}
+
+// markTimedOut marks t as timed out and stops its timer. After it is
+// called, stopTimeout reports false. t.mu must be held.
+func (t *T) markTimedOut() {
+	t.timedOut = true
+	if t.timer != nil {
+		t.timer.Stop()
+		t.timer = nil
+	}
+	t.timerGen++
+}
+
+// abandon fails and reports t, which has run for d, and its running
+// subtests. It does not signal t's parent. t must already have been
+// marked as timed out by markTimedOut.
+func (t *T) abandon(d time.Duration) {
+	t.mu.Lock()
+	goid := t.goid
+	var subs []*T
+	for sub := range t.running {
+		subs = append(subs, sub)
+	}
+	t.mu.Unlock()
+
+	for _, sub := range subs {
+		sub.mu.Lock()
+		running := sub.timer != nil && !sub.timedOut
+		if running {
+			sub.markTimedOut()
+		}
+		sub.mu.Unlock()
+		if running {
+			sub.abandon(time.Since(sub.start).Round(time.Millisecond))
+		}
+	}
+
+	msg := fmt.Sprintf("test timed out after %v\n%s", d, goroutineStack(goid))
+	// Like decorate, but without a file and line: the failure
+	// does not come from a line of the test.
+	s := "    " + strings.ReplaceAll(msg, "\n", "\n        ") + "\n"
+	if t.chatty != nil {
+		t.chatty.Printf(t.name, "%s", s)
+	} else {
+		t.mu.Lock()
+		t.output = append(t.output, s...)
+		t.mu.Unlock()
+	}
+	t.Fail()
+	atomic.AddUint32(&numFailed, 1)
+
+	t.mu.Lock()
+	t.duration += time.Since(t.start)
+	t.done = true
+	t.mu.Unlock()
+	if t.isParallel {
+		t.context.release()
+	}
+	t.report()
+	if t.parent != nil && atomic.LoadInt32(&t.hasSub) == 0 {
+		t.setRan()
+	}
+}
+
+// goroutineStack returns the stack trace of the goroutine with ID goid,
+// as printed by runtime.Stack.
+func goroutineStack(goid int64) string {
+	buf := make([]byte, 64<<10)
+	for {
+		n := runtime_goroutineStack(goid, buf)
+		if n < len(buf) {
+			return strings.TrimRight(string(buf[:n]), "\n")
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+}
+
+// runtime_goid returns the ID of the current goroutine.
+// It is defined in runtime/mprof.go.
+func runtime_goid() int64
+
+// runtime_goroutineStack is like runtime.Stack(buf, false), but formats
+// the stack trace of the goroutine with ID goid.
+// It is defined in runtime/mprof.go.
+func runtime_goroutineStack(goid int64, buf []byte) int
+
+// A leakChecker fails top-level tests that leak goroutines
+// (-test.goroutineleakcheck).
+type leakChecker struct {
+	deps testDeps
+
+	mu       sync.Mutex
+	reported map[string]bool // leaked goroutines already reported, by goroutineKey
+}
+
+// check collects the goroutineleak profile and fails t if it contains
+// goroutines that were not reported before.
+func (c *leakChecker) check(t *T) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var buf bytes.Buffer
+	if err := c.deps.WriteProfileTo("goroutineleak", &buf, 2); err != nil {
+		t.Errorf("testing: can't check for leaked goroutines: %v", err)
+		return
+	}
+	leaked := make(map[string]bool)
+	var stacks []string
+	for _, g := range strings.Split(buf.String(), "\n\n") {
+		if g = strings.TrimSpace(g); g == "" {
+			continue
+		}
+		key, err := goroutineKey(g)
+		if err != nil {
+			t.Errorf("testing: can't parse goroutineleak profile: %v", err)
+			return
+		}
+		leaked[key] = true
+		if !c.reported[key] {
+			stacks = append(stacks, g)
+		}
+	}
+	if len(stacks) > 0 {
+		if !t.Failed() {
+			atomic.AddUint32(&numFailed, 1)
+		}
+		t.Errorf("test leaked %d goroutine(s) blocked forever:\n%s", len(stacks), strings.Join(stacks, "\n\n"))
+	}
+	c.reported = leaked
+}
+
+// goroutineKey returns a key that identifies the goroutine whose stack
+// trace, as printed by runtime.Stack, is g: its ID followed by its stack.
+// The rest of the header line, such as how long the goroutine has been
+// blocked, changes over time and is left out.
+func goroutineKey(g string) (string, error) {
+	var id uint64
+	if _, err := fmt.Sscanf(g, "goroutine %d ", &id); err != nil {
+		return "", fmt.Errorf("bad goroutine header: %v", err)
+	}
+	stack := ""
+	if i := strings.Index(g, "\n"); i >= 0 {
+		stack = g[i:]
+	}
+	return strconv.FormatUint(id, 10) + stack, nil
+}
+
 // testContext holds all fields that are common to all tests. This includes
 // synchronization primitives to run at most *parallel tests.
 type testContext struct {
 	match    *matcher
 	deadline time.Time
 
+	// testTimeout is a copy of the testtimeout flag.
+	testTimeout time.Duration
+
+	// timeoutMu serializes the handling of per-test timeouts, so that
+	// a test and its subtests are not reported at the same time.
+	timeoutMu sync.Mutex
+
+	// leaks checks top-level tests for leaked goroutines, if non-nil.
+	leaks *leakChecker
+
 	mu sync.Mutex
 
 	// Channel used to signal tests that are ready to be run in parallel.
@@ -1203,6 +1643,9 @@ type testContext struct {
 
 	// maxParallel is a copy of the parallel flag.
 	maxParallel int
+
+	// isFuzzing is true while the fuzzing engine is running a fuzz target.
+	isFuzzing bool
 }
 
 func newTestContext(maxParallel int, m *matcher) *testContext {
@@ -1251,6 +1694,16 @@ func (f matchStringOnly) WriteProfileTo(string, io.Writer, int) error { return e
 func (f matchStringOnly) ImportPath() string                          { return "" }
 func (f matchStringOnly) StartTestLog(io.Writer)                      {}
 func (f matchStringOnly) StopTestLog() error                          { return errMain }
+func (f matchStringOnly) CoordinateFuzzing(time.Duration, int64, time.Duration, int64, int, []corpusEntry, []reflect.Type, string, string) error {
+	return errMain
+}
+func (f matchStringOnly) RunFuzzWorker(func(corpusEntry) error) error { return errMain }
+func (f matchStringOnly) ReadCorpus(string, []reflect.Type) ([]corpusEntry, error) {
+	return nil, errMain
+}
+func (f matchStringOnly) CheckCorpus([]interface{}, []reflect.Type) error { return nil }
+func (f matchStringOnly) ResetCoverage()                                  {}
+func (f matchStringOnly) SnapshotCoverage()                               {}
 
 // Main is an internal function, part of the implementation of the "go test" command.
 // It was exported because it is cross-package and predates "internal" packages.
@@ -1259,15 +1712,16 @@ func (f matchStringOnly) StopTestLog() error                          { return e
 // new functionality is added to the testing package.
 // Systems simulating "go test" should be updated to use MainStart.
 func Main(matchString func(pat, str string) (bool, error), tests []InternalTest, benchmarks []InternalBenchmark, examples []InternalExample) {
-	os.Exit(MainStart(matchStringOnly(matchString), tests, benchmarks, examples).Run())
+	os.Exit(MainStart(matchStringOnly(matchString), tests, benchmarks, nil, examples).Run())
 }
 
 // M is a type passed to a TestMain function to run the actual tests.
 type M struct {
-	deps       testDeps
-	tests      []InternalTest
-	benchmarks []InternalBenchmark
-	examples   []InternalExample
+	deps        testDeps
+	tests       []InternalTest
+	benchmarks  []InternalBenchmark
+	fuzzTargets []InternalFuzzTarget
+	examples    []InternalExample
 
 	timer     *time.Timer
 	afterOnce sync.Once
@@ -1291,18 +1745,25 @@ type testDeps interface {
 	StartTestLog(io.Writer)
 	StopTestLog() error
 	WriteProfileTo(string, io.Writer, int) error
+	CoordinateFuzzing(time.Duration, int64, time.Duration, int64, int, []corpusEntry, []reflect.Type, string, string) error
+	RunFuzzWorker(func(corpusEntry) error) error
+	ReadCorpus(string, []reflect.Type) ([]corpusEntry, error)
+	CheckCorpus([]interface{}, []reflect.Type) error
+	ResetCoverage()
+	SnapshotCoverage()
 }
 
 // MainStart is meant for use by tests generated by 'go test'.
 // It is not meant to be called directly and is not subject to the Go 1 compatibility document.
 // It may change signature from release to release.
-func MainStart(deps testDeps, tests []InternalTest, benchmarks []InternalBenchmark, examples []InternalExample) *M {
+func MainStart(deps testDeps, tests []InternalTest, benchmarks []InternalBenchmark, fuzzTargets []InternalFuzzTarget, examples []InternalExample) *M {
 	Init()
 	return &M{
-		deps:       deps,
-		tests:      tests,
-		benchmarks: benchmarks,
-		examples:   examples,
+		deps:        deps,
+		tests:       tests,
+		benchmarks:  benchmarks,
+		fuzzTargets: fuzzTargets,
+		examples:    examples,
 	}
 }
 
@@ -1331,31 +1792,72 @@ func (m *M) Run() (code int) {
 	}
 
 	if len(*matchList) != 0 {
-		listTests(m.deps.MatchString, m.tests, m.benchmarks, m.examples)
+		listTests(m.deps.MatchString, m.tests, m.benchmarks, m.fuzzTargets, m.examples)
 		m.exitCode = 0
 		return
 	}
 
 	parseCpuList()
 
+	if *shuffle != "off" && !*isFuzzWorker {
+		var seed int64
+		if *shuffle == "on" {
+			seed = time.Now().UnixNano()
+		} else {
+			var err error
+			seed, err = strconv.ParseInt(*shuffle, 10, 64)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, `testing: -shuffle should be "off", "on", or a valid integer:`, err)
+				m.exitCode = 2
+				return
+			}
+		}
+		// Report the seed so that the order can be reproduced
+		// by passing it back in with -test.shuffle.
+		fmt.Println("-test.shuffle", seed)
+		rng := rand.New(rand.NewSource(seed))
+		rng.Shuffle(len(m.tests), func(i, j int) { m.tests[i], m.tests[j] = m.tests[j], m.tests[i] })
+		rng.Shuffle(len(m.benchmarks), func(i, j int) { m.benchmarks[i], m.benchmarks[j] = m.benchmarks[j], m.benchmarks[i] })
+	}
+
 	m.before()
 	defer m.after()
-	deadline := m.startAlarm()
-	haveExamples = len(m.examples) > 0
-	testRan, testOk := runTests(m.deps.MatchString, m.tests, deadline)
-	exampleRan, exampleOk := runExamples(m.deps.MatchString, m.examples)
-	m.stopAlarm()
-	if !testRan && !exampleRan && *matchBenchmarks == "" {
-		fmt.Fprintln(os.Stderr, "testing: warning: no tests to run")
+
+	// Run tests, examples, and benchmarks unless this is a fuzz worker process.
+	// Workers start after this is done by the same process, and they should
+	// not repeat this work.
+	if !*isFuzzWorker {
+		deadline := m.startAlarm()
+		haveExamples = len(m.examples) > 0
+		testRan, testOk := runTests(m.deps.MatchString, m.tests, deadline)
+		fuzzTargetsRan, fuzzTargetsOk := runFuzzTests(m.deps, m.fuzzTargets, deadline)
+		exampleRan, exampleOk := runExamples(m.deps.MatchString, m.examples)
+		m.stopAlarm()
+		if !testRan && !exampleRan && !fuzzTargetsRan && *matchBenchmarks == "" && *matchFuzz == "" {
+			fmt.Fprintln(os.Stderr, "testing: warning: no tests to run")
+		}
+		if !testOk || !exampleOk || !fuzzTargetsOk || !runBenchmarks(m.deps.ImportPath(), m.deps.MatchString, m.benchmarks) || race.Errors() > 0 {
+			fmt.Println("FAIL")
+			m.exitCode = 1
+			return
+		}
 	}
-	if !testOk || !exampleOk || !runBenchmarks(m.deps.ImportPath(), m.deps.MatchString, m.benchmarks) || race.Errors() > 0 {
+
+	fuzzingOk := runFuzzing(m.deps, m.fuzzTargets)
+	if !fuzzingOk {
 		fmt.Println("FAIL")
-		m.exitCode = 1
+		if *isFuzzWorker {
+			m.exitCode = fuzzWorkerExitCode
+		} else {
+			m.exitCode = 1
+		}
 		return
 	}
 
-	fmt.Println("PASS")
 	m.exitCode = 0
+	if !*isFuzzWorker {
+		fmt.Println("PASS")
+	}
 	return
 }
 
@@ -1376,7 +1878,7 @@ func (t *T) report() {
 	}
 }
 
-func listTests(matchString func(pat, str string) (bool, error), tests []InternalTest, benchmarks []InternalBenchmark, examples []InternalExample) {
+func listTests(matchString func(pat, str string) (bool, error), tests []InternalTest, benchmarks []InternalBenchmark, fuzzTargets []InternalFuzzTarget, examples []InternalExample) {
 	if _, err := matchString(*matchList, "non-empty"); err != nil {
 		fmt.Fprintf(os.Stderr, "testing: invalid regexp in -test.list (%q): %s\n", *matchList, err)
 		os.Exit(1)
@@ -1392,6 +1894,11 @@ func listTests(matchString func(pat, str string) (bool, error), tests []Internal
 			fmt.Println(bench.Name)
 		}
 	}
+	for _, fuzzTarget := range fuzzTargets {
+		if ok, _ := matchString(*matchList, fuzzTarget.Name); ok {
+			fmt.Println(fuzzTarget.Name)
+		}
+	}
 	for _, example := range examples {
 		if ok, _ := matchString(*matchList, example.Name); ok {
 			fmt.Println(example.Name)
@@ -1415,14 +1922,36 @@ func RunTests(matchString func(pat, str string) (bool, error), tests []InternalT
 
 func runTests(matchString func(pat, str string) (bool, error), tests []InternalTest, deadline time.Time) (ran, ok bool) {
 	ok = true
+	for {
+		start := time.Now()
+		runTestsOnce(matchString, tests, deadline, &ran, &ok)
+
+		// With -count=untilfail, repeat the tests until one of them
+		// fails, or until another round would likely exceed the
+		// -timeout deadline.
+		if !count.untilFail || !ok || !ran {
+			break
+		}
+		if !deadline.IsZero() && time.Now().Add(time.Since(start)).After(deadline) {
+			break
+		}
+	}
+	return ran, ok
+}
+
+// runTestsOnce runs tests count.n times for each -cpu value,
+// accumulating the results in *ran and *ok.
+func runTestsOnce(matchString func(pat, str string) (bool, error), tests []InternalTest, deadline time.Time, ran, ok *bool) {
 	for _, procs := range cpuList {
 		runtime.GOMAXPROCS(procs)
-		for i := uint(0); i < *count; i++ {
+		for i := uint(0); i < count.n; i++ {
 			if shouldFailFast() {
 				break
 			}
 			ctx := newTestContext(*parallel, newMatcher(matchString, *match, "-test.run"))
 			ctx.deadline = deadline
+			ctx.testTimeout = *testTimeout
+			ctx.leaks = leaks
 			t := &T{
 				common: common{
 					signal:  make(chan bool),
@@ -1443,11 +1972,10 @@ func runTests(matchString func(pat, str string) (bool, error), tests []InternalT
 				// phase as this pollutes the stacktrace output when aborting.
 				go func() { <-t.signal }()
 			})
-			ok = ok && !t.Failed()
-			ran = ran || t.ran
+			*ok = *ok && !t.Failed()
+			*ran = *ran || t.ran
 		}
 	}
-	return ran, ok
 }
 
 // before runs before all testing.
@@ -1455,6 +1983,9 @@ func (m *M) before() {
 	if *memProfileRate > 0 {
 		runtime.MemProfileRate = *memProfileRate
 	}
+	if *goroutineLeakCheck {
+		leaks = &leakChecker{deps: m.deps}
+	}
 	if *cpuProfile != "" {
 		f, err := os.Create(toOutputDir(*cpuProfile))
 		if err != nil {
@@ -1645,6 +2176,34 @@ func parseCpuList() {
 	}
 }
 
+// countFlag implements the -test.count flag: the number of times to run
+// each test and benchmark, or "untilfail" to run the tests repeatedly
+// until one of them fails.
+type countFlag struct {
+	n         uint // number of runs; 1 if untilFail is set
+	untilFail bool
+}
+
+func (f *countFlag) String() string {
+	if f.untilFail {
+		return "untilfail"
+	}
+	return strconv.FormatUint(uint64(f.n), 10)
+}
+
+func (f *countFlag) Set(s string) error {
+	if s == "untilfail" {
+		f.n, f.untilFail = 1, true
+		return nil
+	}
+	n, err := strconv.ParseUint(s, 0, strconv.IntSize)
+	if err != nil {
+		return errors.New(`must be a non-negative integer or "untilfail"`)
+	}
+	f.n, f.untilFail = uint(n), false
+	return nil
+}
+
 func shouldFailFast() bool {
 	return *failFast && atomic.LoadUint32(&numFailed) > 0
 }
@@ -8,6 +8,7 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -78,3 +79,122 @@ func testTempDir(t *testing.T) {
 		t.Errorf("unexpected %d files in TempDir: %v", len(fis), fis)
 	}
 }
+
+func TestSetenv(t *testing.T) {
+	tests := []struct {
+		name               string
+		key                string
+		initialValueExists bool
+		initialValue       string
+		newValue           string
+	}{
+		{
+			name:               "initial value exists",
+			key:                "GO_TEST_KEY_1",
+			initialValueExists: true,
+			initialValue:       "111",
+			newValue:           "222",
+		},
+		{
+			name:               "initial value exists but empty",
+			key:                "GO_TEST_KEY_2",
+			initialValueExists: true,
+			initialValue:       "",
+			newValue:           "222",
+		},
+		{
+			name:               "initial value is not exists",
+			key:                "GO_TEST_KEY_3",
+			initialValueExists: false,
+			initialValue:       "",
+			newValue:           "222",
+		},
+	}
+
+	for _, test := range tests {
+		if test.initialValueExists {
+			if err := os.Setenv(test.key, test.initialValue); err != nil {
+				t.Fatalf("unable to set env: got %v", err)
+			}
+		} else {
+			os.Unsetenv(test.key)
+		}
+
+		t.Run(test.name, func(t *testing.T) {
+			t.Setenv(test.key, test.newValue)
+			if os.Getenv(test.key) != test.newValue {
+				t.Fatalf("unexpected value after t.Setenv: got %s, want %s", os.Getenv(test.key), test.newValue)
+			}
+		})
+
+		got, exists := os.LookupEnv(test.key)
+		if got != test.initialValue {
+			t.Fatalf("unexpected value after t.Setenv cleanup: got %s, want %s", got, test.initialValue)
+		}
+		if exists != test.initialValueExists {
+			t.Fatalf("unexpected value after t.Setenv cleanup: got %t, want %t", exists, test.initialValueExists)
+		}
+	}
+}
+
+func TestChdir(t *testing.T) {
+	oldwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+
+	t.Run("Chdir", func(t *testing.T) {
+		t.Chdir(dir)
+		wd, err := os.Getwd()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := filepath.Base(wd), filepath.Base(dir); got != want {
+			t.Errorf("working directory after t.Chdir: got %s, want %s", wd, dir)
+		}
+	})
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if wd != oldwd {
+		t.Errorf("working directory after t.Chdir cleanup: got %s, want %s", wd, oldwd)
+	}
+}
+
+func expectParallelConflict(t *testing.T) {
+	want := "cannot change the process state in parallel tests"
+	if got := recover(); got == nil {
+		t.Error("missing panic")
+	} else if s, ok := got.(string); !ok || !strings.Contains(s, want) {
+		t.Errorf("unexpected panic: got %v, want a message containing %q", got, want)
+	}
+}
+
+// The tests below call Setenv and Chdir through a testing.TB so that
+// the vet check for these mistakes does not reject them.
+
+func TestSetenvWithParallelAfterSetenv(t *testing.T) {
+	defer expectParallelConflict(t)
+	var tb testing.TB = t
+	tb.Setenv("GO_TEST_KEY_1", "value")
+	t.Parallel()
+}
+
+func TestSetenvWithParallelBeforeSetenv(t *testing.T) {
+	defer expectParallelConflict(t)
+	t.Parallel()
+	var tb testing.TB = t
+	tb.Setenv("GO_TEST_KEY_1", "value")
+}
+
+func TestChdirWithParallelParent(t *testing.T) {
+	t.Parallel()
+	t.Run("child", func(t *testing.T) {
+		defer expectParallelConflict(t)
+		var tb testing.TB = t
+		tb.Chdir(os.TempDir())
+	})
+}